@@ -24,3 +24,99 @@ const DisableTagsFlagName = "disable-tags"
 
 // DisableReadingTimeFlagName is the CLI flag name for disabling reading time estimation.
 const DisableReadingTimeFlagName = "disable-reading-time"
+
+// DraftsFlagName is the CLI flag name for including draft posts in the output.
+const DraftsFlagName = "drafts"
+
+// MathFlagName is the CLI flag name for rendering TeX math to MathML.
+const MathFlagName = "math"
+
+// ShortcodesFlagName is the CLI flag name for expanding {{< name >}} shortcodes.
+const ShortcodesFlagName = "shortcodes"
+
+// HighlightStyleFlagName is the CLI flag name for the chroma style of highlighted code.
+const HighlightStyleFlagName = "highlight-style"
+
+// HighlightDarkStyleFlagName is the CLI flag name for the chroma style of highlighted code in dark mode.
+const HighlightDarkStyleFlagName = "highlight-style-dark"
+
+// CopyButtonFlagName is the CLI flag name for adding copy buttons to code blocks.
+const CopyButtonFlagName = "copy-button"
+
+// DialectFlagName is the CLI flag name for selecting the markdown dialect.
+const DialectFlagName = "dialect"
+
+// EnableExtensionFlagName is the CLI flag name for switching on markdown extensions.
+const EnableExtensionFlagName = "enable-extension"
+
+// DisableExtensionFlagName is the CLI flag name for switching off markdown extensions.
+const DisableExtensionFlagName = "disable-extension"
+
+// HeadingShiftFlagName is the CLI flag name for shifting heading levels.
+const HeadingShiftFlagName = "heading-shift"
+
+// HeadingAnchorsFlagName is the CLI flag name for adding permalink anchors to headings.
+const HeadingAnchorsFlagName = "heading-anchors"
+
+// HeadingAnchorSymbolFlagName is the CLI flag name for the heading anchor symbol.
+const HeadingAnchorSymbolFlagName = "heading-anchor-symbol"
+
+// HeadingAnchorPositionFlagName is the CLI flag name for placing heading anchors before or after the text.
+const HeadingAnchorPositionFlagName = "heading-anchor-position"
+
+// HeadingAnchorMinLevelFlagName is the CLI flag name for the shallowest heading level given an anchor.
+const HeadingAnchorMinLevelFlagName = "heading-anchor-min-level"
+
+// HeadingAnchorLabelFlagName is the CLI flag name for the heading anchor aria-label.
+const HeadingAnchorLabelFlagName = "heading-anchor-label"
+
+// ExternalLinksFlagName is the CLI flag name for marking up links to other sites.
+const ExternalLinksFlagName = "external-links"
+
+// SiteHostFlagName is the CLI flag name for the host the blog is published on.
+const SiteHostFlagName = "site-host"
+
+// ExternalLinkRelFlagName is the CLI flag name for the rel values of external links.
+const ExternalLinkRelFlagName = "external-link-rel"
+
+// ExternalLinkNewTabFlagName is the CLI flag name for opening external links in a new tab.
+const ExternalLinkNewTabFlagName = "external-link-new-tab"
+
+// ExternalLinkClassFlagName is the CLI flag name for the CSS class of external links.
+const ExternalLinkClassFlagName = "external-link-class"
+
+// TrustedDomainFlagName is the CLI flag name for domains whose links are not marked as external.
+const TrustedDomainFlagName = "trusted-domain"
+
+// UnsafeHTMLFlagName is the CLI flag name for keeping sanitised raw HTML in posts.
+const UnsafeHTMLFlagName = "unsafe-html"
+
+// HTMLAllowFlagName is the CLI flag name for elements added to the raw HTML allowlist.
+const HTMLAllowFlagName = "html-allow"
+
+// SchemaFlagName is the CLI flag name for the front matter schema file.
+const SchemaFlagName = "schema"
+
+// StrictFlagName is the CLI flag name for failing the build when any post fails to parse.
+const StrictFlagName = "strict"
+
+// LenientFlagName is the CLI flag name for building from the posts that parse, skipping the rest.
+const LenientFlagName = "lenient"
+
+// ImagesFlagName is the CLI flag name for the responsive image pipeline.
+const ImagesFlagName = "images"
+
+// ImageWidthsFlagName is the CLI flag name for the widths images are scaled to.
+const ImageWidthsFlagName = "image-widths"
+
+// ImageSizesFlagName is the CLI flag name for the sizes attribute of responsive images.
+const ImageSizesFlagName = "image-sizes"
+
+// JobsFlagName is the CLI flag name for the number of parse and render workers.
+const JobsFlagName = "jobs"
+
+// CacheDirFlagName is the CLI flag name for the incremental build cache directory.
+const CacheDirFlagName = "cache-dir"
+
+// ClearCacheFlagName is the CLI flag name for emptying the build cache before building.
+const ClearCacheFlagName = "clear-cache"
@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/harrydayexe/GoBlog/v2/internal/utilities"
+	"github.com/harrydayexe/GoBlog/v2/pkg/cache"
 	"github.com/harrydayexe/GoBlog/v2/pkg/config"
 	"github.com/harrydayexe/GoBlog/v2/pkg/generator"
 	"github.com/harrydayexe/GoBlog/v2/pkg/outputter"
@@ -59,6 +60,110 @@ func NewGeneratorCommand(ctx context.Context, c *cli.Command) error {
 		opts = append(opts, config.WithDisableReadingTime())
 	}
 
+	if c.Bool(DraftsFlagName) {
+		opts = append(opts, config.WithDrafts())
+	}
+
+	if c.Bool(MathFlagName) {
+		opts = append(opts, config.WithMath())
+	}
+	if c.Bool(ShortcodesFlagName) {
+		opts = append(opts, config.WithShortcodes())
+	}
+
+	highlightOpt, err := utilities.HighlightStyleOption(
+		c.String(HighlightStyleFlagName),
+		c.String(HighlightDarkStyleFlagName),
+	)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, highlightOpt)
+
+	if c.Bool(CopyButtonFlagName) {
+		opts = append(opts, config.WithCopyButton())
+	}
+
+	if c.Bool(ExternalLinksFlagName) {
+		linksOpt, err := utilities.ExternalLinksOption(
+			c.String(SiteHostFlagName),
+			c.StringSlice(ExternalLinkRelFlagName),
+			c.Bool(ExternalLinkNewTabFlagName),
+			c.String(ExternalLinkClassFlagName),
+			c.StringSlice(TrustedDomainFlagName),
+		)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, linksOpt)
+	}
+
+	if c.Bool(UnsafeHTMLFlagName) {
+		htmlOpt, err := utilities.UnsafeHTMLOption(c.StringSlice(HTMLAllowFlagName))
+		if err != nil {
+			return err
+		}
+		opts = append(opts, htmlOpt)
+	}
+
+	if schema := c.String(SchemaFlagName); schema != "" {
+		opts = append(opts, config.WithSchema(schema))
+	}
+
+	buildOpts, err := utilities.BuildModeOptions(c.Bool(StrictFlagName), c.Bool(LenientFlagName))
+	if err != nil {
+		return err
+	}
+	opts = append(opts, buildOpts...)
+
+	if c.Bool(ImagesFlagName) {
+		imagesOpt, err := utilities.ImagesOption(
+			c.String(ImageSizesFlagName),
+			c.IntSlice(ImageWidthsFlagName),
+		)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, imagesOpt)
+	}
+
+	if jobs := c.Int(JobsFlagName); jobs != 0 {
+		opts = append(opts, config.WithJobs(jobs))
+	}
+
+	if cacheDir := c.String(CacheDirFlagName); cacheDir != "" {
+		if c.Bool(ClearCacheFlagName) {
+			if err := cache.Clear(cacheDir); err != nil {
+				return err
+			}
+		}
+		opts = append(opts, config.WithCache(cacheDir))
+	}
+
+	markdownOpts, err := utilities.MarkdownOptions(
+		c.String(DialectFlagName),
+		c.StringSlice(EnableExtensionFlagName),
+		c.StringSlice(DisableExtensionFlagName),
+		c.Int(HeadingShiftFlagName),
+	)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, markdownOpts...)
+
+	if c.Bool(HeadingAnchorsFlagName) {
+		anchorOpt, err := utilities.HeadingAnchorOption(
+			c.String(HeadingAnchorSymbolFlagName),
+			c.String(HeadingAnchorPositionFlagName),
+			c.Int(HeadingAnchorMinLevelFlagName),
+			c.String(HeadingAnchorLabelFlagName),
+		)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, anchorOpt)
+	}
+
 	templateDirPath := c.String(TemplateDirFlagName)
 	var templateDir fs.FS
 	if templateDirPath == "" {
@@ -7,6 +7,7 @@ package server
 import (
 	"time"
 
+	"github.com/harrydayexe/GoBlog/v2/pkg/images"
 	"github.com/urfave/cli/v3"
 )
 
@@ -58,6 +59,159 @@ var ServeCommand cli.Command = cli.Command{
 			Usage: "disable reading time estimation on posts",
 			Value: false,
 		},
+		&cli.BoolFlag{
+			Name:  DraftsFlagName,
+			Usage: "include posts marked as draft in their front matter",
+			Value: false,
+		},
+		&cli.BoolFlag{
+			Name:  MathFlagName,
+			Usage: "render $inline$ and $$display$$ TeX math to MathML",
+			Value: false,
+		},
+		&cli.BoolFlag{
+			Name:  ShortcodesFlagName,
+			Usage: "expand {{< name >}} shortcodes such as youtube, figure and details",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  HighlightStyleFlagName,
+			Usage: "chroma style for highlighted code",
+			Value: "github",
+		},
+		&cli.StringFlag{
+			Name:  HighlightDarkStyleFlagName,
+			Usage: "chroma style for highlighted code when the reader prefers dark mode (empty to use --highlight-style)",
+			Value: "github-dark",
+		},
+		&cli.BoolFlag{
+			Name:  CopyButtonFlagName,
+			Usage: "add a copy-to-clipboard button to highlighted code blocks",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  DialectFlagName,
+			Usage: "markdown dialect: commonmark, gfm or extended",
+			Value: "commonmark",
+		},
+		&cli.StringSliceFlag{
+			Name:  EnableExtensionFlagName,
+			Usage: "switch on markdown extensions (tables, strikethrough, tasklists, linkify, deflist, typographer, emoji, hardwraps, xhtml)",
+		},
+		&cli.StringSliceFlag{
+			Name:  DisableExtensionFlagName,
+			Usage: "switch off markdown extensions enabled by the dialect",
+		},
+		&cli.IntFlag{
+			Name:  HeadingShiftFlagName,
+			Usage: "move every heading down by this many levels",
+			Value: 0,
+		},
+		&cli.BoolFlag{
+			Name:  HeadingAnchorsFlagName,
+			Usage: "add a permalink anchor to each heading",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  HeadingAnchorSymbolFlagName,
+			Usage: "HTML shown as the heading anchor link",
+			Value: "#",
+		},
+		&cli.StringFlag{
+			Name:  HeadingAnchorPositionFlagName,
+			Usage: "place heading anchors before or after the heading text",
+			Value: "after",
+		},
+		&cli.IntFlag{
+			Name:  HeadingAnchorMinLevelFlagName,
+			Usage: "shallowest heading level given an anchor",
+			Value: 2,
+		},
+		&cli.StringFlag{
+			Name:  HeadingAnchorLabelFlagName,
+			Usage: "aria-label of heading anchors, with %s replaced by the heading text",
+			Value: "Permalink to %s",
+		},
+		&cli.BoolFlag{
+			Name:  ExternalLinksFlagName,
+			Usage: "add rel, target and class attributes to links to other sites",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  SiteHostFlagName,
+			Usage: "host the blog is published on, such as example.com; links to other hosts are external",
+		},
+		&cli.StringSliceFlag{
+			Name:  ExternalLinkRelFlagName,
+			Usage: "rel values of external links (noopener, noreferrer, nofollow, ugc, sponsored, external)",
+			Value: []string{"noopener"},
+		},
+		&cli.BoolFlag{
+			Name:  ExternalLinkNewTabFlagName,
+			Usage: "open external links in a new tab",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  ExternalLinkClassFlagName,
+			Usage: "CSS class of external links",
+		},
+		&cli.StringSliceFlag{
+			Name:  TrustedDomainFlagName,
+			Usage: "domains, with their subdomains, whose links are not treated as external (repeatable)",
+		},
+		&cli.BoolFlag{
+			Name:  UnsafeHTMLFlagName,
+			Usage: "keep raw HTML in posts, removing scripts, event handlers and elements not on the allowlist",
+			Value: false,
+		},
+		&cli.StringSliceFlag{
+			Name:  HTMLAllowFlagName,
+			Usage: "elements added to the raw HTML allowlist, as name or name[attr attr] (repeatable)",
+		},
+		&cli.StringFlag{
+			Name:  SchemaFlagName,
+			Usage: "YAML file of front matter rules posts must follow (default: schema.yaml in the posts directory)",
+		},
+		&cli.BoolFlag{
+			Name:  StrictFlagName,
+			Usage: "fail when any post fails to parse (the default)",
+			Value: false,
+		},
+		&cli.BoolFlag{
+			Name:  LenientFlagName,
+			Usage: "build from the posts that parse, logging each skipped file as a warning",
+			Value: false,
+		},
+		&cli.BoolFlag{
+			Name:  ImagesFlagName,
+			Usage: "strip metadata from bundle JPEG and PNG images and give them resized srcset variants",
+			Value: false,
+		},
+		&cli.IntSliceFlag{
+			Name:  ImageWidthsFlagName,
+			Usage: "widths in pixels of the resized image variants",
+			Value: images.DefaultWidths(),
+		},
+		&cli.StringFlag{
+			Name:  ImageSizesFlagName,
+			Usage: "sizes attribute of responsive images",
+			Value: images.DefaultSizes,
+		},
+		&cli.IntFlag{
+			Name:    JobsFlagName,
+			Aliases: []string{"j"},
+			Usage:   "number of posts to parse and pages to render at once (0 for one per CPU)",
+			Value:   0,
+		},
+		&cli.StringFlag{
+			Name:  CacheDirFlagName,
+			Usage: "directory in which to cache parsed posts and rendered pages between builds",
+		},
+		&cli.BoolFlag{
+			Name:  ClearCacheFlagName,
+			Usage: "empty the --cache-dir cache before building",
+			Value: false,
+		},
 		&cli.BoolFlag{
 			Name:    WatchFlagName,
 			Aliases: []string{"w"},
@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/harrydayexe/GoBlog/v2/internal/utilities"
+	"github.com/harrydayexe/GoBlog/v2/pkg/cache"
 	"github.com/harrydayexe/GoBlog/v2/pkg/config"
 	"github.com/harrydayexe/GoBlog/v2/pkg/server"
 	"github.com/harrydayexe/GoBlog/v2/pkg/templates"
@@ -51,6 +52,110 @@ func NewServeCommand(ctx context.Context, c *cli.Command) error {
 	if c.Bool(DisableReadingTimeFlagName) {
 		cfg.Gen = append(cfg.Gen, config.WithDisableReadingTime())
 	}
+
+	if c.Bool(DraftsFlagName) {
+		cfg.Gen = append(cfg.Gen, config.WithDrafts())
+	}
+
+	if c.Bool(MathFlagName) {
+		cfg.Gen = append(cfg.Gen, config.WithMath())
+	}
+	if c.Bool(ShortcodesFlagName) {
+		cfg.Gen = append(cfg.Gen, config.WithShortcodes())
+	}
+
+	highlightOpt, err := utilities.HighlightStyleOption(
+		c.String(HighlightStyleFlagName),
+		c.String(HighlightDarkStyleFlagName),
+	)
+	if err != nil {
+		return err
+	}
+	cfg.Gen = append(cfg.Gen, highlightOpt)
+
+	if c.Bool(CopyButtonFlagName) {
+		cfg.Gen = append(cfg.Gen, config.WithCopyButton())
+	}
+
+	if c.Bool(ExternalLinksFlagName) {
+		linksOpt, err := utilities.ExternalLinksOption(
+			c.String(SiteHostFlagName),
+			c.StringSlice(ExternalLinkRelFlagName),
+			c.Bool(ExternalLinkNewTabFlagName),
+			c.String(ExternalLinkClassFlagName),
+			c.StringSlice(TrustedDomainFlagName),
+		)
+		if err != nil {
+			return err
+		}
+		cfg.Gen = append(cfg.Gen, linksOpt)
+	}
+
+	if c.Bool(UnsafeHTMLFlagName) {
+		htmlOpt, err := utilities.UnsafeHTMLOption(c.StringSlice(HTMLAllowFlagName))
+		if err != nil {
+			return err
+		}
+		cfg.Gen = append(cfg.Gen, htmlOpt)
+	}
+
+	if schema := c.String(SchemaFlagName); schema != "" {
+		cfg.Gen = append(cfg.Gen, config.WithSchema(schema))
+	}
+
+	buildOpts, err := utilities.BuildModeOptions(c.Bool(StrictFlagName), c.Bool(LenientFlagName))
+	if err != nil {
+		return err
+	}
+	cfg.Gen = append(cfg.Gen, buildOpts...)
+
+	if c.Bool(ImagesFlagName) {
+		imagesOpt, err := utilities.ImagesOption(
+			c.String(ImageSizesFlagName),
+			c.IntSlice(ImageWidthsFlagName),
+		)
+		if err != nil {
+			return err
+		}
+		cfg.Gen = append(cfg.Gen, imagesOpt)
+	}
+
+	if jobs := c.Int(JobsFlagName); jobs != 0 {
+		cfg.Gen = append(cfg.Gen, config.WithJobs(jobs))
+	}
+
+	if cacheDir := c.String(CacheDirFlagName); cacheDir != "" {
+		if c.Bool(ClearCacheFlagName) {
+			if err := cache.Clear(cacheDir); err != nil {
+				return err
+			}
+		}
+		cfg.Gen = append(cfg.Gen, config.WithCache(cacheDir))
+	}
+
+	markdownOpts, err := utilities.MarkdownOptions(
+		c.String(DialectFlagName),
+		c.StringSlice(EnableExtensionFlagName),
+		c.StringSlice(DisableExtensionFlagName),
+		c.Int(HeadingShiftFlagName),
+	)
+	if err != nil {
+		return err
+	}
+	cfg.Gen = append(cfg.Gen, markdownOpts...)
+
+	if c.Bool(HeadingAnchorsFlagName) {
+		anchorOpt, err := utilities.HeadingAnchorOption(
+			c.String(HeadingAnchorSymbolFlagName),
+			c.String(HeadingAnchorPositionFlagName),
+			c.Int(HeadingAnchorMinLevelFlagName),
+			c.String(HeadingAnchorLabelFlagName),
+		)
+		if err != nil {
+			return err
+		}
+		cfg.Gen = append(cfg.Gen, anchorOpt)
+	}
 	cfg.Server = append(cfg.Server, config.WithPort(c.Int(PortFlagName)))
 	cfg.Server = append(cfg.Server, config.WithCacheControl(c.Duration(CacheControlFlagName)))
 
@@ -0,0 +1,117 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package utilities
+
+import (
+	"fmt"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/config"
+	"github.com/harrydayexe/GoBlog/v2/pkg/parser"
+)
+
+// MarkdownOptions validates the markdown dialect flags shared by the generate
+// and serve commands and returns the generator options they select. Empty
+// and zero inputs leave the parser defaults in place.
+func MarkdownOptions(dialect string, enable, disable []string, headingShift int) ([]config.GeneratorOption, error) {
+	var opts []config.GeneratorOption
+	if dialect != "" {
+		if _, err := parser.ParseDialect(dialect); err != nil {
+			return nil, err
+		}
+		opts = append(opts, config.WithDialect(dialect))
+	}
+	for _, names := range []struct {
+		names  []string
+		enable bool
+	}{{enable, true}, {disable, false}} {
+		for _, name := range names.names {
+			if _, err := parser.ParseExtension(name); err != nil {
+				return nil, err
+			}
+			opts = append(opts, config.WithMarkdownExtension(name, names.enable))
+		}
+	}
+	if headingShift != 0 {
+		opts = append(opts, config.WithHeadingShift(headingShift))
+	}
+	return opts, nil
+}
+
+// HeadingAnchorOption validates the heading anchor flags shared by the
+// generate and serve commands and returns the generator option they select.
+// Empty and zero inputs keep the parser defaults.
+func HeadingAnchorOption(symbol, position string, minLevel int, label string) (config.GeneratorOption, error) {
+	if position != "" {
+		if _, err := parser.ParseAnchorPosition(position); err != nil {
+			return config.GeneratorOption{}, err
+		}
+	}
+	if minLevel < 0 || minLevel > 6 {
+		return config.GeneratorOption{}, fmt.Errorf("heading anchor minimum level %d is not between 1 and 6", minLevel)
+	}
+	return config.WithHeadingAnchors(symbol, position, minLevel, label), nil
+}
+
+// HighlightStyleOption validates the highlight style flags shared by the
+// generate and serve commands and returns the generator option they select.
+// An empty dark style keeps the light style in dark mode.
+func HighlightStyleOption(light, dark string) (config.GeneratorOption, error) {
+	for _, style := range []string{light, dark} {
+		if style == "" {
+			continue
+		}
+		if _, err := parser.ParseHighlightStyle(style); err != nil {
+			return config.GeneratorOption{}, err
+		}
+	}
+	return config.WithHighlightStyle(light, dark), nil
+}
+
+// ExternalLinksOption validates the external link flags shared by the generate
+// and serve commands and returns the generator option they select.
+func ExternalLinksOption(site string, rel []string, newTab bool, class string, trusted []string) (config.GeneratorOption, error) {
+	for _, r := range rel {
+		if _, err := parser.ParseLinkRel(r); err != nil {
+			return config.GeneratorOption{}, err
+		}
+	}
+	return config.WithExternalLinks(site, rel, newTab, class, trusted...), nil
+}
+
+// UnsafeHTMLOption validates the raw HTML allowlist flag shared by the
+// generate and serve commands and returns the generator option it selects.
+func UnsafeHTMLOption(allow []string) (config.GeneratorOption, error) {
+	for _, spec := range allow {
+		if _, _, err := parser.ParseHTMLAllow(spec); err != nil {
+			return config.GeneratorOption{}, err
+		}
+	}
+	return config.WithUnsafeHTML(allow...), nil
+}
+
+// BuildModeOptions validates the --strict and --lenient flags shared by the
+// generate and serve commands and returns the generator options they select.
+// Strict is the default, so only --lenient adds an option.
+func BuildModeOptions(strict, lenient bool) ([]config.GeneratorOption, error) {
+	if strict && lenient {
+		return nil, fmt.Errorf("--strict and --lenient cannot be used together")
+	}
+	if lenient {
+		return []config.GeneratorOption{config.WithLenient()}, nil
+	}
+	return nil, nil
+}
+
+// ImagesOption validates the responsive image flags shared by the generate and
+// serve commands and returns the generator option they select. An empty sizes
+// and no widths keep the defaults.
+func ImagesOption(sizes string, widths []int) (config.GeneratorOption, error) {
+	for _, w := range widths {
+		if w <= 0 {
+			return config.GeneratorOption{}, fmt.Errorf("image width %d is not a positive number of pixels", w)
+		}
+	}
+	return config.WithImages(sizes, widths...), nil
+}
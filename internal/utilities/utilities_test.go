@@ -347,3 +347,268 @@ func TestCliErrorHandler_NonInputDirectoryError(t *testing.T) {
 		t.Errorf("expected stderr to contain error message, got: %q", bufErr.String())
 	}
 }
+
+// TestMarkdownOptions tests validation of the markdown dialect flags.
+func TestMarkdownOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		dialect      string
+		enable       []string
+		disable      []string
+		headingShift int
+		wantOpts     int
+		wantErr      string
+	}{
+		{name: "defaults", wantOpts: 0},
+		{name: "all set", dialect: "gfm", enable: []string{"deflist"}, disable: []string{"linkify", "hardwraps"}, headingShift: 1, wantOpts: 5},
+		{name: "unknown dialect", dialect: "markdown2", wantErr: `unknown markdown dialect "markdown2"`},
+		{name: "unknown enabled extension", enable: []string{"footnotes"}, wantErr: `unknown markdown extension "footnotes"`},
+		{name: "unknown disabled extension", disable: []string{"Tables"}, wantErr: `unknown markdown extension "Tables"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts, err := MarkdownOptions(tt.dialect, tt.enable, tt.disable, tt.headingShift)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("MarkdownOptions() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MarkdownOptions() unexpected error: %v", err)
+			}
+			if len(opts) != tt.wantOpts {
+				t.Errorf("MarkdownOptions() returned %d options, want %d", len(opts), tt.wantOpts)
+			}
+		})
+	}
+}
+
+func TestHeadingAnchorOption(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		position string
+		minLevel int
+		wantErr  string
+	}{
+		{name: "defaults"},
+		{name: "before", position: "before", minLevel: 3},
+		{name: "unknown position", position: "left", wantErr: `unknown heading anchor position "left"`},
+		{name: "level too deep", minLevel: 7, wantErr: "heading anchor minimum level 7 is not between 1 and 6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opt, err := HeadingAnchorOption("#", tt.position, tt.minLevel, "")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("HeadingAnchorOption() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HeadingAnchorOption() unexpected error: %v", err)
+			}
+			if opt.WithHeadingAnchorsFunc == nil {
+				t.Error("HeadingAnchorOption() did not set WithHeadingAnchorsFunc")
+			}
+		})
+	}
+}
+
+func TestHighlightStyleOption(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		light   string
+		dark    string
+		wantErr string
+	}{
+		{name: "light and dark", light: "github", dark: "github-dark"},
+		{name: "light only", light: "monokailight"},
+		{name: "unknown light", light: "nope", dark: "github-dark", wantErr: `unknown highlight style "nope"`},
+		{name: "unknown dark", light: "github", dark: "GitHub Dark", wantErr: `unknown highlight style "GitHub Dark"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opt, err := HighlightStyleOption(tt.light, tt.dark)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("HighlightStyleOption() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HighlightStyleOption() unexpected error: %v", err)
+			}
+			if opt.WithHighlightFunc == nil {
+				t.Error("HighlightStyleOption() did not set WithHighlightFunc")
+			}
+		})
+	}
+}
+
+// TestImagesOption verifies that ImagesOption rejects widths that are not
+// positive.
+func TestImagesOption(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		sizes   string
+		widths  []int
+		wantErr string
+	}{
+		{name: "defaults"},
+		{name: "widths and sizes", sizes: "100vw", widths: []int{640, 1280}},
+		{name: "zero width", widths: []int{640, 0}, wantErr: "image width 0"},
+		{name: "negative width", widths: []int{-480}, wantErr: "image width -480"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opt, err := ImagesOption(tt.sizes, tt.widths)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ImagesOption() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ImagesOption() unexpected error: %v", err)
+			}
+			if opt.WithImagesFunc == nil {
+				t.Error("ImagesOption() did not set WithImagesFunc")
+			}
+		})
+	}
+}
+
+// TestExternalLinksOption verifies that ExternalLinksOption rejects unknown
+// rel values.
+func TestExternalLinksOption(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		rel     []string
+		wantErr string
+	}{
+		{name: "no rel"},
+		{name: "known rels", rel: []string{"noopener", "nofollow", "ugc"}},
+		{name: "unknown rel", rel: []string{"nofollow", "bookmark"}, wantErr: `unknown link rel "bookmark"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opt, err := ExternalLinksOption("example.com", tt.rel, true, "external", []string{"github.com"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ExternalLinksOption() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExternalLinksOption() unexpected error: %v", err)
+			}
+			if opt.WithExternalLinksFunc == nil {
+				t.Error("ExternalLinksOption() did not set WithExternalLinksFunc")
+			}
+		})
+	}
+}
+
+// TestUnsafeHTMLOption verifies that UnsafeHTMLOption rejects malformed
+// allowlist entries.
+func TestUnsafeHTMLOption(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		allow   []string
+		wantErr string
+	}{
+		{name: "no extra elements"},
+		{name: "names and attributes", allow: []string{"dl", "video[src controls]", "div[data-*]"}},
+		{name: "missing bracket", allow: []string{"video[src"}, wantErr: "missing ]"},
+		{name: "bad name", allow: []string{"<script>"}, wantErr: `invalid element "<script>"`},
+		{name: "bad attribute", allow: []string{"a[href=x]"}, wantErr: `invalid attribute "href=x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opt, err := UnsafeHTMLOption(tt.allow)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("UnsafeHTMLOption() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnsafeHTMLOption() unexpected error: %v", err)
+			}
+			if opt.WithUnsafeHTMLFunc == nil {
+				t.Error("UnsafeHTMLOption() did not set WithUnsafeHTMLFunc")
+			}
+		})
+	}
+}
+
+// TestBuildModeOptions verifies that BuildModeOptions rejects --strict with
+// --lenient and only adds an option for --lenient.
+func TestBuildModeOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		strict      bool
+		lenient     bool
+		wantLenient bool
+		wantErr     string
+	}{
+		{name: "default"},
+		{name: "strict", strict: true},
+		{name: "lenient", lenient: true, wantLenient: true},
+		{name: "both", strict: true, lenient: true, wantErr: "cannot be used together"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts, err := BuildModeOptions(tt.strict, tt.lenient)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("BuildModeOptions() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildModeOptions() unexpected error: %v", err)
+			}
+			gotLenient := len(opts) == 1 && opts[0].WithLenientFunc != nil
+			if gotLenient != tt.wantLenient || (!tt.wantLenient && len(opts) != 0) {
+				t.Errorf("BuildModeOptions() = %d options, want lenient %t", len(opts), tt.wantLenient)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package workpool runs indexed tasks on a bounded number of goroutines.
+package workpool
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Run calls fn for each index from 0 to n-1 with at most jobs calls running
+// at once. When jobs is zero or negative, runtime.GOMAXPROCS(0) is used.
+//
+// Indices are started in order. Once ctx is done, or fn has returned an
+// error, no further indices are started; Run waits for the calls already
+// running and returns ctx.Err(), or else the error of the lowest failing
+// index. Since every index below a failing one has been started, that error
+// does not depend on scheduling.
+//
+// fn must only write state belonging to its own index.
+func Run(ctx context.Context, jobs, n int, fn func(i int) error) error {
+	if jobs <= 0 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+
+	errs := make([]error, n)
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, jobs)
+
+	for i := range n {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil || failed.Load() {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(i); err != nil {
+				errs[i] = err
+				failed.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package workpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRun_AllIndices tests that every index is processed exactly once.
+func TestRun_AllIndices(t *testing.T) {
+	t.Parallel()
+
+	for _, jobs := range []int{0, 1, 3, 100} {
+		t.Run(fmt.Sprintf("jobs=%d", jobs), func(t *testing.T) {
+			t.Parallel()
+
+			got := make([]int, 50)
+			if err := Run(context.Background(), jobs, len(got), func(i int) error {
+				got[i]++
+				return nil
+			}); err != nil {
+				t.Fatalf("Run() error = %v, want nil", err)
+			}
+			for i, n := range got {
+				if n != 1 {
+					t.Errorf("index %d processed %d times, want 1", i, n)
+				}
+			}
+		})
+	}
+}
+
+// TestRun_BoundsConcurrency tests that no more than jobs calls run at once.
+func TestRun_BoundsConcurrency(t *testing.T) {
+	t.Parallel()
+
+	var running, peak atomic.Int32
+	err := Run(context.Background(), 2, 20, func(i int) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", p)
+	}
+}
+
+// TestRun_LowestError tests that the error of the lowest failing index is
+// returned whatever order the calls finish in.
+func TestRun_LowestError(t *testing.T) {
+	t.Parallel()
+
+	for range 20 {
+		err := Run(context.Background(), 4, 10, func(i int) error {
+			if i == 3 || i == 5 {
+				time.Sleep(time.Duration(5-i) * time.Millisecond)
+				return fmt.Errorf("task %d", i)
+			}
+			return nil
+		})
+		if err == nil || err.Error() != "task 3" {
+			t.Fatalf("Run() error = %v, want task 3", err)
+		}
+	}
+}
+
+// TestRun_Canceled tests that a canceled context stops Run and is reported.
+func TestRun_Canceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int32
+	err := Run(ctx, 1, 100, func(i int) error {
+		if calls.Add(1) == 3 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	if n := calls.Load(); n > 4 {
+		t.Errorf("Run() made %d calls after cancellation, want it to stop promptly", n)
+	}
+}
@@ -0,0 +1,162 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Store is a directory of cache entries. Create one with Open.
+type Store struct {
+	dir string
+
+	mu   sync.Mutex
+	used map[string]bool // Entries read or written since Open, as bucket/key
+}
+
+// Open returns a Store backed by dir, creating the directory if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &Store{dir: dir, used: make(map[string]bool)}, nil
+}
+
+// Dir returns the directory the Store is backed by.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Key returns the hex SHA-256 hash of parts, for use as an entry key. Each
+// part is length-prefixed, so ("ab", "c") and ("a", "bc") give different keys.
+func Key(parts ...[]byte) string {
+	h := sha256.New()
+	var n [8]byte
+	for _, part := range parts {
+		binary.BigEndian.PutUint64(n[:], uint64(len(part)))
+		h.Write(n[:])
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the entry stored under key in bucket, and whether there was
+// one. An entry that cannot be read is treated as missing.
+func (s *Store) Get(bucket, key string) ([]byte, bool) {
+	data, err := os.ReadFile(filepath.Join(s.dir, bucket, key))
+	if err != nil {
+		return nil, false
+	}
+	s.markUsed(bucket, key)
+	return data, true
+}
+
+// Put stores data under key in bucket. The entry is written to a temporary
+// file and renamed into place, so a concurrent Get never sees it half
+// written.
+func (s *Store) Put(bucket, key string, data []byte) error {
+	dir := filepath.Join(s.dir, bucket)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache bucket: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, key+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, key)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	s.markUsed(bucket, key)
+	return nil
+}
+
+// Prune removes every entry that has not been read or written since the
+// Store was opened, so that the cache only holds what the last build used.
+func (s *Store) Prune() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return removeEntries(s.dir, func(bucket, key string) bool {
+		return !s.used[bucket+"/"+key]
+	})
+}
+
+// Clear removes every cache entry in dir. It leaves anything that is not a
+// cache entry in place, and succeeds if dir does not exist.
+func Clear(dir string) error {
+	return removeEntries(dir, func(bucket, key string) bool { return true })
+}
+
+// markUsed records that an entry belongs to the current build.
+func (s *Store) markUsed(bucket, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used[bucket+"/"+key] = true
+}
+
+// removeEntries removes the entries in the buckets of dir for which remove
+// reports true, along with any temporary files left by an interrupted Put.
+// Files whose names are not keys are left alone, so pointing the cache at
+// the wrong directory cannot delete anything else.
+func removeEntries(dir string, remove func(bucket, key string) bool) error {
+	buckets, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var errs []error
+	for _, bucket := range buckets {
+		if !bucket.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(dir, bucket.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, entry := range entries {
+			key, _, temp := strings.Cut(entry.Name(), ".tmp")
+			if entry.IsDir() || !isKey(key) {
+				continue
+			}
+			if temp || remove(bucket.Name(), key) {
+				if err := os.Remove(filepath.Join(dir, bucket.Name(), entry.Name())); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to remove cache entries: %w", err)
+	}
+	return nil
+}
+
+// isKey reports whether name has the form of a key returned by Key.
+func isKey(name string) bool {
+	if len(name) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
@@ -0,0 +1,124 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestKey tests that keys are stable and that part boundaries matter.
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	a := Key([]byte("ab"), []byte("c"))
+	if a != Key([]byte("ab"), []byte("c")) {
+		t.Error("Key() should return the same key for the same parts")
+	}
+	if a == Key([]byte("a"), []byte("bc")) {
+		t.Error("Key() should depend on where parts are split")
+	}
+	if !isKey(a) {
+		t.Errorf("Key() = %q, want 64 hex digits", a)
+	}
+}
+
+// TestStore_GetPut tests storing and reading back entries.
+func TestStore_GetPut(t *testing.T) {
+	t.Parallel()
+
+	s, err := Open(filepath.Join(t.TempDir(), "cache"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	key := Key([]byte("post"))
+
+	if _, ok := s.Get("posts", key); ok {
+		t.Error("Get() on an empty store should miss")
+	}
+	if err := s.Put("posts", key, []byte("data")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if data, ok := s.Get("posts", key); !ok || string(data) != "data" {
+		t.Errorf("Get() = %q, %v, want data, true", data, ok)
+	}
+	if _, ok := s.Get("pages", key); ok {
+		t.Error("Get() should not find an entry in a different bucket")
+	}
+}
+
+// TestStore_Prune tests that only entries used since Open survive Prune.
+func TestStore_Prune(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	old, used, fresh := Key([]byte("old")), Key([]byte("used")), Key([]byte("fresh"))
+
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for _, key := range []string{old, used} {
+		if err := s.Put("posts", key, []byte(key)); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	s, err = Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	s.Get("posts", used)
+	if err := s.Put("pages", fresh, []byte(fresh)); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := s.Prune(); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	if _, ok := s.Get("posts", old); ok {
+		t.Error("Prune() should remove entries not used since Open")
+	}
+	if _, ok := s.Get("posts", used); !ok {
+		t.Error("Prune() should keep entries read since Open")
+	}
+	if _, ok := s.Get("pages", fresh); !ok {
+		t.Error("Prune() should keep entries written since Open")
+	}
+}
+
+// TestClear tests that Clear removes cache entries and nothing else.
+func TestClear(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	key := Key([]byte("post"))
+	if err := s.Put("posts", key, []byte("data")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	other := filepath.Join(dir, "posts", "notes.txt")
+	if err := os.WriteFile(other, []byte("keep"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if err := Clear(dir); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if _, ok := s.Get("posts", key); ok {
+		t.Error("Clear() should remove cache entries")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("Clear() should leave other files alone: %v", err)
+	}
+
+	if err := Clear(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("Clear() of a missing directory error = %v, want nil", err)
+	}
+}
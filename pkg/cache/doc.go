@@ -0,0 +1,40 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package cache provides the on-disk store behind incremental builds.
+//
+// A Store keeps opaque entries in a directory, grouped into buckets and named
+// by a content hash built with Key. Entries are never updated in place: when
+// anything that went into a key changes, the key changes too, so a stale
+// entry is simply never read again. The generator uses three buckets: one for
+// parsed posts keyed by the post's source, the parser configuration and the
+// bundle's file names, one for rendered pages keyed by the template set and
+// the data passed to the template, and, with config.WithImages, one for
+// processed images keyed by their content and the configured widths.
+//
+// # Basic Usage
+//
+// Most callers never use a Store directly; they pass a directory to the
+// generator:
+//
+//	gen := generator.New(postsFS, renderer, config.WithCache(".goblog-cache"))
+//
+// Each Generate call opens the directory, reuses the posts and pages whose
+// inputs have not changed, and at the end removes the entries it did not use.
+//
+// # Invalidation
+//
+// Keys cover everything the cache can see, but not the behaviour of custom
+// template functions registered with config.WithFuncs. If one of those
+// changes, or the cache is suspected to be stale for any other reason, call
+// Clear before generating:
+//
+//	if err := cache.Clear(".goblog-cache"); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// The goblog CLI does this when run with --clear-cache.
+//
+// A Store is safe for concurrent use by multiple goroutines.
+package cache
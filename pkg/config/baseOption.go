@@ -4,7 +4,10 @@
 
 package config
 
-import "log/slog"
+import (
+	"log/slog"
+	"time"
+)
 
 // BaseOption represents a configuration option that can be applied to
 // many different instances during construction.
@@ -14,10 +17,11 @@ import "log/slog"
 // modify specific configuration fields.
 //
 // This type should not be constructed directly by users. Instead, use the
-// provided option functions like WithBlogRoot() or WithLogger().
+// provided option functions like WithBlogRoot(), WithLogger() or WithClock().
 type BaseOption struct {
 	WithBlogRootFunc func(v *BlogRoot)
 	WithLoggerFunc   func(v *Logger)
+	WithClockFunc    func(v *Clock)
 }
 
 // Logger is a configuration type that holds a [log/slog.Logger] for structured
@@ -83,3 +87,35 @@ func (o BlogRoot) AsOption() BaseOption {
 func (o Logger) AsOption() BaseOption {
 	return WithLogger(o.Logger)
 }
+
+// Clock is a configuration type that holds the function used to read the
+// current time.
+//
+// The generator uses it to decide which posts are live (scheduled posts with a
+// future date and expired posts are hidden) and the server uses it to schedule
+// regeneration when the next post becomes due. When no clock is supplied,
+// constructors fall back to [time.Now] at construction time.
+type Clock struct{ Now func() time.Time }
+
+// WithClock returns a BaseOption that sets the function used to read the
+// current time. It is mainly useful in tests, where a fixed or controllable
+// clock makes scheduled publishing deterministic.
+//
+// Passing nil is permitted and is treated the same as not supplying the option:
+// the component falls back to [time.Now] at construction time.
+//
+// Example usage:
+//
+//	fixed := func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+//	gen := generator.New(fsys, renderer, config.WithClock(fixed).AsGeneratorOption())
+func WithClock(now func() time.Time) BaseOption {
+	return BaseOption{
+		WithClockFunc: func(v *Clock) { v.Now = now },
+	}
+}
+
+// AsOption returns a BaseOption that re-applies this Clock value to another
+// component, enabling a resolved clock to be forwarded to sub-components.
+func (o Clock) AsOption() BaseOption {
+	return WithClock(o.Now)
+}
@@ -41,6 +41,70 @@
 // suppress the "· N min read" annotation next to each post date. Reading time
 // is enabled by default (220 WPM, rounded up, 1-minute minimum).
 //
+// WithDrafts() includes posts marked draft: true in their front matter. Drafts
+// are excluded from post, index, and tag output by default; when included, the
+// default templates mark them with a "Draft" badge via models.Post.Draft.
+//
+// WithMath() renders $inline$ and $$display$$ TeX math in posts to MathML when
+// they are parsed, so pages need no JavaScript to display it. A post with an
+// expression that cannot be parsed fails with an error giving its line.
+//
+// WithShortcodes() expands Hugo-style shortcodes such as {{< youtube id >}},
+// {{< figure src="cat.jpg" >}} and {{< details >}}...{{< /details >}} in
+// posts. Templates under shortcodes/ in the template directory add shortcodes
+// of their own, and enable shortcodes without the option. A post using an
+// unknown shortcode fails with an error giving its line.
+//
+// WithHighlightStyle(light, dark string) highlights code blocks and selects the
+// chroma styles of the generated chroma.css stylesheet: light by default, and
+// dark when the reader prefers a dark colour scheme. WithCopyButton() adds a
+// copy-to-clipboard button to each highlighted code block.
+//
+// WithDialect(name string) selects the markdown dialect posts are written in:
+// "commonmark" (the default), "gfm" or "extended". WithMarkdownExtension(name,
+// enable) switches a single extension such as "tables" or "hardwraps" on or
+// off on top of the dialect, and WithHeadingShift(n) moves every heading down
+// by n levels.
+//
+// WithHeadingAnchors(symbol, position string, minLevel int, label string) puts
+// an accessible permalink inside each heading of level minLevel or deeper,
+// before or after its text. Empty and zero arguments keep the defaults: a "#"
+// after the text of headings from level 2, labelled "Permalink to <heading>".
+//
+// WithExternalLinks(site string, rel []string, newTab bool, class string,
+// trusted ...string) adds rel values, target="_blank" and a class to links
+// whose host is neither site nor a trusted domain.
+//
+// WithUnsafeHTML(allow ...string) keeps raw HTML written in posts, passing
+// the rendered HTML through an allowlist sanitiser that keeps elements such as
+// <details>, <kbd>, <sup> and <video> and removes scripts, event handlers and
+// javascript: URLs. Each allow value adds an element, such as "dl" or
+// "video[src controls]", to the allowlist.
+//
+// WithSchema(path string) checks the front matter of every post against the
+// required keys, types, allowed values, length limits and patterns declared
+// in the YAML file at path. Without it, a schema.yaml file at the root of the
+// posts directory is used when there is one.
+//
+// WithLenient() builds the blog from the posts that parse instead of failing
+// when any post does. Each file left out is logged as a warning and listed in
+// GeneratedBlog.Skipped.
+//
+// WithImages(sizes string, widths ...int) publishes the JPEG and PNG files of
+// page bundles with their EXIF and GPS metadata removed, alongside copies
+// scaled to each width, and gives the images in posts intrinsic sizes,
+// loading="lazy" and a srcset of the copies. An image that cannot be
+// processed is published unchanged with a warning.
+//
+// WithJobs(n int) bounds the number of posts parsed, and pages rendered, at
+// the same time. The default of zero uses runtime.GOMAXPROCS(0) workers.
+// Output and error order do not depend on n.
+//
+// WithCache(dir string) keeps parsed posts and rendered pages in dir between
+// Generate calls, keyed by a hash of their inputs, so that unchanged posts skip
+// markdown conversion and unchanged pages are not rendered again. Use
+// cache.Clear to invalidate it by hand.
+//
 // WithSiteTitle(title string) sets the site title used in generated HTML
 // pages and templates.
 //
@@ -66,12 +130,18 @@
 // each constructor falls back to slog.Default() at construction time. Passing
 // nil has the same effect as omitting the option.
 //
+// WithClock(now func() time.Time) is a BaseOption that sets the function used
+// to read the current time. The generator hides posts whose date is in the
+// future or whose expiryDate has passed, and the HTTP server regenerates the
+// blog on its own when the next scheduled post becomes due or expires. When
+// not supplied, time.Now is used.
+//
 // WithFuncs(funcs template.FuncMap) is a RendererOption that registers
 // additional template functions for use in all templates. Functions are merged
-// into the built-in FuncMap (formatDate, shortDate, year). A function whose
-// name matches a built-in silently replaces it. Pass RendererOption values to
-// generator.NewTemplateRenderer, or to ServerConfig.RendererOpts for the HTTP
-// server path.
+// into the built-in FuncMap (formatDate, shortDate, year and the param*
+// helpers). A function whose name matches a built-in silently replaces it.
+// Pass RendererOption values to generator.NewTemplateRenderer, or to
+// ServerConfig.RendererOpts for the HTTP server path.
 //
 // WithHTMLPaths() is a GeneratorOption that switches BaseData.Path values to
 // use .html file extensions instead of clean URLs. When enabled, the index
@@ -99,11 +169,15 @@
 // # Option types
 //
 // GeneratorOption carries options for generator.New and outputter.NewDirectoryWriter,
-// including WithRawOutput, WithDisableTags, WithDisableReadingTime, WithSiteTitle,
-// WithEnvironment, WithCustomData, WithHTMLPaths, and (via the embedded BaseOption)
-// WithLogger and WithBlogRoot.
+// including WithRawOutput, WithDisableTags, WithDisableReadingTime, WithDrafts,
+// WithMath, WithShortcodes, WithHighlightStyle, WithCopyButton, WithDialect,
+// WithMarkdownExtension, WithHeadingShift, WithHeadingAnchors,
+// WithExternalLinks, WithUnsafeHTML, WithSchema, WithLenient, WithImages,
+// WithJobs, WithCache, WithSiteTitle, WithEnvironment, WithCustomData,
+// WithHTMLPaths, and (via the embedded BaseOption) WithLogger, WithBlogRoot and
+// WithClock.
 // BaseServerOption carries options for the HTTP server (port, host, middleware,
-// cache-control TTL, health-check endpoints, and via the embedded BaseOption: WithLogger, WithBlogRoot).
+// cache-control TTL, health-check endpoints, and via the embedded BaseOption: WithLogger, WithBlogRoot, WithClock).
 // WatcherOption carries options for watcher.New (debounce, and via the embedded
 // BaseOption: WithLogger, WithBlogRoot).
 // RendererOption carries options for generator.NewTemplateRenderer (custom funcs).
@@ -14,7 +14,11 @@ package config
 // This type should not be constructed directly by users. Instead, use the
 // provided option functions like WithRawOutput(), WithDisableTags(),
 // WithDisableReadingTime(), WithSiteTitle(), WithEnvironment(), WithCustomData(),
-// or call [BaseOption.AsGeneratorOption] on a [BaseOption] value.
+// WithDrafts(), WithMath(), WithShortcodes(), WithHighlightStyle(),
+// WithCopyButton(), WithDialect(), WithMarkdownExtension(), WithHeadingShift(),
+// WithHeadingAnchors(), WithExternalLinks(), WithUnsafeHTML(), WithSchema(),
+// WithLenient(), WithImages(), WithJobs(), WithCache(), or call
+// [BaseOption.AsGeneratorOption] on a [BaseOption] value.
 type GeneratorOption struct {
 	BaseOption
 
@@ -25,6 +29,20 @@ type GeneratorOption struct {
 	WithEnvironmentFunc        func(v *Environment)
 	WithCustomDataFunc         func(v *CustomData)
 	WithHTMLPathsFunc          func(v *HTMLPaths)
+	WithDraftsFunc             func(v *Drafts)
+	WithMathFunc               func(v *Math)
+	WithShortcodesFunc         func(v *Shortcodes)
+	WithHighlightFunc          func(v *Highlight)
+	WithCopyButtonFunc         func(v *CopyButton)
+	WithMarkdownFunc           func(v *Markdown)
+	WithHeadingAnchorsFunc     func(v *HeadingAnchors)
+	WithExternalLinksFunc      func(v *ExternalLinks)
+	WithUnsafeHTMLFunc         func(v *UnsafeHTML)
+	WithSchemaFunc             func(v *Schema)
+	WithLenientFunc            func(v *Lenient)
+	WithImagesFunc             func(v *Images)
+	WithJobsFunc               func(v *Jobs)
+	WithCacheFunc              func(v *Cache)
 }
 
 // WithBaseOption wraps a BaseOption as a GeneratorOption so it can be passed
@@ -179,6 +197,595 @@ func (o DisableReadingTime) AsOption() GeneratorOption {
 	}
 }
 
+// Drafts is a configuration type that controls whether posts marked with
+// draft: true in their front matter are included in the generated output.
+//
+// When Include is false (the default):
+//   - Draft posts are removed before any page is rendered
+//   - Drafts do not appear in post, index, or tag output, nor in tag counts
+//
+// When Include is true, drafts are rendered like any other post and templates
+// can mark them visibly via models.Post.Draft.
+//
+// This type is typically embedded in generator configuration structs and should
+// be set using the WithDrafts() option function.
+type Drafts struct{ Include bool }
+
+// WithDrafts returns a GeneratorOption that includes draft posts in the
+// generated output.
+//
+// By default, posts with draft: true in their front matter are skipped. Use
+// this option when previewing work in progress locally. The default templates
+// show a "Draft" badge on any post whose Draft field is set.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer, config.WithDrafts())
+func WithDrafts() GeneratorOption {
+	return GeneratorOption{
+		WithDraftsFunc: func(v *Drafts) {
+			v.Include = true
+		},
+	}
+}
+
+// AsOption converts this Drafts value back into a GeneratorOption.
+func (o Drafts) AsOption() GeneratorOption {
+	if o.Include {
+		return WithDrafts()
+	}
+	return GeneratorOption{
+		WithDraftsFunc: func(v *Drafts) {
+			v.Include = false
+		},
+	}
+}
+
+// Math is a configuration type that controls whether TeX math in posts is
+// rendered to MathML.
+//
+// When Enable is true:
+//   - $inline$ and $$display$$ expressions are converted to MathML at parse time
+//   - A post containing an expression that cannot be parsed fails to generate
+//
+// This type is typically embedded in generator configuration structs and should
+// be set using the WithMath() option function.
+type Math struct{ Enable bool }
+
+// WithMath returns a GeneratorOption that renders TeX math in posts to MathML.
+//
+// Math is converted on the server, so pages display it without any
+// JavaScript. Each post that contains an invalid expression is reported as a
+// parser.FileError naming the file and the line of the expression.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer, config.WithMath())
+func WithMath() GeneratorOption {
+	return GeneratorOption{
+		WithMathFunc: func(v *Math) {
+			v.Enable = true
+		},
+	}
+}
+
+// AsOption converts this Math value back into a GeneratorOption.
+func (o Math) AsOption() GeneratorOption {
+	if o.Enable {
+		return WithMath()
+	}
+	return GeneratorOption{
+		WithMathFunc: func(v *Math) {
+			v.Enable = false
+		},
+	}
+}
+
+// Shortcodes is a configuration type that controls whether Hugo-style
+// {{< name >}} shortcodes in posts are expanded.
+//
+// When Enable is true:
+//   - The built-in youtube, figure and details shortcodes are available
+//   - Templates under shortcodes/ in the template directory add shortcodes
+//   - A post using a shortcode that has no handler fails to generate
+//
+// Shortcodes are also enabled whenever the template directory holds shortcode
+// templates. This type is typically embedded in generator configuration
+// structs and should be set using the WithShortcodes() option function.
+type Shortcodes struct{ Enable bool }
+
+// WithShortcodes returns a GeneratorOption that expands shortcodes such as
+// {{< youtube id >}} and {{< details >}}...{{< /details >}} in posts.
+//
+// Each post that uses an unknown shortcode, or one whose handler fails, is
+// reported as a parser.FileError naming the file and the line of the
+// shortcode.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer, config.WithShortcodes())
+func WithShortcodes() GeneratorOption {
+	return GeneratorOption{
+		WithShortcodesFunc: func(v *Shortcodes) {
+			v.Enable = true
+		},
+	}
+}
+
+// AsOption converts this Shortcodes value back into a GeneratorOption.
+func (o Shortcodes) AsOption() GeneratorOption {
+	if o.Enable {
+		return WithShortcodes()
+	}
+	return GeneratorOption{
+		WithShortcodesFunc: func(v *Shortcodes) {
+			v.Enable = false
+		},
+	}
+}
+
+// Highlight is a configuration type that selects the chroma styles of the
+// generated chroma.css stylesheet. Light is used by default and Dark when the
+// reader prefers a dark colour scheme.
+//
+// When either is set, code blocks are highlighted. When both are empty, the
+// parser configuration decides whether code is highlighted and its default
+// styles are used.
+//
+// This type is typically embedded in generator configuration structs and should
+// be set using the WithHighlightStyle() option function.
+type Highlight struct {
+	Light string
+	Dark  string
+}
+
+// WithHighlightStyle returns a GeneratorOption that highlights code blocks and
+// styles them with the chroma style light, switching to dark under
+// prefers-color-scheme: dark. An empty dark keeps the light style in dark
+// mode. The stylesheet is published as chroma.css at the blog root.
+//
+// Use parser.ParseHighlightStyle to validate a name taken from user input;
+// Generate fails for a style that is not registered.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer, config.WithHighlightStyle("github", "github-dark"))
+func WithHighlightStyle(light, dark string) GeneratorOption {
+	return GeneratorOption{
+		WithHighlightFunc: func(v *Highlight) {
+			v.Light = light
+			v.Dark = dark
+		},
+	}
+}
+
+// AsOption converts this Highlight value back into a GeneratorOption.
+func (o Highlight) AsOption() GeneratorOption {
+	return WithHighlightStyle(o.Light, o.Dark)
+}
+
+// CopyButton is a configuration type that controls whether highlighted code
+// blocks carry a copy-to-clipboard button.
+//
+// This type is typically embedded in generator configuration structs and should
+// be set using the WithCopyButton() option function.
+type CopyButton struct{ Enable bool }
+
+// WithCopyButton returns a GeneratorOption that puts a copy-to-clipboard
+// button in every highlighted code block, and sets BaseData.CopyButton. The
+// default templates then include the script that copies the code when it is
+// clicked; custom templates need their own handler for clicks on
+// button.code-copy.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer,
+//	    config.WithHighlightStyle("github", "github-dark"),
+//	    config.WithCopyButton(),
+//	)
+func WithCopyButton() GeneratorOption {
+	return GeneratorOption{
+		WithCopyButtonFunc: func(v *CopyButton) {
+			v.Enable = true
+		},
+	}
+}
+
+// AsOption converts this CopyButton value back into a GeneratorOption.
+func (o CopyButton) AsOption() GeneratorOption {
+	return GeneratorOption{
+		WithCopyButtonFunc: func(v *CopyButton) {
+			v.Enable = o.Enable
+		},
+	}
+}
+
+// Markdown is a configuration type that selects the markdown dialect posts are
+// written in. Its values are the names used by the parser package: Dialect is
+// "commonmark", "gfm" or "extended", and Extensions maps extension names such
+// as "tables" or "hardwraps" to whether they are on.
+//
+// When Dialect is empty the parser's default, CommonMark, is used. Extensions
+// override the dialect, and HeadingShift is added to every heading's level.
+//
+// This type is typically embedded in generator configuration structs and should
+// be set using the WithDialect(), WithMarkdownExtension() and WithHeadingShift()
+// option functions.
+type Markdown struct {
+	Dialect      string
+	Extensions   map[string]bool
+	HeadingShift int
+}
+
+// WithDialect returns a GeneratorOption that selects the markdown dialect:
+// "commonmark" (the default), "gfm" for GitHub Flavored Markdown tables,
+// strikethrough, task lists and autolinks, or "extended", which adds
+// definition lists, typographic punctuation and :emoji: shortcodes.
+//
+// Use parser.ParseDialect to validate a name taken from user input.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer, config.WithDialect("gfm"))
+func WithDialect(name string) GeneratorOption {
+	return GeneratorOption{
+		WithMarkdownFunc: func(v *Markdown) {
+			v.Dialect = name
+		},
+	}
+}
+
+// WithMarkdownExtension returns a GeneratorOption that switches a single
+// markdown extension on or off, overriding the dialect. The names are those of
+// parser.Extensions, such as "tables", "emoji" or "hardwraps".
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer,
+//	    config.WithDialect("gfm"),
+//	    config.WithMarkdownExtension("linkify", false),
+//	)
+func WithMarkdownExtension(name string, enable bool) GeneratorOption {
+	return GeneratorOption{
+		WithMarkdownFunc: func(v *Markdown) {
+			if v.Extensions == nil {
+				v.Extensions = map[string]bool{}
+			}
+			v.Extensions[name] = enable
+		},
+	}
+}
+
+// WithHeadingShift returns a GeneratorOption that moves every heading in a
+// post down by n levels, so that # renders as <h2> when n is 1 and the page
+// title stays the only <h1>.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer, config.WithHeadingShift(1))
+func WithHeadingShift(n int) GeneratorOption {
+	return GeneratorOption{
+		WithMarkdownFunc: func(v *Markdown) {
+			v.HeadingShift = n
+		},
+	}
+}
+
+// AsOption converts this Markdown value back into a GeneratorOption.
+func (o Markdown) AsOption() GeneratorOption {
+	return GeneratorOption{
+		WithMarkdownFunc: func(v *Markdown) {
+			*v = o
+		},
+	}
+}
+
+// HeadingAnchors is a configuration type that controls the permalink anchors
+// added to headings in posts. Its values are those of parser.HeadingAnchor:
+// Position is "before" or "after", and empty or zero fields take the
+// parser's defaults.
+//
+// This type is typically embedded in generator configuration structs and should
+// be set using the WithHeadingAnchors() option function.
+type HeadingAnchors struct {
+	Enable   bool
+	Symbol   string
+	Position string
+	MinLevel int
+	Label    string
+}
+
+// WithHeadingAnchors returns a GeneratorOption that puts a permalink to each
+// heading inside it, so readers can copy a link to a section. symbol is the
+// HTML shown as the link, position is "before" or "after" the heading text,
+// headings shallower than minLevel get no anchor, and label is the link's
+// aria-label, in which %s is replaced by the heading text. Empty and zero
+// arguments keep the defaults: "#", "after", 2 and "Permalink to %s".
+//
+// Use parser.ParseAnchorPosition to validate a position taken from user input.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer, config.WithHeadingAnchors("¶", "before", 2, ""))
+func WithHeadingAnchors(symbol, position string, minLevel int, label string) GeneratorOption {
+	return HeadingAnchors{
+		Enable:   true,
+		Symbol:   symbol,
+		Position: position,
+		MinLevel: minLevel,
+		Label:    label,
+	}.AsOption()
+}
+
+// AsOption converts this HeadingAnchors value back into a GeneratorOption.
+func (o HeadingAnchors) AsOption() GeneratorOption {
+	return GeneratorOption{
+		WithHeadingAnchorsFunc: func(v *HeadingAnchors) {
+			*v = o
+		},
+	}
+}
+
+// ExternalLinks is a configuration type that controls the attributes added to
+// links that leave the site.
+//
+// This type is typically embedded in generator configuration structs and should
+// be set using the WithExternalLinks() option function.
+type ExternalLinks struct {
+	Enable  bool
+	Site    string
+	Rel     []string
+	NewTab  bool
+	Class   string
+	Trusted []string
+}
+
+// WithExternalLinks returns a GeneratorOption that marks up links to other
+// sites. site is the host the blog is published on, such as "example.com";
+// links to it, to the trusted domains and to their subdomains are left alone.
+// Every other http or https link gets the rel values in rel, target="_blank"
+// and rel="noopener" when newTab is set, and class as its class when it is not
+// empty.
+//
+// Use parser.ParseLinkRel to validate rel values taken from user input.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer,
+//	    config.WithExternalLinks("example.com", []string{"nofollow"}, true, "external", "github.com"),
+//	)
+func WithExternalLinks(site string, rel []string, newTab bool, class string, trusted ...string) GeneratorOption {
+	return ExternalLinks{
+		Enable:  true,
+		Site:    site,
+		Rel:     rel,
+		NewTab:  newTab,
+		Class:   class,
+		Trusted: trusted,
+	}.AsOption()
+}
+
+// AsOption converts this ExternalLinks value back into a GeneratorOption.
+func (o ExternalLinks) AsOption() GeneratorOption {
+	return GeneratorOption{
+		WithExternalLinksFunc: func(v *ExternalLinks) {
+			*v = o
+		},
+	}
+}
+
+// UnsafeHTML is a configuration type that controls whether raw HTML written
+// in posts is kept.
+//
+// When Enable is true:
+//   - Raw HTML in posts is rendered instead of being left out
+//   - The rendered HTML is sanitised with parser.DefaultHTMLPolicy, which
+//     keeps elements such as <details>, <kbd>, <sup> and <video>
+//   - Scripts, event handlers and javascript: URLs are always removed
+//
+// Allow lists further elements to keep, each written as its name, such as
+// "dl", or as its name followed by the attributes it may have, such as
+// "video[src controls]".
+//
+// This type is typically embedded in generator configuration structs and should
+// be set using the WithUnsafeHTML() option function.
+type UnsafeHTML struct {
+	Enable bool
+	Allow  []string
+}
+
+// WithUnsafeHTML returns a GeneratorOption that keeps raw HTML written in
+// posts, sanitised with an allowlist so that guest authors cannot run
+// scripts. allow adds elements to the default allowlist, as described by
+// UnsafeHTML.
+//
+// Use parser.ParseHTMLAllow to validate allow values taken from user input.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer, config.WithUnsafeHTML("dl", "dt", "dd", "video[src controls]"))
+func WithUnsafeHTML(allow ...string) GeneratorOption {
+	return UnsafeHTML{Enable: true, Allow: allow}.AsOption()
+}
+
+// AsOption converts this UnsafeHTML value back into a GeneratorOption.
+func (o UnsafeHTML) AsOption() GeneratorOption {
+	return GeneratorOption{
+		WithUnsafeHTMLFunc: func(v *UnsafeHTML) {
+			*v = o
+		},
+	}
+}
+
+// Schema is a configuration type that holds the path of the YAML file
+// describing the front matter posts must have. An empty Path uses the
+// schema.yaml file at the root of the posts directory, if there is one.
+//
+// This type is typically embedded in generator configuration structs and should
+// be set using the WithSchema() option function.
+type Schema struct{ Path string }
+
+// WithSchema returns a GeneratorOption that checks the front matter of every
+// post against the schema in the YAML file at path, in the format read by
+// parser.ParseSchema. Posts that break its rules are reported as
+// parser.FileError values, one per post, listing every broken rule.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer, config.WithSchema("schema.yaml"))
+func WithSchema(path string) GeneratorOption {
+	return GeneratorOption{
+		WithSchemaFunc: func(v *Schema) {
+			v.Path = path
+		},
+	}
+}
+
+// AsOption converts this Schema value back into a GeneratorOption.
+func (o Schema) AsOption() GeneratorOption {
+	return WithSchema(o.Path)
+}
+
+// Lenient is a configuration type that controls what happens when some posts
+// fail to parse.
+//
+// When Enable is false (strict, the default), Generate fails if any post
+// does. When it is true, the blog is built from the posts that parse, and
+// each file left out is logged as a warning and listed in
+// GeneratedBlog.Skipped, along with any bundle image published unchanged
+// because it could not be processed.
+//
+// This type is typically embedded in generator configuration structs and should
+// be set using the WithLenient() option function.
+type Lenient struct{ Enable bool }
+
+// WithLenient returns a GeneratorOption that builds the blog from the posts
+// that parse rather than failing when any post does, so that one broken file
+// does not take a live site down when it is reloaded.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer, config.WithLenient())
+func WithLenient() GeneratorOption {
+	return Lenient{Enable: true}.AsOption()
+}
+
+// AsOption converts this Lenient value back into a GeneratorOption.
+func (o Lenient) AsOption() GeneratorOption {
+	return GeneratorOption{
+		WithLenientFunc: func(v *Lenient) {
+			*v = o
+		},
+	}
+}
+
+// Images is a configuration type that controls the responsive image pipeline
+// for the JPEG and PNG files of page bundles.
+//
+// This type is typically embedded in generator configuration structs and should
+// be set using the WithImages() option function.
+type Images struct {
+	Enable bool
+	Sizes  string
+	Widths []int
+}
+
+// WithImages returns a GeneratorOption that makes the JPEG and PNG images in
+// page bundles responsive. Each is published with its metadata, including any
+// GPS position, removed, next to a copy scaled to each of widths that is
+// narrower than the image. Markdown images naming it get its intrinsic width
+// and height, loading="lazy", and a srcset of the copies with sizes as the
+// sizes attribute.
+//
+// An empty sizes and no widths keep the defaults: images.DefaultSizes and
+// images.DefaultWidths. Combined with WithCache, images are only processed
+// again when they change. An image that cannot be processed is published
+// unchanged with a warning, and with WithLenient is also listed in
+// GeneratedBlog.Skipped.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer, config.WithImages("100vw", 640, 1280))
+func WithImages(sizes string, widths ...int) GeneratorOption {
+	return Images{
+		Enable: true,
+		Sizes:  sizes,
+		Widths: widths,
+	}.AsOption()
+}
+
+// AsOption converts this Images value back into a GeneratorOption.
+func (o Images) AsOption() GeneratorOption {
+	return GeneratorOption{
+		WithImagesFunc: func(v *Images) {
+			*v = o
+		},
+	}
+}
+
+// Jobs is a configuration type that bounds how many posts are parsed, and how
+// many pages are rendered, at the same time.
+//
+// When Jobs is zero or negative, runtime.GOMAXPROCS(0) workers are used. A
+// value of 1 processes one file at a time.
+//
+// This type is typically embedded in generator configuration structs and should
+// be set using the WithJobs() option function.
+type Jobs struct{ Jobs int }
+
+// WithJobs returns a GeneratorOption that parses and renders with at most n
+// workers. Output does not depend on n: posts, pages and errors are always
+// reported in the same order.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer, config.WithJobs(4))
+func WithJobs(n int) GeneratorOption {
+	return GeneratorOption{
+		WithJobsFunc: func(v *Jobs) {
+			v.Jobs = n
+		},
+	}
+}
+
+// AsOption converts this Jobs value back into a GeneratorOption.
+func (o Jobs) AsOption() GeneratorOption {
+	return WithJobs(o.Jobs)
+}
+
+// Cache is a configuration type that holds the directory of the incremental
+// build cache. An empty Dir disables caching.
+//
+// This type is typically embedded in generator configuration structs and should
+// be set using the WithCache() option function.
+type Cache struct{ Dir string }
+
+// WithCache returns a GeneratorOption that keeps parsed posts and rendered
+// pages in dir between Generate calls. Posts whose source, bundle file names
+// and parser settings are unchanged skip markdown conversion, and pages whose
+// template data and templates are unchanged are not rendered again. Entries a
+// build does not use are removed when it finishes.
+//
+// The cache cannot see inside template functions registered with
+// config.WithFuncs, so they must be pure: their output must depend only on
+// their arguments. Clear the cache with cache.Clear after changing one.
+//
+// Example usage:
+//
+//	gen := generator.New(fsys, renderer, config.WithCache(".goblog-cache"))
+func WithCache(dir string) GeneratorOption {
+	return GeneratorOption{
+		WithCacheFunc: func(v *Cache) {
+			v.Dir = dir
+		},
+	}
+}
+
+// AsOption converts this Cache value back into a GeneratorOption.
+func (o Cache) AsOption() GeneratorOption {
+	return WithCache(o.Dir)
+}
+
 // SiteTitle is a configuration type that holds the site's title.
 //
 // This type is typically embedded in generator configuration structs
@@ -27,6 +27,12 @@ type RendererOption struct {
 //	formatDate(t time.Time) string   formats t as "January 2, 2006"
 //	shortDate(t time.Time) string    formats t as "Jan 2, 2006"
 //	year() int                       returns the current calendar year
+//	paramString(m, key) string       a Params or Custom value as a string
+//	paramInt(m, key) int             ... as an int
+//	paramFloat(m, key) float64       ... as a float64
+//	paramBool(m, key) bool           ... as a bool
+//	paramTime(m, key) time.Time      ... as a time.Time
+//	paramStrings(m, key) []string    ... as a list of strings
 //
 // If a key in funcs matches one of those built-in names, the supplied function
 // replaces the built-in and a warning is logged via slog. This allows
@@ -39,6 +45,11 @@ type RendererOption struct {
 // the options are applied, with later registrations overwriting earlier ones
 // for the same key.
 //
+// When rendered pages are cached with [WithCache], functions must be pure,
+// returning the same output for the same arguments: a page is only rendered
+// again when its templates or data change, so a function reading the clock,
+// the environment or a file would leave stale output in the cache.
+//
 // # Security
 //
 // html/template's contextual auto-escaping is bypassed for any function that
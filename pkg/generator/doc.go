@@ -69,11 +69,11 @@
 //
 //	<h1>{{upper .Post.Title}}</h1>
 //
-// The built-in helpers (formatDate, shortDate, year) remain available unless
-// intentionally replaced. Registering a function whose name matches a built-in
-// silently replaces that built-in — useful for custom date formats but a
-// potential footgun if done accidentally. See [config.WithFuncs] for the full list
-// of reserved names.
+// The built-in helpers (formatDate, shortDate, year and the param functions
+// described under Post Params) remain available unless intentionally replaced.
+// Registering a function whose name matches a built-in silently replaces that
+// built-in — useful for custom date formats but a potential footgun if done
+// accidentally. See [config.WithFuncs] for the full list of reserved names.
 //
 // # Custom Template Data
 //
@@ -99,6 +99,27 @@
 // Multiple WithCustomData calls merge their maps; later values overwrite earlier
 // ones for duplicate keys.
 //
+// # Post Params
+//
+// Front matter keys that the parser does not recognise are kept per post in
+// Post.Params, the per-post counterpart of .Custom:
+//
+//	---
+//	title: My Post
+//	hero: /images/hero.png
+//	rating: 4
+//	---
+//
+// In a template:
+//
+//	{{with .Post.Params.hero}}<img src="{{.}}" alt="">{{end}}
+//	{{if gt (paramInt .Post.Params "rating") 3}}Recommended{{end}}
+//
+// The paramString, paramInt, paramFloat, paramBool, paramTime and paramStrings
+// helpers convert a value to the named type, returning the zero value when the
+// key is missing or cannot be converted. They accept a nil map, so they need no
+// guard, and work on .Custom as well.
+//
 // # Page Path
 //
 // Every rendered page receives a Path field on its template data containing the
@@ -4,14 +4,21 @@
 
 package generator
 
+import (
+	"time"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/parser"
+)
+
 // GeneratedBlog contains all the HTML content for a complete static blog site.
 //
 // It includes individual post pages, the main index page, tag pages, and tags index.
 // All content is stored as raw HTML bytes ready to be written to files or
 // served via HTTP.
 //
-// Post slugs are derived from markdown filenames. Tag names are extracted from
-// post front matter. For more info see [pkg/models/Post].
+// Post slugs come from the slug front matter key when present, otherwise from
+// the post title or markdown filename; they are unique within a blog. Tag
+// names are extracted from post front matter. For more info see [pkg/models/Post].
 //
 // # Raw Output Mode
 //
@@ -21,6 +28,7 @@ package generator
 //   - Posts map contains clean HTML fragments for each post
 //   - Tags map will be empty (tag pages are not generated)
 //   - TagsIndex will be empty (tags index is not generated)
+//   - Series map will be empty (series pages are not generated)
 //   - Index field will be empty or contain minimal content
 //
 // This mode is useful for embedding blog content into existing applications,
@@ -46,16 +54,59 @@ package generator
 // templates guard the "· N min read" annotation with
 // {{if .Post.ReadingTimeMinutes}}, so the annotation is simply omitted without
 // any other changes to the output structure.
+//
+// # Series
+//
+// Posts that share a series front matter value form a series. Series maps
+// the slug of each series name to a page listing its parts in order, for
+// example "building-a-blog" for the series "Building a Blog".
+//
+// # Page Bundles
+//
+// Posts written as <dir>/index.md carry the other files in <dir> with them.
+// Assets holds those files keyed by the post slug and their path within the
+// bundle, for example "my-post/diagram.png", and they are expected to be
+// published under posts/ so that they appear at posts/my-post/diagram.png.
+// With config.WithImages, JPEG and PNG assets have their metadata removed and
+// are joined by their resized copies, such as "my-post/diagram-480w.png".
+// Assets is populated in raw output mode too.
+//
+// # Code Highlighting
+//
+// ChromaCSS holds the stylesheet for the class names of highlighted code
+// blocks, with the configured light style and a prefers-color-scheme: dark
+// override, and is expected to be published as chroma.css at the blog root,
+// where the default templates link it. It is set by every Generate call, in
+// raw output mode too.
+//
+// # Scheduled Publishing
+//
+// Posts dated in the future and posts past their expiryDate are not included.
+// NextUpdate holds the earliest future time at which a post goes live or
+// expires, or the zero time when nothing is scheduled. Regenerate at that
+// time to keep the output current.
 type GeneratedBlog struct {
-	Posts     map[string][]byte // Posts maps a slug to raw HTML bytes for each post
-	Index     []byte            // Index contains the raw HTML for the blog index page
-	Tags      map[string][]byte // Tags maps each tag name to its tag page HTML
-	TagsIndex []byte            // TagsIndex contains the raw HTML for the tags index page
+	Posts      map[string][]byte // Posts maps a slug to raw HTML bytes for each post
+	Index      []byte            // Index contains the raw HTML for the blog index page
+	Tags       map[string][]byte // Tags maps each tag name to its tag page HTML
+	TagsIndex  []byte            // TagsIndex contains the raw HTML for the tags index page
+	Series     map[string][]byte // Series maps each series slug to its series page HTML
+	Assets     map[string][]byte // Assets maps "<slug>/<file>" to the contents of each page bundle asset
+	ChromaCSS  []byte            // ChromaCSS is the chroma.css stylesheet for highlighted code
+	NextUpdate time.Time         // NextUpdate is when the next scheduled post goes live or expires (zero if none)
+
+	// Skipped lists the files left out because they failed to parse, and
+	// the bundle images published unchanged because they could not be
+	// processed, in path order. It is only set with config.WithLenient;
+	// otherwise parse failures fail Generate.
+	Skipped []parser.FileError
 }
 
 func NewEmptyGeneratedBlog() *GeneratedBlog {
 	return &GeneratedBlog{
-		Posts: make(map[string][]byte),
-		Tags:  make(map[string][]byte),
+		Posts:  make(map[string][]byte),
+		Tags:   make(map[string][]byte),
+		Series: make(map[string][]byte),
+		Assets: make(map[string][]byte),
 	}
 }
@@ -6,14 +6,24 @@ package generator
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
+	"maps"
+	"os"
+	"path"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/harrydayexe/GoBlog/v2/internal/workpool"
+	"github.com/harrydayexe/GoBlog/v2/pkg/cache"
 	"github.com/harrydayexe/GoBlog/v2/pkg/config"
+	"github.com/harrydayexe/GoBlog/v2/pkg/images"
 	"github.com/harrydayexe/GoBlog/v2/pkg/models"
 	"github.com/harrydayexe/GoBlog/v2/pkg/parser"
 )
@@ -30,12 +40,27 @@ type Generator struct {
 	config.RawOutput
 	config.DisableTags
 	config.DisableReadingTime
+	config.Drafts
+	config.Math
+	config.Shortcodes
+	config.Highlight
+	config.CopyButton
+	config.Markdown
+	config.HeadingAnchors
+	config.ExternalLinks
+	config.UnsafeHTML
+	config.Schema
+	config.Lenient
+	config.Images
+	config.Jobs
+	config.Cache
 	config.SiteTitle
 	config.BlogRoot
 	config.Environment
 	config.CustomData
 	config.HTMLPaths
 	config.Logger
+	config.Clock
 	ParserConfig parser.Config // The config to use when parsing
 
 	renderer *TemplateRenderer
@@ -46,6 +71,20 @@ func (c Generator) String() string {
 - RawOutput           %t,
 - DisableTags         %t,
 - DisableReadingTime  %t,
+- Drafts              %t,
+- Math                %t,
+- Shortcodes          %t,
+- Highlight           %s/%s,
+- CopyButton          %t,
+- Dialect             %s,
+- HeadingAnchors      %t,
+- ExternalLinks       %t,
+- UnsafeHTML          %t %v,
+- Schema              %s,
+- Lenient             %t,
+- Images              %t %v,
+- Jobs                %d,
+- Cache               %s,
 - SiteTitle           %s,
 - BlogRoot            %s,
 - Environment         %s,
@@ -54,6 +93,23 @@ func (c Generator) String() string {
 		c.RawOutput,
 		c.DisableTags.Disable,
 		c.DisableReadingTime.Disable,
+		c.Drafts.Include,
+		c.Math.Enable,
+		c.Shortcodes.Enable,
+		c.Highlight.Light,
+		c.Highlight.Dark,
+		c.CopyButton.Enable,
+		c.Markdown.Dialect,
+		c.HeadingAnchors.Enable,
+		c.ExternalLinks.Enable,
+		c.UnsafeHTML.Enable,
+		c.UnsafeHTML.Allow,
+		c.Schema.Path,
+		c.Lenient.Enable,
+		c.Images.Enable,
+		c.Images.Widths,
+		c.Jobs.Jobs,
+		c.Cache.Dir,
 		c.SiteTitle,
 		c.BlogRoot,
 		c.Environment.Environment,
@@ -67,7 +123,12 @@ func (c Generator) String() string {
 // resources cannot be initialized.
 //
 // Optional config.GeneratorOption values control behavior: config.WithRawOutput,
-// config.WithDisableTags, config.WithDisableReadingTime, config.WithSiteTitle,
+// config.WithDisableTags, config.WithDisableReadingTime, config.WithDrafts,
+// config.WithMath, config.WithShortcodes, config.WithHighlightStyle,
+// config.WithCopyButton, config.WithDialect, config.WithMarkdownExtension,
+// config.WithHeadingShift, config.WithHeadingAnchors, config.WithExternalLinks,
+// config.WithUnsafeHTML, config.WithSchema, config.WithLenient,
+// config.WithImages, config.WithJobs, config.WithCache, config.WithSiteTitle,
 // config.WithBlogRoot, config.WithEnvironment, config.WithCustomData.
 // The template renderer is supplied as a positional argument, not an option.
 func New(posts fs.FS, renderer *TemplateRenderer, opts ...config.GeneratorOption) *Generator {
@@ -85,6 +146,34 @@ func New(posts fs.FS, renderer *TemplateRenderer, opts ...config.GeneratorOption
 			opt.WithDisableTagsFunc(&gen.DisableTags)
 		} else if opt.WithDisableReadingTimeFunc != nil {
 			opt.WithDisableReadingTimeFunc(&gen.DisableReadingTime)
+		} else if opt.WithDraftsFunc != nil {
+			opt.WithDraftsFunc(&gen.Drafts)
+		} else if opt.WithMathFunc != nil {
+			opt.WithMathFunc(&gen.Math)
+		} else if opt.WithShortcodesFunc != nil {
+			opt.WithShortcodesFunc(&gen.Shortcodes)
+		} else if opt.WithHighlightFunc != nil {
+			opt.WithHighlightFunc(&gen.Highlight)
+		} else if opt.WithCopyButtonFunc != nil {
+			opt.WithCopyButtonFunc(&gen.CopyButton)
+		} else if opt.WithMarkdownFunc != nil {
+			opt.WithMarkdownFunc(&gen.Markdown)
+		} else if opt.WithHeadingAnchorsFunc != nil {
+			opt.WithHeadingAnchorsFunc(&gen.HeadingAnchors)
+		} else if opt.WithExternalLinksFunc != nil {
+			opt.WithExternalLinksFunc(&gen.ExternalLinks)
+		} else if opt.WithUnsafeHTMLFunc != nil {
+			opt.WithUnsafeHTMLFunc(&gen.UnsafeHTML)
+		} else if opt.WithSchemaFunc != nil {
+			opt.WithSchemaFunc(&gen.Schema)
+		} else if opt.WithLenientFunc != nil {
+			opt.WithLenientFunc(&gen.Lenient)
+		} else if opt.WithImagesFunc != nil {
+			opt.WithImagesFunc(&gen.Images)
+		} else if opt.WithJobsFunc != nil {
+			opt.WithJobsFunc(&gen.Jobs)
+		} else if opt.WithCacheFunc != nil {
+			opt.WithCacheFunc(&gen.Cache)
 		} else if opt.WithSiteTitleFunc != nil {
 			opt.WithSiteTitleFunc(&gen.SiteTitle)
 		} else if opt.WithBlogRootFunc != nil {
@@ -97,6 +186,8 @@ func New(posts fs.FS, renderer *TemplateRenderer, opts ...config.GeneratorOption
 			opt.WithHTMLPathsFunc(&gen.HTMLPaths)
 		} else if opt.WithLoggerFunc != nil {
 			opt.WithLoggerFunc(&gen.Logger)
+		} else if opt.WithClockFunc != nil {
+			opt.WithClockFunc(&gen.Clock)
 		}
 	}
 
@@ -104,6 +195,10 @@ func New(posts fs.FS, renderer *TemplateRenderer, opts ...config.GeneratorOption
 		gen.Logger.Logger = slog.Default()
 	}
 
+	if gen.Clock.Now == nil {
+		gen.Clock.Now = time.Now
+	}
+
 	if gen.SiteTitle.SiteTitle == "" {
 		gen.SiteTitle = config.SiteTitle{
 			SiteTitle: "GoBlog",
@@ -138,31 +233,491 @@ func New(posts fs.FS, renderer *TemplateRenderer, opts ...config.GeneratorOption
 //   - Index will be empty or minimal
 //   - Useful for custom integration scenarios
 //
+// # Drafts
+//
+// Posts with draft: true in their front matter are dropped before any output
+// is assembled, in both raw and templated modes, unless config.WithDrafts()
+// is applied. Drafts that are dropped do not claim their slug, while
+// scheduled and expired posts do. Wiki links in published posts cannot
+// point at any of them.
+//
+// # Series
+//
+// Posts that share a series front matter value are grouped by the series
+// slug. Each post page receives the full part list and its previous and next
+// parts through models.PostPageData, and each series gets a page of its own
+// in GeneratedBlog.Series rendered from pages/series.tmpl. Series pages are
+// not generated in raw output mode.
+//
+// # Page Bundles
+//
+// A post written as <dir>/index.md is a page bundle. The other files in <dir>
+// are read into GeneratedBlog.Assets under "<slug>/" so they can be published
+// at posts/<slug>/ next to the post, where the parser has already pointed the
+// post's relative links.
+//
+// # Responsive Images
+//
+// With config.WithImages, the JPEG and PNG assets of page bundles are
+// published with their metadata removed, and each is joined in
+// GeneratedBlog.Assets by copies scaled to the configured widths, named as by
+// images.VariantName. Images in posts that name one of them are given its
+// intrinsic size, loading="lazy" and a srcset listing the copies. With
+// config.WithCache, processed images are kept between calls too.
+//
+// # Shortcodes
+//
+// With config.WithShortcodes, or when the renderer's template directory holds
+// shortcodes/*.tmpl templates, {{< name >}} shortcodes in posts are expanded.
+// The built-in youtube, figure and details shortcodes are replaced by
+// templates of the same name, and templates by Go handlers set in
+// ParserConfig.Shortcodes. A post using an unknown shortcode is reported as a
+// parser.FileError giving its line.
+//
+// # Render Hooks
+//
+// The render-hooks/link.tmpl, image.tmpl, heading.tmpl and codeblock.tmpl
+// templates of the renderer's template directory replace the HTML written for
+// those markdown nodes, unless a hook of the same kind is set in
+// ParserConfig.RenderHooks.
+//
+// # Raw HTML
+//
+// With config.WithUnsafeHTML, raw HTML written in posts is kept and every
+// post is sanitised with ParserConfig.HTMLPolicy, or parser.DefaultHTMLPolicy
+// when it is unset, extended by the elements the option allows. Shortcode
+// templates and render hooks are sanitised too, so elements they write that
+// the policy does not know must be allowed as well.
+//
+// # Front Matter Schemas
+//
+// The front matter of every post is checked against the schema in the file
+// given by config.WithSchema or, without it, in schema.yaml at the root of
+// the posts directory, unless ParserConfig.Schema is set. Each post breaking
+// its rules is reported as a parser.FileError wrapping a parser.SchemaError
+// for each rule, and Generate fails as it does for any other invalid post.
+//
+// # Lenient Builds
+//
+// By default, Generate fails when any post fails to parse. With
+// config.WithLenient, the blog is built from the posts that parse instead:
+// each file left out is logged as a warning and listed in
+// GeneratedBlog.Skipped. Errors that are not about a single post, such as an
+// unreadable posts directory or a template that fails to render, still fail
+// Generate.
+//
+// A bundle image that config.WithImages cannot process, such as a corrupt
+// file, is published unchanged with a warning in either mode. In lenient
+// mode it is also listed in GeneratedBlog.Skipped.
+//
+// # Scheduled Publishing
+//
+// Posts whose date is after the current time (as reported by the configured
+// config.Clock) are hidden until that time, and posts with an expiryDate are
+// hidden once it has passed. GeneratedBlog.NextUpdate records when the next
+// such change is due so callers can regenerate at that point; pkg/server does
+// this automatically.
+//
+// # Concurrency
+//
+// Posts are parsed, and pages rendered, by up to config.Jobs workers at once
+// (runtime.GOMAXPROCS(0) by default). The generated blog, and the error
+// returned when a page fails to render, are the same whatever the number of
+// workers.
+//
+// # Incremental Builds
+//
+// With config.WithCache, parsed posts and rendered pages are kept on disk
+// between calls. A post is converted from markdown again only when its source,
+// its bundle's file names or the parser settings change, and a page is
+// rendered again only when its templates or the data passed to them change,
+// which for a post page means the post itself or its series, or when the year
+// returned by the year template function changes. Entries a call does not use
+// are removed before it returns. Template functions registered with
+// config.WithFuncs are assumed to be pure, returning the same output for the
+// same arguments, since the cache cannot see inside them.
+//
 // Generate respects the provided context and will return early with
 // context.Canceled or context.DeadlineExceeded if the context is canceled
 // or times out.
 //
-// It returns an error if markdown files cannot be read, parsing fails, or
-// template rendering encounters an error.
+// It returns an error if markdown files cannot be read, parsing fails (unless
+// config.WithLenient is set), or template rendering encounters an error.
 func (g *Generator) Generate(ctx context.Context) (*GeneratedBlog, error) {
 	g.Logger.Logger.DebugContext(ctx, "Creating parser for generate call")
 	parserCfg := g.ParserConfig
 	parserCfg.Logger = g.Logger.Logger
+	parserCfg.BlogRoot = string(g.BlogRoot)
+	parserCfg.EnableMath = parserCfg.EnableMath || g.Math.Enable
+	if g.renderer != nil && len(g.renderer.shortcodes) > 0 {
+		// Handlers set in ParserConfig win over templates of the same name
+		shortcodes := maps.Clone(g.renderer.shortcodes)
+		maps.Copy(shortcodes, parserCfg.Shortcodes)
+		parserCfg.Shortcodes = shortcodes
+		parserCfg.ShortcodeVersion = g.renderer.shortcodeFingerprint + parserCfg.ShortcodeVersion
+		parserCfg.EnableShortcodes = true
+	}
+	parserCfg.EnableShortcodes = parserCfg.EnableShortcodes || g.Shortcodes.Enable
+	if g.renderer != nil && g.renderer.renderHookFingerprint != "" {
+		// Hooks set in ParserConfig win over templates of the same kind
+		hooks, set := g.renderer.renderHooks, parserCfg.RenderHooks
+		if set.Link != nil {
+			hooks.Link = set.Link
+		}
+		if set.Image != nil {
+			hooks.Image = set.Image
+		}
+		if set.Heading != nil {
+			hooks.Heading = set.Heading
+		}
+		if set.CodeBlock != nil {
+			hooks.CodeBlock = set.CodeBlock
+		}
+		parserCfg.RenderHooks = hooks
+		parserCfg.RenderHooksVersion = g.renderer.renderHookFingerprint + parserCfg.RenderHooksVersion
+	}
+	if g.Highlight.Light != "" || g.Highlight.Dark != "" {
+		parserCfg.EnableCodeHighlighting = true
+		parserCfg.HighlightStyle = g.Highlight.Light
+		parserCfg.HighlightDarkStyle = g.Highlight.Dark
+	}
+	parserCfg.EnableCopyButton = parserCfg.EnableCopyButton || g.CopyButton.Enable
+	if g.Markdown.Dialect != "" {
+		parserCfg.Dialect = parser.Dialect(g.Markdown.Dialect)
+	}
+	if len(g.Markdown.Extensions) > 0 {
+		extensions := maps.Clone(parserCfg.Extensions)
+		if extensions == nil {
+			extensions = make(map[parser.Extension]bool)
+		}
+		for name, enable := range g.Markdown.Extensions {
+			extensions[parser.Extension(name)] = enable
+		}
+		parserCfg.Extensions = extensions
+	}
+	if g.Markdown.HeadingShift != 0 {
+		parserCfg.HeadingShift = g.Markdown.HeadingShift
+	}
+	if g.HeadingAnchors.Enable {
+		parserCfg.EnableHeadingAnchors = true
+		parserCfg.HeadingAnchor = parser.HeadingAnchor{
+			Symbol:   g.HeadingAnchors.Symbol,
+			Position: parser.AnchorPosition(g.HeadingAnchors.Position),
+			MinLevel: g.HeadingAnchors.MinLevel,
+			Label:    g.HeadingAnchors.Label,
+		}
+	}
+	if g.ExternalLinks.Enable {
+		parserCfg.EnableExternalLinks = true
+		parserCfg.ExternalLinks = parser.ExternalLinks{
+			Site:    g.ExternalLinks.Site,
+			Rel:     g.ExternalLinks.Rel,
+			NewTab:  g.ExternalLinks.NewTab,
+			Class:   g.ExternalLinks.Class,
+			Trusted: g.ExternalLinks.Trusted,
+		}
+	}
+	if g.UnsafeHTML.Enable {
+		parserCfg.EnableUnsafeHTML = true
+		if parserCfg.HTMLPolicy.Elements == nil {
+			parserCfg.HTMLPolicy = parser.DefaultHTMLPolicy()
+		} else {
+			parserCfg.HTMLPolicy.Elements = maps.Clone(parserCfg.HTMLPolicy.Elements)
+		}
+		for _, spec := range g.UnsafeHTML.Allow {
+			name, attrs, err := parser.ParseHTMLAllow(spec)
+			if err != nil {
+				return nil, err
+			}
+			parserCfg.HTMLPolicy.Allow(name, attrs...)
+		}
+	}
+	if parserCfg.Schema.Fields == nil {
+		schema, err := g.loadSchema()
+		if err != nil {
+			return nil, err
+		}
+		parserCfg.Schema = schema
+	}
+	if g.Jobs.Jobs != 0 {
+		parserCfg.Jobs = g.Jobs.Jobs
+	}
+	// Only posts that will be published can be linked to, so that a link to
+	// a draft or scheduled post fails the build instead of breaking the
+	// site. Scheduled posts still claim their slug, so that one cannot take
+	// a live post's slug when it is published, but excluded drafts do not
+	now := g.Clock.Now()
+	if parserCfg.Published == nil {
+		parserCfg.Published = func(post *models.Post) bool {
+			return (g.Drafts.Include || !post.Draft) && post.IsLive(now)
+		}
+	}
+	if parserCfg.Excluded == nil {
+		parserCfg.Excluded = func(post *models.Post) bool {
+			return !g.Drafts.Include && post.Draft
+		}
+	}
+	var store *cache.Store
+	if g.Cache.Dir != "" {
+		var err error
+		if store, err = cache.Open(g.Cache.Dir); err != nil {
+			return nil, err
+		}
+		parserCfg.Cache = store
+	}
+	if g.Images.Enable {
+		parserCfg.Images = images.New(
+			images.WithWidths(g.Images.Widths...),
+			images.WithSizes(g.Images.Sizes),
+			images.WithCache(store),
+		)
+	}
 	p := parser.NewWithConfig(&parserCfg)
 
+	chromaCSS, err := p.HighlightCSS()
+	if err != nil {
+		return nil, err
+	}
+
+	// In lenient mode, posts that fail to parse are left out rather than
+	// failing the build
 	posts, err := p.ParseDirectory(ctx, g.PostsDir)
+	var parseErrs parser.ParseErrors
+	if g.Lenient.Enable && errors.As(err, &parseErrs) {
+		for _, fe := range parseErrs.Errors {
+			g.Logger.Logger.WarnContext(ctx, "Skipping post that failed to parse",
+				slog.String("path", fe.Path),
+				slog.Any("error", fe.Err),
+			)
+		}
+		err = nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	if !g.Drafts.Include {
+		posts = posts.ExcludeDrafts()
+	}
+
+	// Hide scheduled and expired posts, remembering when the next one changes.
+	nextUpdate := posts.NextChange(now)
+	posts = posts.FilterLive(now)
+
+	assets, unprocessed, err := g.loadBundleAssets(ctx, posts, parserCfg.Images)
+	if err != nil {
+		return nil, err
+	}
+	skipped := parseErrs.Errors
+	if g.Lenient.Enable && len(unprocessed) > 0 {
+		skipped = append(slices.Clone(skipped), unprocessed...)
+		slices.SortFunc(skipped, func(a, b parser.FileError) int {
+			return strings.Compare(a.Path, b.Path)
+		})
+	}
+
 	// Step 2: If RawOutput mode, return immediately with raw HTML
 	if g.RawOutput.RawOutput {
 		g.Logger.Logger.InfoContext(ctx, "Raw output enabled, ignoring templates")
-		return g.assembleRawBlog(posts), nil
+		blog := g.assembleRawBlog(posts)
+		blog.Assets = assets
+		blog.ChromaCSS = chromaCSS
+		blog.NextUpdate = nextUpdate
+		blog.Skipped = skipped
+		g.pruneCache(ctx, store)
+		return blog, nil
 	}
 
 	// Step 3: Apply templates
-	return g.assembleBlogWithTemplates(ctx, posts)
+	blog, err := g.assembleBlogWithTemplates(ctx, posts, store)
+	if err != nil {
+		return nil, err
+	}
+	blog.Assets = assets
+	blog.ChromaCSS = chromaCSS
+	blog.NextUpdate = nextUpdate
+	blog.Skipped = skipped
+	g.pruneCache(ctx, store)
+	return blog, nil
+}
+
+// pageCacheBucket is the cache bucket holding rendered pages.
+const pageCacheBucket = "pages"
+
+// pageCache holds the pages rendered by earlier builds. Pages are keyed by
+// their data with each post's content replaced by a hash of it, computed
+// once, so that the key of a page listing many posts stays small.
+type pageCache struct {
+	store *cache.Store
+	posts map[*models.Post]*models.Post // The key copy of each post
+}
+
+// newPageCache returns a pageCache reading and writing store, or nil when
+// store is nil. posts must not change while the pageCache is in use.
+func newPageCache(store *cache.Store, posts models.PostList) *pageCache {
+	if store == nil {
+		return nil
+	}
+	c := &pageCache{store: store, posts: make(map[*models.Post]*models.Post, len(posts))}
+	for _, post := range posts {
+		key := *post
+		key.RawContent = cache.Key(post.Content, []byte(post.RawContent))
+		key.Content, key.HTMLContent = nil, ""
+		c.posts[post] = &key
+	}
+	return c
+}
+
+// post returns the key copy of post.
+func (c *pageCache) post(post *models.Post) *models.Post {
+	if key, ok := c.posts[post]; ok {
+		return key
+	}
+	return post
+}
+
+// list returns the key copies of posts.
+func (c *pageCache) list(posts []*models.Post) []*models.Post {
+	if posts == nil {
+		return nil
+	}
+	keys := make([]*models.Post, len(posts))
+	for i, post := range posts {
+		keys[i] = c.post(post)
+	}
+	return keys
+}
+
+// keyData returns data with its posts replaced by their key copies.
+func (c *pageCache) keyData(data any) any {
+	switch d := data.(type) {
+	case models.PostPageData:
+		d.Post, d.PrevInSeries, d.NextInSeries = c.post(d.Post), c.post(d.PrevInSeries), c.post(d.NextInSeries)
+		d.Series = c.list(d.Series)
+		return d
+	case models.IndexPageData:
+		d.Posts = c.list(d.Posts)
+		return d
+	case models.TagPageData:
+		d.Posts = c.list(d.Posts)
+		return d
+	case models.SeriesPageData:
+		d.Posts = c.list(d.Posts)
+		return d
+	}
+	return data
+}
+
+// renderPage returns the output of render, which renders a page of the given
+// kind from data. With a page cache, a page rendered before from the same
+// templates and data, in the same year, is returned from the cache instead.
+// The year is part of the key because the year template function reads the
+// clock, and so is not determined by the templates and data alone.
+func (g *Generator) renderPage(pages *pageCache, kind string, data any, render func() ([]byte, error)) ([]byte, error) {
+	if pages == nil {
+		return render()
+	}
+	encoded, err := json.Marshal(pages.keyData(data))
+	if err != nil {
+		g.Logger.Logger.Debug("Not caching page", slog.String("kind", kind), slog.Any("error", err))
+		return render()
+	}
+	year := strconv.Itoa(g.Clock.Now().Year())
+	key := cache.Key([]byte(g.renderer.fingerprint), []byte(kind), encoded, []byte(year))
+	if page, ok := pages.store.Get(pageCacheBucket, key); ok {
+		return page, nil
+	}
+
+	page, err := render()
+	if err != nil {
+		return nil, err
+	}
+	if err := pages.store.Put(pageCacheBucket, key, page); err != nil {
+		g.Logger.Logger.Warn("Failed to cache page", slog.String("kind", kind), slog.Any("error", err))
+	}
+	return page, nil
+}
+
+// pruneCache removes the cache entries the finished build did not use. A
+// failure only wastes disk space, so it is logged rather than returned.
+func (g *Generator) pruneCache(ctx context.Context, store *cache.Store) {
+	if store == nil {
+		return
+	}
+	if err := store.Prune(); err != nil {
+		g.Logger.Logger.WarnContext(ctx, "Failed to prune cache", slog.String("dir", store.Dir()), slog.Any("error", err))
+	}
+}
+
+// loadBundleAssets reads the assets of every page bundle in posts, keyed by
+// "<slug>/<asset>". With an image processor, JPEG and PNG assets are
+// replaced by their processed data and joined by their scaled copies.
+//
+// An image that cannot be processed, such as a corrupt file, is published as
+// it is with a warning, and returned as a FileError among the unprocessed
+// files.
+func (g *Generator) loadBundleAssets(ctx context.Context, posts models.PostList, proc *images.Processor) (map[string][]byte, []parser.FileError, error) {
+	assets := make(map[string][]byte)
+	var unprocessed []parser.FileError
+	for _, post := range posts {
+		for _, asset := range post.Assets {
+			name := path.Join(post.BundleDir, asset)
+			data, err := fs.ReadFile(g.PostsDir, name)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read bundle asset: %w", err)
+			}
+			key := post.Slug + "/" + asset
+			if proc == nil || !images.IsRaster(asset) {
+				assets[key] = data
+				continue
+			}
+
+			img, err := proc.Process(data)
+			if err != nil {
+				g.Logger.Logger.WarnContext(ctx, "Publishing image that failed to process unchanged",
+					slog.String("path", name),
+					slog.Any("error", err),
+				)
+				assets[key] = data
+				unprocessed = append(unprocessed, parser.FileError{Path: name, Err: err})
+				continue
+			}
+			assets[key] = img.Data
+			for _, v := range img.Variants {
+				assets[images.VariantName(key, v.Width)] = v.Data
+			}
+		}
+	}
+	return assets, unprocessed, nil
+}
+
+// schemaFile is the name of the front matter schema read from the root of the
+// posts directory when config.WithSchema is not given.
+const schemaFile = "schema.yaml"
+
+// loadSchema reads the front matter schema from the file set with
+// config.WithSchema or, without one, from schemaFile in the posts directory.
+// It returns an empty schema when neither exists.
+func (g *Generator) loadSchema() (parser.Schema, error) {
+	var data []byte
+	var err error
+	name := g.Schema.Path
+	if name != "" {
+		data, err = os.ReadFile(name)
+	} else {
+		name = schemaFile
+		if data, err = fs.ReadFile(g.PostsDir, name); errors.Is(err, fs.ErrNotExist) {
+			return parser.Schema{}, nil
+		}
+	}
+	if err != nil {
+		return parser.Schema{}, fmt.Errorf("failed to read front matter schema: %w", err)
+	}
+	schema, err := parser.ParseSchema(data)
+	if err != nil {
+		return parser.Schema{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return schema, nil
 }
 
 // DebugConfig logs the current generator configuration at the debug level.
@@ -179,8 +734,8 @@ func (g *Generator) DebugConfig(ctx context.Context) {
 }
 
 // pagePath returns the BaseData.Path value for a given page.
-// kind must be one of "index", "post", "tag", or "tagsIndex"; name is the
-// slug or tag string (empty for "index" and "tagsIndex").
+// kind must be one of "index", "post", "tag", "tagsIndex", or "series"; name
+// is the slug or tag string (empty for "index" and "tagsIndex").
 func (g *Generator) pagePath(kind, name string) string {
 	root := string(g.BlogRoot)
 
@@ -199,6 +754,8 @@ func (g *Generator) pagePath(kind, name string) string {
 		base = root + "tags/" + name
 	case "tagsIndex":
 		base = root + "tags"
+	case "series":
+		base = root + "series/" + name
 	}
 
 	if g.HTMLPaths.Enable {
@@ -222,7 +779,7 @@ func (g *Generator) assembleRawBlog(posts models.PostList) *GeneratedBlog {
 	return blog
 }
 
-func (g *Generator) assembleBlogWithTemplates(ctx context.Context, posts models.PostList) (*GeneratedBlog, error) {
+func (g *Generator) assembleBlogWithTemplates(ctx context.Context, posts models.PostList, store *cache.Store) (*GeneratedBlog, error) {
 	g.Logger.Logger.DebugContext(ctx, "Rendering posts with templates")
 
 	// Check if renderer is available
@@ -233,6 +790,7 @@ func (g *Generator) assembleBlogWithTemplates(ctx context.Context, posts models.
 	blog := NewEmptyGeneratedBlog()
 
 	tagsEnabled := !g.DisableTags.Disable
+	copyButton := g.ParserConfig.EnableCopyButton || g.CopyButton.Enable
 
 	// When tags are disabled, clear Post.Tags so template tag pills do not render.
 	if !tagsEnabled {
@@ -251,48 +809,85 @@ func (g *Generator) assembleBlogWithTemplates(ctx context.Context, posts models.
 	// Sort posts by date descending
 	posts.SortByDate()
 
-	// Render individual post pages
+	// Enrich posts with BlogRoot for post cards on index, tag and series pages.
+	// This is done once up front, as pages are rendered concurrently.
 	for _, post := range posts {
+		post.BlogRoot = string(g.BlogRoot)
+	}
+
+	pages := newPageCache(store, posts)
+
+	// Index each series by slug, with its parts in reading order
+	allSeries := posts.GetAllSeries()
+	series := make(map[string]models.PostList)
+	for _, slug := range allSeries {
+		series[slug] = posts.FilterBySeries(slug)
+	}
+
+	// Render individual post pages
+	postPages := make([][]byte, len(posts))
+	if err := workpool.Run(ctx, g.Jobs.Jobs, len(posts), func(i int) error {
+		post := posts[i]
 		data := models.PostPageData{
 			BaseData: models.BaseData{
 				SiteTitle:   g.SiteTitle.SiteTitle,
 				PageTitle:   post.Title,
 				Description: post.Description,
-				Year:        time.Now().Year(),
+				Year:        g.Clock.Now().Year(),
 				BlogRoot:    string(g.BlogRoot),
 				Environment: g.Environment.Environment,
 				TagsEnabled: tagsEnabled,
+				CopyButton:  copyButton,
 				Custom:      g.CustomData.Data,
 				Path:        g.pagePath("post", post.Slug),
 			},
 			Post: post,
 		}
+		if parts, ok := series[post.SeriesSlug()]; ok {
+			data.Series = parts
+			for i, part := range parts {
+				if part != post {
+					continue
+				}
+				data.SeriesPart = i + 1
+				if i > 0 {
+					data.PrevInSeries = parts[i-1]
+				}
+				if i < len(parts)-1 {
+					data.NextInSeries = parts[i+1]
+				}
+			}
+		}
 
-		rendered, err := g.renderer.RenderPost(data)
+		rendered, err := g.renderPage(pages, "post", data, func() ([]byte, error) {
+			return g.renderer.RenderPost(data)
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to render post %s: %w", post.Slug, err)
+			return fmt.Errorf("failed to render post %s: %w", post.Slug, err)
 		}
-
-		blog.Posts[post.Slug] = rendered
+		postPages[i] = rendered
+		return nil
+	}); err != nil {
+		return nil, err
 	}
-
-	// Enrich posts with BlogRoot for index page
-	indexPosts := make([]*models.Post, len(posts))
 	for i, post := range posts {
-		indexPosts[i] = post
-		indexPosts[i].BlogRoot = string(g.BlogRoot)
+		blog.Posts[post.Slug] = postPages[i]
 	}
 
+	indexPosts := make([]*models.Post, len(posts))
+	copy(indexPosts, posts)
+
 	// Render index page
 	indexData := models.IndexPageData{
 		BaseData: models.BaseData{
 			SiteTitle:   g.SiteTitle.SiteTitle,
 			PageTitle:   "Home",
 			Description: "Recent blog posts",
-			Year:        time.Now().Year(),
+			Year:        g.Clock.Now().Year(),
 			BlogRoot:    string(g.BlogRoot),
 			Environment: g.Environment.Environment,
 			TagsEnabled: tagsEnabled,
+			CopyButton:  copyButton,
 			Custom:      g.CustomData.Data,
 			Path:        g.pagePath("index", ""),
 		},
@@ -300,32 +895,72 @@ func (g *Generator) assembleBlogWithTemplates(ctx context.Context, posts models.
 		TotalPosts: len(indexPosts),
 	}
 
-	index, err := g.renderer.RenderIndex(indexData)
+	index, err := g.renderPage(pages, "index", indexData, func() ([]byte, error) {
+		return g.renderer.RenderIndex(indexData)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to render index: %w", err)
 	}
 	blog.Index = index
 
+	// Render series pages
+	seriesPages := make([][]byte, len(allSeries))
+	if err := workpool.Run(ctx, g.Jobs.Jobs, len(allSeries), func(i int) error {
+		slug := allSeries[i]
+		parts := series[slug]
+
+		seriesData := models.SeriesPageData{
+			BaseData: models.BaseData{
+				SiteTitle:   g.SiteTitle.SiteTitle,
+				PageTitle:   "Series: " + parts[0].Series,
+				Description: fmt.Sprintf("All parts of the %s series", parts[0].Series),
+				Year:        g.Clock.Now().Year(),
+				BlogRoot:    string(g.BlogRoot),
+				Environment: g.Environment.Environment,
+				TagsEnabled: tagsEnabled,
+				CopyButton:  copyButton,
+				Custom:      g.CustomData.Data,
+				Path:        g.pagePath("series", slug),
+			},
+			Series:    parts[0].Series,
+			Slug:      slug,
+			Posts:     parts,
+			PostCount: len(parts),
+		}
+
+		rendered, err := g.renderPage(pages, "series", seriesData, func() ([]byte, error) {
+			return g.renderer.RenderSeries(seriesData)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to render series page %s: %w", slug, err)
+		}
+		seriesPages[i] = rendered
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	for i, slug := range allSeries {
+		blog.Series[slug] = seriesPages[i]
+	}
+
 	if tagsEnabled {
 		// Render tag pages
 		allTags := posts.GetAllTags()
-		for _, tag := range allTags {
+		tagPages := make([][]byte, len(allTags))
+		if err := workpool.Run(ctx, g.Jobs.Jobs, len(allTags), func(i int) error {
+			tag := allTags[i]
 			tagPosts := posts.FilterByTag(tag)
 
-			// Enrich tag posts with BlogRoot
-			for _, post := range tagPosts {
-				post.BlogRoot = string(g.BlogRoot)
-			}
-
 			tagData := models.TagPageData{
 				BaseData: models.BaseData{
 					SiteTitle:   g.SiteTitle.SiteTitle,
 					PageTitle:   "Tag: " + tag,
 					Description: fmt.Sprintf("Posts tagged with %s", tag),
-					Year:        time.Now().Year(),
+					Year:        g.Clock.Now().Year(),
 					BlogRoot:    string(g.BlogRoot),
 					Environment: g.Environment.Environment,
 					TagsEnabled: true,
+					CopyButton:  copyButton,
 					Custom:      g.CustomData.Data,
 					Path:        g.pagePath("tag", tag),
 				},
@@ -334,12 +969,19 @@ func (g *Generator) assembleBlogWithTemplates(ctx context.Context, posts models.
 				PostCount: len(tagPosts),
 			}
 
-			rendered, err := g.renderer.RenderTag(tagData)
+			rendered, err := g.renderPage(pages, "tag", tagData, func() ([]byte, error) {
+				return g.renderer.RenderTag(tagData)
+			})
 			if err != nil {
-				return nil, fmt.Errorf("failed to render tag page %s: %w", tag, err)
+				return fmt.Errorf("failed to render tag page %s: %w", tag, err)
 			}
-
-			blog.Tags[tag] = rendered
+			tagPages[i] = rendered
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		for i, tag := range allTags {
+			blog.Tags[tag] = tagPages[i]
 		}
 
 		// Render tags index page
@@ -362,10 +1004,11 @@ func (g *Generator) assembleBlogWithTemplates(ctx context.Context, posts models.
 				SiteTitle:   g.SiteTitle.SiteTitle,
 				PageTitle:   "All Tags",
 				Description: "Browse all topics covered in this blog",
-				Year:        time.Now().Year(),
+				Year:        g.Clock.Now().Year(),
 				BlogRoot:    string(g.BlogRoot),
 				Environment: g.Environment.Environment,
 				TagsEnabled: true,
+				CopyButton:  copyButton,
 				Custom:      g.CustomData.Data,
 				Path:        g.pagePath("tagsIndex", ""),
 			},
@@ -373,7 +1016,9 @@ func (g *Generator) assembleBlogWithTemplates(ctx context.Context, posts models.
 			TotalTags: len(tagInfos),
 		}
 
-		tagsIndex, err := g.renderer.RenderTagsIndex(tagsIndexData)
+		tagsIndex, err := g.renderPage(pages, "tagsIndex", tagsIndexData, func() ([]byte, error) {
+			return g.renderer.RenderTagsIndex(tagsIndexData)
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to render tags index: %w", err)
 		}
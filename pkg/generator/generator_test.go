@@ -7,18 +7,29 @@ package generator
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"html/template"
+	"image"
+	"image/jpeg"
 	"io/fs"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"testing/fstest"
 	"time"
 
+	"github.com/harrydayexe/GoBlog/v2/pkg/cache"
 	"github.com/harrydayexe/GoBlog/v2/pkg/config"
+	"github.com/harrydayexe/GoBlog/v2/pkg/images"
 	"github.com/harrydayexe/GoBlog/v2/pkg/models"
+	"github.com/harrydayexe/GoBlog/v2/pkg/parser"
 )
 
 // TestNew tests creating a new Generator with various options.
@@ -1509,6 +1520,1162 @@ func TestNew_WithLogger(t *testing.T) {
 	}
 }
 
+// TestWithDrafts tests that the WithDrafts option is applied correctly.
+func TestWithDrafts(t *testing.T) {
+	t.Parallel()
+
+	testFS := os.DirFS("testdata")
+
+	genDefault := New(testFS, nil)
+	if genDefault.Drafts.Include {
+		t.Error("Generator without WithDrafts() should have Include = false")
+	}
+
+	genDrafts := New(testFS, nil, config.WithDrafts())
+	if !genDrafts.Drafts.Include {
+		t.Error("Generator with WithDrafts() should have Include = true")
+	}
+}
+
+// TestGenerate_Drafts verifies that draft posts are left out of post, index
+// and tag output by default and are rendered with a Draft badge when
+// WithDrafts() is supplied.
+func TestGenerate_Drafts(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"published.md": {Data: []byte(`---
+title: Published Post
+date: 2024-01-15
+description: A published post
+tags: [go]
+---
+Published content.
+`)},
+		"draft.md": {Data: []byte(`---
+title: Draft Post
+date: 2024-01-16
+description: A work in progress
+tags: [go, wip]
+draft: true
+---
+Draft content.
+`)},
+	}
+
+	renderer, err := NewTemplateRenderer(os.DirFS("../templates/default"))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+
+	// Default: drafts are excluded everywhere.
+	blog, err := New(testFS, renderer).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if _, ok := blog.Posts["draft-post"]; ok {
+		t.Error("draft post should not be rendered by default")
+	}
+	if _, ok := blog.Posts["published-post"]; !ok {
+		t.Error("published post should be rendered")
+	}
+	if contains(string(blog.Index), "Draft Post") {
+		t.Error("index should not list the draft post by default")
+	}
+	if _, ok := blog.Tags["wip"]; ok {
+		t.Error("tag used only by a draft should not get a tag page by default")
+	}
+	if contains(string(blog.Tags["go"]), "Draft Post") {
+		t.Error("tag page should not list the draft post by default")
+	}
+
+	// Raw output also excludes drafts.
+	rawBlog, err := New(testFS, nil, config.WithRawOutput()).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() with RawOutput error = %v", err)
+	}
+	if _, ok := rawBlog.Posts["draft-post"]; ok {
+		t.Error("draft post should not be rendered in raw output by default")
+	}
+
+	// WithDrafts: drafts are rendered and marked.
+	blogDrafts, err := New(testFS, renderer, config.WithDrafts()).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() with WithDrafts error = %v", err)
+	}
+	draftHTML, ok := blogDrafts.Posts["draft-post"]
+	if !ok {
+		t.Fatal("draft post should be rendered with WithDrafts()")
+	}
+	if !contains(string(draftHTML), "bg-amber-100") {
+		t.Error("draft post page should show a Draft badge")
+	}
+	if !contains(string(blogDrafts.Index), "Draft Post") {
+		t.Error("index should list the draft post with WithDrafts()")
+	}
+	if _, ok := blogDrafts.Tags["wip"]; !ok {
+		t.Error("tag used by a draft should get a tag page with WithDrafts()")
+	}
+	if contains(string(blogDrafts.Posts["published-post"]), "bg-amber-100") {
+		t.Error("published post page should not show a Draft badge")
+	}
+}
+
+// TestGenerate_ScheduledPublishing verifies that future-dated and expired
+// posts are hidden according to the injected clock, and that NextUpdate
+// reports when the next change is due.
+// TestGenerate_WikiLinksToUnpublished verifies that a wiki link to a draft
+// or scheduled post fails the build, unless drafts are included.
+func TestGenerate_WikiLinksToUnpublished(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"live.md":   {Data: []byte("---\ntitle: Live\ndescription: d\ndate: 2024-01-01\n---\nSee [[Draft]].")},
+		"draft.md":  {Data: []byte("---\ntitle: Draft\ndescription: d\ndate: 2024-01-01\ndraft: true\n---\nSee [[Future]].")},
+		"future.md": {Data: []byte("---\ntitle: Future\ndescription: d\ndate: 2024-03-01\n---\nFuture.")},
+	}
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	generate := func(opts ...config.GeneratorOption) (*GeneratedBlog, error) {
+		opts = append(opts, config.WithRawOutput(), config.WithClock(func() time.Time { return now }).AsGeneratorOption())
+		gen := New(testFS, nil, opts...)
+		gen.ParserConfig.EnableWikiLinks = true
+		return gen.Generate(context.Background())
+	}
+
+	var parseErrs parser.ParseErrors
+	if _, err := generate(); !errors.As(err, &parseErrs) || len(parseErrs.Errors) != 1 || parseErrs.Errors[0].Path != "live.md" {
+		t.Fatalf("expected live.md's link to the draft to fail, got %v", err)
+	}
+
+	// With drafts included, the draft is published and links to the
+	// scheduled post, which is not
+	parseErrs = parser.ParseErrors{}
+	if _, err := generate(config.WithDrafts()); !errors.As(err, &parseErrs) || len(parseErrs.Errors) != 1 || parseErrs.Errors[0].Path != "draft.md" {
+		t.Fatalf("expected draft.md's link to the scheduled post to fail, got %v", err)
+	}
+
+	now = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	blog, err := generate(config.WithDrafts())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(blog.Posts) != 3 {
+		t.Errorf("expected every post once the scheduled post is live, got %d", len(blog.Posts))
+	}
+}
+
+// TestGenerate_SlugCollisionWithUnpublished verifies that an excluded draft
+// does not take the slug of a post that is published, but a scheduled post
+// cannot share a live post's slug.
+func TestGenerate_SlugCollisionWithUnpublished(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"a-draft.md": {Data: []byte("---\ntitle: Post\ndescription: d\ndate: 2024-01-01\ndraft: true\n---\nDraft.")},
+		"b-live.md":  {Data: []byte("---\ntitle: Post\ndescription: d\ndate: 2024-01-01\n---\nLive.")},
+	}
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	gen := New(testFS, nil,
+		config.WithRawOutput(),
+		config.WithClock(func() time.Time { return now }).AsGeneratorOption(),
+	)
+
+	blog, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if got := string(blog.Posts["post"]); !strings.Contains(got, "Live.") {
+		t.Errorf("expected the live post under its slug, got %q", got)
+	}
+
+	testFS["c-future.md"] = &fstest.MapFile{Data: []byte("---\ntitle: Post\ndescription: d\ndate: 2024-03-01\n---\nFuture.")}
+	_, err = gen.Generate(context.Background())
+	var parseErrs parser.ParseErrors
+	var collision parser.SlugCollisionError
+	if !errors.As(err, &parseErrs) || len(parseErrs.Errors) != 1 || parseErrs.Errors[0].Path != "c-future.md" ||
+		!errors.As(parseErrs.Errors[0], &collision) || collision.ExistingPath != "b-live.md" {
+		t.Errorf("expected the scheduled post to collide with b-live.md, got %v", err)
+	}
+}
+
+func TestGenerate_ScheduledPublishing(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"live.md":     {Data: []byte("---\ntitle: Live\ndescription: d\ndate: 2024-01-01\n---\nLive.")},
+		"future.md":   {Data: []byte("---\ntitle: Future\ndescription: d\ndate: 2024-03-01\n---\nFuture.")},
+		"expired.md":  {Data: []byte("---\ntitle: Expired\ndescription: d\ndate: 2023-01-01\nexpiryDate: 2023-06-01\n---\nExpired.")},
+		"expiring.md": {Data: []byte("---\ntitle: Expiring\ndescription: d\ndate: 2024-01-01\nexpiryDate: 2024-02-01\n---\nExpiring.")},
+	}
+
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	gen := New(testFS, nil,
+		config.WithRawOutput(),
+		config.WithClock(func() time.Time { return now }).AsGeneratorOption(),
+	)
+
+	blog, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	for _, slug := range []string{"live", "expiring"} {
+		if _, ok := blog.Posts[slug]; !ok {
+			t.Errorf("post %q should be published at %v", slug, now)
+		}
+	}
+	for _, slug := range []string{"future", "expired"} {
+		if _, ok := blog.Posts[slug]; ok {
+			t.Errorf("post %q should not be published at %v", slug, now)
+		}
+	}
+
+	wantNext := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	if !blog.NextUpdate.Equal(wantNext) {
+		t.Errorf("NextUpdate = %v, want %v", blog.NextUpdate, wantNext)
+	}
+
+	// Nothing left to schedule once every change has passed.
+	later := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	gen = New(testFS, nil,
+		config.WithRawOutput(),
+		config.WithClock(func() time.Time { return later }).AsGeneratorOption(),
+	)
+	blog, err = gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !blog.NextUpdate.IsZero() {
+		t.Errorf("NextUpdate = %v, want zero", blog.NextUpdate)
+	}
+	if _, ok := blog.Posts["future"]; !ok {
+		t.Error("future post should be published once its date has passed")
+	}
+	if _, ok := blog.Posts["expiring"]; ok {
+		t.Error("expiring post should be withdrawn once its expiry has passed")
+	}
+}
+
+// TestGenerate_TOCSidebar verifies that the default post template renders a
+// table of contents only for posts with enough headings.
+func TestGenerate_TOCSidebar(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"long.md":  {Data: []byte("---\ntitle: Long\ndate: 2024-01-01\ndescription: d\n---\n## One\n\n## Two\n\n### Two A\n")},
+		"short.md": {Data: []byte("---\ntitle: Short\ndate: 2024-01-01\ndescription: d\n---\n## Only\n")},
+	}
+
+	renderer, err := NewTemplateRenderer(os.DirFS("../templates/default"))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+
+	blog, err := New(testFS, renderer).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	long := string(blog.Posts["long"])
+	if !contains(long, `aria-label="Table of contents"`) {
+		t.Error("long post should render a table of contents")
+	}
+	if !contains(long, `href="#two-a"`) {
+		t.Error("table of contents should link to nested headings")
+	}
+	if contains(string(blog.Posts["short"]), `aria-label="Table of contents"`) {
+		t.Error("short post should not render a table of contents")
+	}
+}
+
+// TestGenerate_Math verifies that config.WithMath reaches the parser.
+func TestGenerate_Math(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\nWe have $x^2$.\n")},
+	}
+
+	blog, err := New(testFS, nil, config.WithRawOutput(), config.WithMath()).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !contains(string(blog.Posts["post"]), "<msup><mi>x</mi><mn>2</mn></msup>") {
+		t.Errorf("expected math rendered to MathML, got %s", blog.Posts["post"])
+	}
+
+	blog, err = New(testFS, nil, config.WithRawOutput()).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if contains(string(blog.Posts["post"]), "<math") {
+		t.Errorf("expected math to be left as text without config.WithMath, got %s", blog.Posts["post"])
+	}
+}
+
+// TestGenerate_Markdown verifies that the markdown dialect and extension
+// options reach the parser.
+func TestGenerate_Markdown(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\n# Top\n\n~~old~~ and https://example.com\n")},
+	}
+
+	blog, err := New(testFS, nil, config.WithRawOutput()).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if contains(string(blog.Posts["post"]), "<del>") {
+		t.Errorf("expected no strikethrough in the default dialect, got %s", blog.Posts["post"])
+	}
+
+	blog, err = New(testFS, nil,
+		config.WithRawOutput(),
+		config.WithDialect("gfm"),
+		config.WithMarkdownExtension("linkify", false),
+		config.WithHeadingShift(1),
+	).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	html := string(blog.Posts["post"])
+	if !contains(html, "<del>old</del>") {
+		t.Errorf("expected strikethrough with the gfm dialect, got %s", html)
+	}
+	if contains(html, `<a href="https://example.com">`) {
+		t.Errorf("expected linkify to be disabled, got %s", html)
+	}
+	if !contains(html, "<h2") {
+		t.Errorf("expected headings to be shifted down a level, got %s", html)
+	}
+}
+
+// TestGenerate_Callouts verifies that callouts are rendered on post pages and
+// that the default templates style them.
+func TestGenerate_Callouts(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\n> [!NOTE]\n> Read this.\n")},
+	}
+
+	renderer, err := NewTemplateRenderer(os.DirFS("../templates/default"))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+
+	blog, err := New(testFS, renderer).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	html := string(blog.Posts["post"])
+	if !contains(html, `<aside class="callout callout-note">`) {
+		t.Errorf("expected the callout to be rendered, got %s", html)
+	}
+	if !contains(html, ".prose .callout-note") {
+		t.Error("expected the default templates to style callouts")
+	}
+}
+
+// TestGenerate_HeadingAnchors verifies that config.WithHeadingAnchors adds
+// permalinks to post headings and that the default templates style them.
+func TestGenerate_HeadingAnchors(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\n# Intro\n\n## Usage\n")},
+	}
+
+	renderer, err := NewTemplateRenderer(os.DirFS("../templates/default"))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+
+	blog, err := New(testFS, renderer, config.WithHeadingAnchors("¶", "before", 1, "Link to %s")).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	html := string(blog.Posts["post"])
+	for _, want := range []string{
+		`<h1 id="intro"><a class="heading-anchor" href="#intro" aria-label="Link to Intro">¶</a> Intro</h1>`,
+		`<h2 id="usage"><a class="heading-anchor" href="#usage" aria-label="Link to Usage">¶</a> Usage</h2>`,
+		".prose .heading-anchor",
+	} {
+		if !contains(html, want) {
+			t.Errorf("expected %s in output, got %s", want, html)
+		}
+	}
+
+	blog, err = New(testFS, renderer).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if contains(string(blog.Posts["post"]), `class="heading-anchor"`) {
+		t.Error("expected no heading anchors by default")
+	}
+}
+
+// TestGenerate_ChromaCSS verifies that config.WithHighlightStyle highlights
+// code, that every build carries a chroma.css stylesheet, and that the default
+// templates link it.
+func TestGenerate_ChromaCSS(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\n```go\nfunc main() {}\n```\n")},
+	}
+
+	renderer, err := NewTemplateRenderer(os.DirFS("../templates/default"))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+
+	blog, err := New(testFS, renderer,
+		config.WithBlogRoot("/blog/").AsGeneratorOption(),
+		config.WithHighlightStyle("monokailight", "monokai"),
+	).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	html := string(blog.Posts["post"])
+	if !contains(html, `<pre class="chroma">`) {
+		t.Errorf("expected highlighted code, got %s", html)
+	}
+	if !contains(html, `<link rel="stylesheet" href="/blog/chroma.css">`) {
+		t.Errorf("expected the stylesheet to be linked, got %s", html)
+	}
+	if !contains(string(blog.ChromaCSS), "@media (prefers-color-scheme: dark)") {
+		t.Errorf("expected dark mode rules in ChromaCSS, got %s", blog.ChromaCSS)
+	}
+
+	blog, err = New(testFS, renderer).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(blog.ChromaCSS) == 0 {
+		t.Error("expected a default ChromaCSS")
+	}
+	if contains(string(blog.Posts["post"]), `<pre class="chroma">`) {
+		t.Error("expected code not to be highlighted without WithHighlightStyle")
+	}
+
+	_, err = New(testFS, renderer, config.WithHighlightStyle("no-such-style", "")).Generate(context.Background())
+	if err == nil || !contains(err.Error(), `unknown highlight style "no-such-style"`) {
+		t.Errorf("Generate() error = %v, want an unknown style error", err)
+	}
+}
+
+// TestGenerate_CopyButton verifies that config.WithCopyButton adds copy
+// buttons to highlighted code and that the default templates handle them.
+func TestGenerate_CopyButton(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\n```go title=\"main.go\"\nfunc main() {}\n```\n")},
+	}
+
+	renderer, err := NewTemplateRenderer(os.DirFS("../templates/default"))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+
+	blog, err := New(testFS, renderer,
+		config.WithHighlightStyle("github", "github-dark"),
+		config.WithCopyButton(),
+	).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	html := string(blog.Posts["post"])
+	for _, want := range []string{
+		`<figcaption class="code-title">main.go</figcaption>`,
+		`<button type="button" class="code-copy"`,
+		`closest(".code-copy")`,
+		".prose .code-title",
+	} {
+		if !contains(html, want) {
+			t.Errorf("expected %s in output, got %s", want, html)
+		}
+	}
+
+	// Without copy buttons, no page carries the script for them
+	blog, err = New(testFS, renderer, config.WithHighlightStyle("github", "github-dark")).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for name, page := range map[string][]byte{"post": blog.Posts["post"], "index": blog.Index} {
+		if contains(string(page), `closest(".code-copy")`) {
+			t.Errorf("expected no copy button script on the %s page", name)
+		}
+	}
+}
+
+// TestGenerate_ExternalLinks verifies that config.WithExternalLinks marks up
+// links to other sites and leaves links to the site and trusted domains alone.
+func TestGenerate_ExternalLinks(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\n" +
+			"[Go](https://go.dev) [Home](https://www.example.com/) [Repo](https://github.com/harrydayexe/GoBlog)\n")},
+	}
+
+	blog, err := New(testFS, nil,
+		config.WithRawOutput(),
+		config.WithExternalLinks("example.com", []string{"nofollow"}, true, "external", "github.com"),
+	).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	html := string(blog.Posts["post"])
+	for _, want := range []string{
+		`<a href="https://go.dev" rel="nofollow noopener" target="_blank" class="external">Go</a>`,
+		`<a href="https://www.example.com/">Home</a>`,
+		`<a href="https://github.com/harrydayexe/GoBlog">Repo</a>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s in output, got %s", want, html)
+		}
+	}
+}
+
+// TestGenerate_Shortcodes verifies that shortcode templates are loaded by
+// NewTemplateRenderer, replace built-in shortcodes of the same name, and are
+// replaced in turn by Go handlers set in ParserConfig.
+func TestGenerate_Shortcodes(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\n" +
+			"{{< note >}}\nRead *this*.\n{{< /note >}}\n\n" +
+			"{{< figure cat.jpg >}}\n\n" +
+			"{{< youtube dQw4w9WgXcQ >}}\n\n" +
+			"Press {{< kbd Esc >}}.\n")},
+	}
+	templatesFS := fstest.MapFS{
+		"pages/post.tmpl":        {Data: []byte(`{{.Post.HTMLContent}}`)},
+		"pages/index.tmpl":       {Data: []byte(`index`)},
+		"pages/tag.tmpl":         {Data: []byte(`tag`)},
+		"pages/tags-index.tmpl":  {Data: []byte(`tags`)},
+		"shortcodes/note.tmpl":   {Data: []byte(`<aside class="note">{{upper .Post.Title}}: {{.InnerHTML}}</aside>`)},
+		"shortcodes/figure.tmpl": {Data: []byte(`<img class="themed" src="{{.Arg 0}}">`)},
+		"shortcodes/kbd.tmpl":    {Data: []byte(`<kbd>template</kbd>`)},
+	}
+
+	renderer, err := NewTemplateRenderer(templatesFS, config.WithFuncs(template.FuncMap{
+		"upper": strings.ToUpper,
+	}))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+
+	gen := New(testFS, renderer)
+	gen.ParserConfig.Shortcodes = map[string]parser.ShortcodeFunc{
+		"kbd": func(sc parser.Shortcode) (template.HTML, error) {
+			return template.HTML("<kbd>" + template.HTMLEscapeString(sc.Arg(0)) + "</kbd>"), nil
+		},
+	}
+	blog, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	html := string(blog.Posts["post"])
+	for _, want := range []string{
+		`<aside class="note">POST: Read <em>this</em>.</aside>`,
+		`<img class="themed" src="cat.jpg">`,
+		`<iframe src="https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ"`,
+		`Press <kbd>Esc</kbd>.`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s in output, got %s", want, html)
+		}
+	}
+}
+
+// TestGenerate_UnknownShortcode verifies that config.WithShortcodes reports a
+// shortcode without a handler as a FileError giving its line.
+func TestGenerate_UnknownShortcode(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\nText.\n\n{{< gallery >}}\n")},
+	}
+
+	_, err := New(testFS, nil, config.WithRawOutput(), config.WithShortcodes()).Generate(context.Background())
+	var parseErrs parser.ParseErrors
+	if !errors.As(err, &parseErrs) || len(parseErrs.Errors) != 1 || parseErrs.Errors[0].Path != "post.md" {
+		t.Fatalf("Generate() error = %v, want a FileError for post.md", err)
+	}
+	var scErr parser.ShortcodeError
+	if fe := parseErrs.Errors[0]; !errors.As(fe, &scErr) || scErr.Line != 8 || !errors.Is(fe, parser.ErrUnknownShortcode) {
+		t.Errorf("Generate() error = %v, want an unknown shortcode on line 8", fe)
+	}
+}
+
+// TestGenerate_RenderHooks verifies that render hook templates are loaded by
+// NewTemplateRenderer, and that hooks set in ParserConfig win over them.
+func TestGenerate_RenderHooks(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\n" +
+			"## Intro\n\n[Go](https://go.dev) ![Gopher](/gopher.png \"The gopher\")\n")},
+	}
+	templatesFS := fstest.MapFS{
+		"pages/post.tmpl":           {Data: []byte(`{{.Post.HTMLContent}}`)},
+		"pages/index.tmpl":          {Data: []byte(`index`)},
+		"pages/tag.tmpl":            {Data: []byte(`tag`)},
+		"pages/tags-index.tmpl":     {Data: []byte(`tags`)},
+		"render-hooks/link.tmpl":    {Data: []byte(`<a href="{{.Destination}}">{{.Text}} ↗</a>`)},
+		"render-hooks/image.tmpl":   {Data: []byte(`<figure>{{.HTML}}<figcaption>{{.Title}}</figcaption></figure>`)},
+		"render-hooks/heading.tmpl": {Data: []byte(`<h{{.Level}}>template</h{{.Level}}>`)},
+	}
+
+	renderer, err := NewTemplateRenderer(templatesFS)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+
+	gen := New(testFS, renderer)
+	gen.ParserConfig.RenderHooks.Heading = func(d parser.HeadingData) (template.HTML, error) {
+		return template.HTML(fmt.Sprintf(`<h%d id="%s">%s</h%d>`, d.Level, d.ID, d.Text, d.Level)), nil
+	}
+	blog, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	html := string(blog.Posts["post"])
+	for _, want := range []string{
+		`<h2 id="intro">Intro</h2>`,
+		`<a href="https://go.dev">Go ↗</a>`,
+		`<figure><img src="/gopher.png" alt="Gopher" title="The gopher" /><figcaption>The gopher</figcaption></figure>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s in output, got %s", want, html)
+		}
+	}
+}
+
+// TestNewTemplateRenderer_UnknownRenderHook verifies that a file under
+// render-hooks/ that names no kind of node is rejected.
+func TestNewTemplateRenderer_UnknownRenderHook(t *testing.T) {
+	t.Parallel()
+
+	templatesFS := fstest.MapFS{
+		"render-hooks/table.tmpl": {Data: []byte(`<table></table>`)},
+	}
+	_, err := NewTemplateRenderer(templatesFS)
+	if err == nil || !strings.Contains(err.Error(), "unknown render hook render-hooks/table.tmpl") {
+		t.Errorf("NewTemplateRenderer() error = %v, want an unknown render hook", err)
+	}
+}
+
+// TestGenerate_UnsafeHTML verifies that config.WithUnsafeHTML keeps raw HTML
+// on the allowlist, extended by its arguments, and removes scripts.
+func TestGenerate_UnsafeHTML(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\n" +
+			"Press <kbd>Esc</kbd> <marquee scrollamount=\"2\" onmouseover=\"steal()\">now</marquee>.\n\n" +
+			"<script>steal()</script>\n")},
+	}
+	templatesFS := fstest.MapFS{
+		"pages/post.tmpl":       {Data: []byte(`{{.Post.HTMLContent}}`)},
+		"pages/index.tmpl":      {Data: []byte(`index`)},
+		"pages/tag.tmpl":        {Data: []byte(`tag`)},
+		"pages/tags-index.tmpl": {Data: []byte(`tags`)},
+	}
+
+	renderer, err := NewTemplateRenderer(templatesFS)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+
+	blog, err := New(testFS, renderer, config.WithUnsafeHTML("marquee[scrollamount]")).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	html := string(blog.Posts["post"])
+	if want := `Press <kbd>Esc</kbd> <marquee scrollamount="2">now</marquee>.`; !strings.Contains(html, want) {
+		t.Errorf("expected %s in output, got %s", want, html)
+	}
+	if strings.Contains(html, "steal") {
+		t.Errorf("expected scripts and event handlers to be removed, got %s", html)
+	}
+
+	_, err = New(testFS, renderer, config.WithUnsafeHTML("marquee[")).Generate(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "missing ]") {
+		t.Errorf("Generate() error = %v, want an invalid allowlist error", err)
+	}
+}
+
+// TestGenerate_Schema verifies that posts are checked against schema.yaml in
+// the posts directory, or the file given by config.WithSchema instead.
+func TestGenerate_Schema(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md":     {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\ntags: [go]\n---\nBody\n")},
+		"schema.yaml": {Data: []byte("fields:\n  author:\n    required: true\n")},
+	}
+
+	_, err := New(testFS, nil, config.WithRawOutput()).Generate(context.Background())
+	var parseErrs parser.ParseErrors
+	if !errors.As(err, &parseErrs) || len(parseErrs.Errors) != 1 || !errors.Is(parseErrs.Errors[0], parser.ErrMissingKey) {
+		t.Fatalf("Generate() error = %v, want a missing author", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte("fields:\n  tags:\n    enum: [go, web]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	blog, err := New(testFS, nil, config.WithRawOutput(), config.WithSchema(path)).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() with config.WithSchema error = %v", err)
+	}
+	if _, ok := blog.Posts["post"]; !ok {
+		t.Error("expected the post to be generated")
+	}
+
+	if err := os.WriteFile(path, []byte("fields:\n  tags:\n    type: array\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = New(testFS, nil, config.WithRawOutput(), config.WithSchema(path)).Generate(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `unknown type "array"`) {
+		t.Errorf("Generate() error = %v, want an invalid schema", err)
+	}
+}
+
+// TestGenerate_Lenient verifies that config.WithLenient builds the blog from
+// the posts that parse and lists the others in GeneratedBlog.Skipped, while
+// the default fails on them.
+func TestGenerate_Lenient(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"good.md":    {Data: []byte("---\ntitle: Good\ndate: 2024-01-01\ndescription: d\n---\nBody\n")},
+		"typo.md":    {Data: []byte("---\ntitle: Typo\ndate: 2024-01-01\ndescription: d\ntags: [go\n---\nBody\n")},
+		"missing.md": {Data: []byte("---\ntitle: Missing\ndate: 2024-01-01\n---\nBody\n")},
+	}
+
+	var parseErrs parser.ParseErrors
+	if _, err := New(testFS, nil, config.WithRawOutput()).Generate(context.Background()); !errors.As(err, &parseErrs) {
+		t.Fatalf("Generate() error = %v, want ParseErrors by default", err)
+	}
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	blog, err := New(testFS, nil,
+		config.WithRawOutput(),
+		config.WithLenient(),
+		config.WithLogger(logger).AsGeneratorOption(),
+	).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() with config.WithLenient error = %v", err)
+	}
+	if len(blog.Posts) != 1 || blog.Posts["good"] == nil {
+		t.Errorf("expected only the good post, got %d posts", len(blog.Posts))
+	}
+	var skipped []string
+	for _, fe := range blog.Skipped {
+		skipped = append(skipped, fe.Path)
+	}
+	if want := []string{"missing.md", "typo.md"}; !slices.Equal(skipped, want) {
+		t.Errorf("Skipped = %v, want %v", skipped, want)
+	}
+	for _, path := range skipped {
+		if !strings.Contains(logs.String(), "level=WARN msg=\"Skipping post that failed to parse\" path="+path) {
+			t.Errorf("expected a warning for %s, got logs:\n%s", path, logs.String())
+		}
+	}
+}
+
+// TestGenerate_PostParams verifies that unknown front matter keys reach
+// templates through Post.Params and the param template functions.
+func TestGenerate_PostParams(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\nhero: /hero.png\nrating: 4\nauthors: [ann, bob]\n---\nText.\n")},
+	}
+	templatesFS := fstest.MapFS{
+		"pages/post.tmpl":       {Data: []byte(`hero={{.Post.Params.hero}} rating={{add1 (paramInt .Post.Params "rating")}} authors={{range paramStrings .Post.Params "authors"}}[{{.}}]{{end}}`)},
+		"pages/index.tmpl":      {Data: []byte(`index`)},
+		"pages/tag.tmpl":        {Data: []byte(`tag`)},
+		"pages/tags-index.tmpl": {Data: []byte(`tags`)},
+	}
+
+	renderer, err := NewTemplateRenderer(templatesFS, config.WithFuncs(template.FuncMap{
+		"add1": func(n int) int { return n + 1 },
+	}))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+
+	blog, err := New(testFS, renderer).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if want := "hero=/hero.png rating=5 authors=[ann][bob]"; string(blog.Posts["post"]) != want {
+		t.Errorf("post page = %q, want %q", blog.Posts["post"], want)
+	}
+}
+
+// TestGenerate_Jobs verifies that the generated blog does not depend on the
+// number of workers, and that a canceled context stops generation.
+func TestGenerate_Jobs(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{}
+	for i := range 30 {
+		testFS[fmt.Sprintf("post-%02d.md", i)] = &fstest.MapFile{Data: []byte(fmt.Sprintf(
+			"---\ntitle: Post %d\ndate: 2024-01-%02d\ndescription: d\ntags: [t%d, all]\nseries: S%d\n---\nText %d.\n",
+			i, i%28+1, i%4, i%3, i))}
+	}
+
+	renderer, err := NewTemplateRenderer(os.DirFS("../templates/default"))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+
+	want, err := New(testFS, renderer, config.WithJobs(1)).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, jobs := range []int{0, 8} {
+		got, err := New(testFS, renderer, config.WithJobs(jobs)).Generate(context.Background())
+		if err != nil {
+			t.Fatalf("Generate() with %d jobs error = %v", jobs, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Generate() with %d jobs differs from Generate() with 1 job", jobs)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	blog, err := New(testFS, renderer, config.WithJobs(4)).Generate(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Generate() error = %v, want context.Canceled", err)
+	}
+	if blog != nil {
+		t.Errorf("Generate() with a canceled context should return nil blog")
+	}
+}
+
+// TestGenerate_Cache verifies that with a cache, a second build gives the
+// same blog without rendering unchanged pages again.
+func TestGenerate_Cache(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"a.md": {Data: []byte("---\ntitle: A\ndate: 2024-01-01\ndescription: d\ntags: [go]\n---\nA text.\n")},
+		"b.md": {Data: []byte("---\ntitle: B\ndate: 2024-01-02\ndescription: d\n---\nB text.\n")},
+	}
+	var renders atomic.Int32
+	templatesFS := fstest.MapFS{
+		"pages/post.tmpl":       {Data: []byte(`{{count}}{{.Post.Title}}: {{.Post.HTMLContent}}`)},
+		"pages/index.tmpl":      {Data: []byte(`{{count}}{{range .Posts}}{{.Title}} {{end}}`)},
+		"pages/tag.tmpl":        {Data: []byte(`{{count}}{{.Tag}}`)},
+		"pages/tags-index.tmpl": {Data: []byte(`{{count}}tags`)},
+	}
+	renderer, err := NewTemplateRenderer(templatesFS, config.WithFuncs(template.FuncMap{
+		"count": func() string { renders.Add(1); return "" },
+	}))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+	cacheDir := t.TempDir()
+
+	generate := func() *GeneratedBlog {
+		t.Helper()
+		renders.Store(0)
+		blog, err := New(testFS, renderer, config.WithCache(cacheDir)).Generate(context.Background())
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		return blog
+	}
+
+	first := generate()
+	if n := renders.Load(); n != 5 {
+		t.Errorf("first build rendered %d pages, want 5", n)
+	}
+	if second := generate(); !reflect.DeepEqual(second, first) {
+		t.Error("cached build differs from the first build")
+	}
+	if n := renders.Load(); n != 0 {
+		t.Errorf("unchanged build rendered %d pages, want 0", n)
+	}
+
+	// Editing b.md re-renders its page and the index, which lists it
+	testFS["b.md"] = &fstest.MapFile{Data: []byte("---\ntitle: B2\ndate: 2024-01-02\ndescription: d\n---\nNew text.\n")}
+	blog := generate()
+	if n := renders.Load(); n != 2 {
+		t.Errorf("build after one edit rendered %d pages, want 2", n)
+	}
+	if !contains(string(blog.Posts["b2"]), "New text") || string(blog.Posts["a"]) != string(first.Posts["a"]) {
+		t.Errorf("unexpected posts after edit: %q", blog.Posts)
+	}
+
+	// Editing only the text of a.md re-renders every page that lists it,
+	// since templates may show the content of any post they are given
+	testFS["a.md"] = &fstest.MapFile{Data: []byte("---\ntitle: A\ndate: 2024-01-01\ndescription: d\ntags: [go]\n---\nOther text.\n")}
+	blog = generate()
+	if n := renders.Load(); n != 3 {
+		t.Errorf("build after a text edit rendered %d pages, want 3", n)
+	}
+	if !contains(string(blog.Posts["a"]), "Other text") {
+		t.Errorf("unexpected post after text edit: %q", blog.Posts["a"])
+	}
+}
+
+// TestPageCache_KeyData verifies that page cache keys hold a hash of each
+// post's content rather than the content itself.
+func TestPageCache_KeyData(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("Long body text. ", 100)
+	post := &models.Post{Title: "Post", Content: []byte(body), HTMLContent: template.HTML(body), RawContent: body}
+	store, err := cache.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("cache.Open() error = %v", err)
+	}
+	pages := newPageCache(store, models.PostList{post})
+
+	for _, data := range []any{
+		models.PostPageData{Post: post, Series: models.PostList{post}},
+		models.IndexPageData{Posts: models.PostList{post}},
+		models.TagPageData{Posts: []*models.Post{post}},
+		models.SeriesPageData{Posts: models.PostList{post}},
+	} {
+		encoded, err := json.Marshal(pages.keyData(data))
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		if strings.Contains(string(encoded), "Long body text") || !strings.Contains(string(encoded), `"Title":"Post"`) {
+			t.Errorf("expected the key of %T to hash the post content, got %s", data, encoded)
+		}
+	}
+	if string(post.Content) != body || post.RawContent != body {
+		t.Error("keyData should not change the posts it is given")
+	}
+}
+
+// TestGenerate_PostCardSummary verifies that index and tag pages preview each
+// post with its description, leaving the summary for templates that opt in.
+func TestGenerate_PostCardSummary(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: Plain description\ntags: [go]\n---\nThe *teaser* text.\n\n<!--more-->\n\nHidden body.\n")},
+	}
+
+	renderer, err := NewTemplateRenderer(os.DirFS("../templates/default"))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+
+	blog, err := New(testFS, renderer).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	for name, page := range map[string][]byte{"index": blog.Index, "tag": blog.Tags["go"]} {
+		html := string(page)
+		if !contains(html, "Plain description") {
+			t.Errorf("%s page should render the post description", name)
+		}
+		if contains(html, "<em>teaser</em>") || contains(html, "Hidden body") {
+			t.Errorf("%s page should not include the post content", name)
+		}
+	}
+}
+
+// TestGenerate_PageBundleAssets verifies that assets of published page
+// bundles are collected into GeneratedBlog.Assets, and that assets of hidden
+// posts are not.
+func TestGenerate_PageBundleAssets(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"shown/index.md":   {Data: []byte("---\ntitle: Shown\ndate: 2024-01-01\ndescription: d\n---\n![x](x.png)\n")},
+		"shown/x.png":      {Data: []byte("shown")},
+		"draft/index.md":   {Data: []byte("---\ntitle: Hidden\ndate: 2024-01-01\ndescription: d\ndraft: true\n---\n")},
+		"draft/secret.png": {Data: []byte("hidden")},
+	}
+
+	blog, err := New(testFS, nil, config.WithRawOutput(), config.WithBlogRoot("/blog/").AsGeneratorOption()).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if got := string(blog.Assets["shown/x.png"]); got != "shown" {
+		t.Errorf("expected shown/x.png asset, got %q", got)
+	}
+	if len(blog.Assets) != 1 {
+		t.Errorf("expected only the published bundle's asset, got %d assets", len(blog.Assets))
+	}
+	if !contains(string(blog.Posts["shown"]), `src="/blog/posts/shown/x.png"`) {
+		t.Errorf("expected asset link rewritten under the blog root, got %s", blog.Posts["shown"])
+	}
+}
+
+// TestGenerate_Images verifies that config.WithImages publishes bundle JPEGs
+// without their EXIF segment, alongside scaled copies that the post's image
+// lists in its srcset, and that processed images are cached between calls.
+func TestGenerate_Images(t *testing.T) {
+	t.Parallel()
+
+	var photo bytes.Buffer
+	if err := jpeg.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 300, 200)), nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x00GPS 51.5007N 0.1246W")
+	segment := append([]byte{0xFF, 0xE1, 0, byte(len(exif) + 2)}, exif...)
+	data := append(append([]byte{0xFF, 0xD8}, segment...), photo.Bytes()[2:]...)
+
+	testFS := fstest.MapFS{
+		"trip/index.md":  {Data: []byte("---\ntitle: Trip\ndate: 2024-01-01\ndescription: d\n---\n![View](view.jpg)\n")},
+		"trip/view.jpg":  {Data: data},
+		"trip/notes.txt": {Data: []byte("notes")},
+	}
+	cacheDir := t.TempDir()
+	gen := New(testFS, nil, config.WithRawOutput(), config.WithImages("", 100, 1000), config.WithCache(cacheDir))
+
+	for range 2 {
+		blog, err := gen.Generate(context.Background())
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+
+		original := blog.Assets["trip/view.jpg"]
+		if bytes.Contains(original, []byte("GPS")) || bytes.Contains(original, []byte("Exif")) {
+			t.Error("published image should not contain its EXIF segment")
+		}
+		if cfg, err := jpeg.DecodeConfig(bytes.NewReader(original)); err != nil || cfg.Width != 300 {
+			t.Errorf("published image should still be a 300px JPEG, got %+v, %v", cfg, err)
+		}
+		if cfg, err := jpeg.DecodeConfig(bytes.NewReader(blog.Assets["trip/view-100w.jpg"])); err != nil || cfg.Width != 100 {
+			t.Errorf("expected a 100px copy, got %+v, %v", cfg, err)
+		}
+		if _, ok := blog.Assets["trip/view-1000w.jpg"]; ok {
+			t.Error("no copy should be made wider than the image")
+		}
+		if string(blog.Assets["trip/notes.txt"]) != "notes" {
+			t.Error("other assets should be published unchanged")
+		}
+
+		post := string(blog.Posts["trip"])
+		for _, want := range []string{
+			`width="300" height="200" loading="lazy"`,
+			`srcset="/posts/trip/view-100w.jpg 100w, /posts/trip/view.jpg 300w"`,
+			`sizes="` + images.DefaultSizes + `"`,
+		} {
+			if !contains(post, want) {
+				t.Errorf("expected %s in post, got %s", want, post)
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(cacheDir, "images"))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("expected one cached image, got %d (%v)", len(entries), err)
+	}
+}
+
+// TestGenerate_ImageProcessingFailure verifies that a bundle image that
+// cannot be processed is published unchanged instead of failing Generate,
+// and is listed in GeneratedBlog.Skipped in lenient mode.
+func TestGenerate_ImageProcessingFailure(t *testing.T) {
+	t.Parallel()
+
+	corrupt := []byte("\xFF\xD8 not really a JPEG")
+	testFS := fstest.MapFS{
+		"trip/index.md":  {Data: []byte("---\ntitle: Trip\ndate: 2024-01-01\ndescription: d\n---\n![View](view.jpg)\n")},
+		"trip/view.jpg":  {Data: corrupt},
+		"trip/other.png": {Data: []byte("not a PNG")},
+	}
+
+	for _, lenient := range []bool{false, true} {
+		opts := []config.GeneratorOption{config.WithRawOutput(), config.WithImages("", 100)}
+		if lenient {
+			opts = append(opts, config.WithLenient())
+		}
+		blog, err := New(testFS, nil, opts...).Generate(context.Background())
+		if err != nil {
+			t.Fatalf("lenient=%t: Generate() error = %v", lenient, err)
+		}
+
+		if !bytes.Equal(blog.Assets["trip/view.jpg"], corrupt) {
+			t.Errorf("lenient=%t: expected the image to be published unchanged, got %q", lenient, blog.Assets["trip/view.jpg"])
+		}
+		if post := string(blog.Posts["trip"]); !contains(post, `<img src="/posts/trip/view.jpg" alt="View" />`) {
+			t.Errorf("lenient=%t: expected a plain image in the post, got %s", lenient, post)
+		}
+
+		var skipped []string
+		for _, fe := range blog.Skipped {
+			skipped = append(skipped, fe.Path)
+		}
+		if want := []string{"trip/other.png", "trip/view.jpg"}; lenient && !slices.Equal(skipped, want) {
+			t.Errorf("Skipped = %v, want %v", skipped, want)
+		} else if !lenient && skipped != nil {
+			t.Errorf("expected nothing skipped without lenient mode, got %v", skipped)
+		}
+	}
+}
+
+// TestGenerate_Series verifies that series parts are linked to each other and
+// that each series gets its own page.
+func TestGenerate_Series(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"one.md":   {Data: []byte("---\ntitle: Part One\ndate: 2024-01-03\ndescription: d\nseries: Go Basics\nseriesOrder: 1\n---\nOne\n")},
+		"two.md":   {Data: []byte("---\ntitle: Part Two\ndate: 2024-01-02\ndescription: d\nseries: Go Basics\nseriesOrder: 2\n---\nTwo\n")},
+		"three.md": {Data: []byte("---\ntitle: Part Three\ndate: 2024-01-01\ndescription: d\nseries: Go Basics\nseriesOrder: 3\n---\nThree\n")},
+		"solo.md":  {Data: []byte("---\ntitle: Solo\ndate: 2024-01-04\ndescription: d\n---\nSolo\n")},
+	}
+
+	renderer, err := NewTemplateRenderer(os.DirFS("../templates/default"))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error = %v", err)
+	}
+
+	blog, err := New(testFS, renderer).Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if len(blog.Series) != 1 {
+		t.Fatalf("expected 1 series page, got %d", len(blog.Series))
+	}
+	page := string(blog.Series["go-basics"])
+	if !contains(page, "Go Basics") || !contains(page, "3 parts") {
+		t.Error("series page should show the series name and part count")
+	}
+	if strings.Index(page, "Part One") > strings.Index(page, "Part Two") {
+		t.Error("series page should list parts in seriesOrder")
+	}
+
+	two := string(blog.Posts["part-two"])
+	if !contains(two, "Part 2 of 3") {
+		t.Error("middle part should show its position in the series")
+	}
+	if !contains(two, `href="/posts/part-one.html" rel="prev"`) || !contains(two, `href="/posts/part-three.html" rel="next"`) {
+		t.Error("middle part should link to its previous and next parts")
+	}
+	if contains(string(blog.Posts["part-one"]), `rel="prev"`) {
+		t.Error("first part should not have a previous link")
+	}
+	if contains(string(blog.Posts["solo"]), `aria-label="Series"`) {
+		t.Error("posts outside a series should not show series navigation")
+	}
+}
+
 // Helper function to check if a string contains a substring (case-insensitive).
 func contains(s, substr string) bool {
 	return bytes.Contains([]byte(strings.ToLower(s)), []byte(strings.ToLower(substr)))
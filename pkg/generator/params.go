@@ -0,0 +1,138 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package generator
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"time"
+)
+
+// paramFuncs are the built-in template functions that read typed values out
+// of a models.Post Params or BaseData.Custom map. Each takes the map and a
+// key, and returns the zero value of its type when the key is missing or its
+// value cannot be converted, so templates can use them without guards.
+var paramFuncs = template.FuncMap{
+	"paramString":  paramString,
+	"paramInt":     paramInt,
+	"paramFloat":   paramFloat,
+	"paramBool":    paramBool,
+	"paramTime":    paramTime,
+	"paramStrings": paramStrings,
+}
+
+// paramString returns params[key] formatted as a string.
+func paramString(params map[string]any, key string) string {
+	return formatParam(params[key])
+}
+
+// formatParam formats a front matter value as a string. Times use RFC 3339
+// and a missing value is the empty string.
+func formatParam(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// paramInt returns params[key] as an int. Whole floats and strings holding
+// an integer are converted.
+func paramInt(params map[string]any, key string) int {
+	switch v := params[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case uint64:
+		return int(v)
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v)
+		}
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// paramFloat returns params[key] as a float64. Integers and strings holding
+// a number are converted.
+func paramFloat(params map[string]any, key string) float64 {
+	switch v := params[key].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return 0
+}
+
+// paramBool returns params[key] as a bool. Strings such as "true" and "1"
+// are converted.
+func paramBool(params map[string]any, key string) bool {
+	switch v := params[key].(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return false
+}
+
+// paramTime returns params[key] as a time.Time. Strings in RFC 3339 or
+// 2006-01-02 form are parsed, so a date quoted in the front matter or written
+// in JSON still works.
+func paramTime(params map[string]any, key string) time.Time {
+	switch v := params[key].(type) {
+	case time.Time:
+		return v
+	case string:
+		for _, layout := range []string{time.RFC3339, time.DateOnly} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// paramStrings returns params[key] as a []string. Each element of a list is
+// formatted as by paramString, and a single value becomes a one-element
+// slice.
+func paramStrings(params map[string]any, key string) []string {
+	switch v := params[key].(type) {
+	case nil:
+		return nil
+	case []string:
+		return v
+	case []any:
+		out := make([]string, len(v))
+		for i, item := range v {
+			out[i] = formatParam(item)
+		}
+		return out
+	default:
+		return []string{formatParam(v)}
+	}
+}
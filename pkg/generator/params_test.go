@@ -0,0 +1,78 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package generator
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParamFuncs(t *testing.T) {
+	t.Parallel()
+
+	date := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	params := map[string]any{
+		"str":       "hello",
+		"int":       3,
+		"int64":     int64(7),
+		"whole":     2.0,
+		"float":     2.5,
+		"numstr":    "42",
+		"bool":      true,
+		"boolstr":   "true",
+		"date":      date,
+		"datestr":   "2024-01-02",
+		"list":      []any{"a", 1, true},
+		"stringish": "x",
+	}
+
+	if got := paramString(params, "str"); got != "hello" {
+		t.Errorf("paramString(str) = %q, want hello", got)
+	}
+	if got := paramString(params, "int"); got != "3" {
+		t.Errorf("paramString(int) = %q, want 3", got)
+	}
+	if got := paramString(params, "missing"); got != "" {
+		t.Errorf("paramString(missing) = %q, want empty", got)
+	}
+	if got := paramString(nil, "str"); got != "" {
+		t.Errorf("paramString(nil map) = %q, want empty", got)
+	}
+
+	for key, want := range map[string]int{"int": 3, "int64": 7, "whole": 2, "numstr": 42, "float": 0, "str": 0, "missing": 0} {
+		if got := paramInt(params, key); got != want {
+			t.Errorf("paramInt(%s) = %d, want %d", key, got, want)
+		}
+	}
+
+	for key, want := range map[string]float64{"float": 2.5, "int": 3, "numstr": 42, "str": 0} {
+		if got := paramFloat(params, key); got != want {
+			t.Errorf("paramFloat(%s) = %v, want %v", key, got, want)
+		}
+	}
+
+	for key, want := range map[string]bool{"bool": true, "boolstr": true, "str": false, "missing": false} {
+		if got := paramBool(params, key); got != want {
+			t.Errorf("paramBool(%s) = %v, want %v", key, got, want)
+		}
+	}
+
+	for key, want := range map[string]time.Time{"date": date, "datestr": date, "str": {}} {
+		if got := paramTime(params, key); !got.Equal(want) {
+			t.Errorf("paramTime(%s) = %v, want %v", key, got, want)
+		}
+	}
+
+	if got := paramStrings(params, "list"); !slices.Equal(got, []string{"a", "1", "true"}) {
+		t.Errorf("paramStrings(list) = %v, want [a 1 true]", got)
+	}
+	if got := paramStrings(params, "stringish"); !slices.Equal(got, []string{"x"}) {
+		t.Errorf("paramStrings(stringish) = %v, want [x]", got)
+	}
+	if got := paramStrings(params, "missing"); got != nil {
+		t.Errorf("paramStrings(missing) = %v, want nil", got)
+	}
+}
@@ -6,44 +6,94 @@ package generator
 
 import (
 	"bytes"
+	"fmt"
 	"html/template"
 	"io/fs"
 	"log/slog"
+	"maps"
+	"path"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/harrydayexe/GoBlog/v2/pkg/cache"
 	"github.com/harrydayexe/GoBlog/v2/pkg/config"
 	"github.com/harrydayexe/GoBlog/v2/pkg/models"
+	"github.com/harrydayexe/GoBlog/v2/pkg/parser"
 )
 
 // TemplateRenderer parses a tree of HTML templates from an fs.FS and renders
-// each page type (post, index, tag, tags-index) into HTML.
+// each page type (post, index, tag, tags-index, series) into HTML.
 //
 // Create a renderer once via [NewTemplateRenderer]; the resulting value is
 // safe for concurrent use by multiple goroutines. The internal
 // *template.Template is not exposed — callers customise rendering by
 // supplying a different fs.FS rather than mutating the renderer.
 type TemplateRenderer struct {
-	templates *template.Template
+	templates   *template.Template
+	fingerprint string // Hash of the template files and function names, for cache keys
+
+	shortcodes           map[string]parser.ShortcodeFunc // Handlers of the shortcodes/ templates, by name
+	shortcodeFingerprint string                          // Hash of the shortcodes/ templates, for cache keys
+
+	renderHooks           parser.RenderHooks // Hooks made from the render-hooks/ templates
+	renderHookFingerprint string             // Hash of the render-hooks/ templates, for cache keys
 }
 
+// shortcodePattern matches the shortcode templates under a templates fs.FS.
+const shortcodePattern = "shortcodes/*.tmpl"
+
+// renderHookPattern matches the render hook templates under a templates
+// fs.FS.
+const renderHookPattern = "render-hooks/*.tmpl"
+
 // NewTemplateRenderer parses every *.tmpl file under templatesFS and returns
 // a renderer ready to produce HTML for each page type.
 //
 // templatesFS must contain the following top-level directories:
 //
-//	pages/    required — must contain post.tmpl, index.tmpl, tag.tmpl,
-//	          and tags-index.tmpl
-//	partials/ required — each file must {{define}} one named block;
-//	          the default templates expect "head", "header", "footer",
-//	          and "post-card"
-//	layouts/  optional — loaded but not executed by any Render* method;
-//	          pages are self-contained documents that inline partials directly
+//	pages/        required — must contain post.tmpl, index.tmpl, tag.tmpl,
+//	              and tags-index.tmpl, plus series.tmpl when any post sets
+//	              series in its front matter
+//	partials/     required — each file must {{define}} one named block;
+//	              the default templates expect "head", "header", "footer",
+//	              "post-card", and "toc"
+//	layouts/      optional — loaded but not executed by any Render* method;
+//	              pages are self-contained documents that inline partials directly
+//	shortcodes/   optional — each file renders the shortcode named after it,
+//	              so shortcodes/gallery.tmpl renders {{< gallery >}}
+//	render-hooks/ optional — link.tmpl, image.tmpl, heading.tmpl and
+//	              codeblock.tmpl replace how those markdown nodes render
 //
 // Each *.tmpl file is registered under its full glob path as the template
 // name (e.g. "pages/post.tmpl", "partials/head.tmpl"). Pages reference
 // partials by their defined name ({{template "head" .}}), and Render*
 // methods invoke pages by their path.
 //
+// # Shortcodes
+//
+// A shortcode template is executed with a [parser.Shortcode] as its data and
+// has the same functions as every other template:
+//
+//	<figure class="gallery">{{range .Args}}<img src="{{$.Resolve .}}" alt="">{{end}}</figure>
+//
+// Shortcode templates are used by [Generator.Generate] when shortcodes are
+// enabled, which their presence does automatically. They replace built-in
+// shortcodes of the same name, and are replaced in turn by handlers set in
+// Generator.ParserConfig.Shortcodes.
+//
+// # Render Hooks
+//
+// A render hook template replaces the HTML written for every markdown node of
+// its kind. It is executed with a [parser.LinkData], [parser.ImageData],
+// [parser.HeadingData] or [parser.CodeBlockData], each holding the HTML the
+// node is otherwise rendered as:
+//
+//	<figure>{{.HTML}}{{with .Title}}<figcaption>{{.}}</figcaption>{{end}}</figure>
+//
+// Hooks set in Generator.ParserConfig.RenderHooks win over templates. Any
+// other file under render-hooks/ is an error.
+//
 // # Built-in helpers
 //
 // The following helpers are available in every template's FuncMap:
@@ -52,14 +102,26 @@ type TemplateRenderer struct {
 //	shortDate(t time.Time) string    formats t as "Jan 2, 2006"
 //	year() int                       returns the current calendar year
 //
+// and, to read typed values out of a post's front matter Params (or the
+// site-wide Custom data), each taking the map and a key:
+//
+//	paramString(m, key) string       the value formatted as a string
+//	paramInt(m, key) int             the value as an int, or 0
+//	paramFloat(m, key) float64       the value as a float64, or 0
+//	paramBool(m, key) bool           the value as a bool, or false
+//	paramTime(m, key) time.Time      the value as a time, or the zero time
+//	paramStrings(m, key) []string    the value as a list of strings
+//
+// For example: {{range paramStrings .Post.Params "authors"}}{{.}}{{end}}.
+//
 // # Custom functions
 //
 // Additional template functions can be registered via [config.WithFuncs].
 // User-supplied functions are merged into the FuncMap after the built-ins, so
 // registering a function whose name matches a built-in (formatDate, shortDate,
-// year) will silently replace that built-in. This enables intentional overrides
-// (e.g. a custom date format) but will also silently suppress default template
-// behaviour if done accidentally.
+// year or a param function) will silently replace that built-in. This enables
+// intentional overrides (e.g. a custom date format) but will also silently
+// suppress default template behaviour if done accidentally.
 //
 // Multiple [config.WithFuncs] calls accumulate; later registrations overwrite
 // earlier ones for the same key.
@@ -89,15 +151,12 @@ func NewTemplateRenderer(templatesFS fs.FS, opts ...config.RendererOption) (*Tem
 			return time.Now().Year()
 		},
 	}
+	maps.Copy(funcMap, paramFuncs)
 
 	// Track current built-in values so we can detect when user code replaces them.
 	// Function values are not comparable, so we temporarily remove the built-ins
 	// before each user opt and check which ones reappear afterwards.
-	builtins := map[string]any{
-		"formatDate": funcMap["formatDate"],
-		"shortDate":  funcMap["shortDate"],
-		"year":       funcMap["year"],
-	}
+	builtins := maps.Clone(map[string]any(funcMap))
 
 	// Merge user-supplied functions on top of the built-ins. A name collision
 	// with a built-in results in the user's function winning; log at Warn so
@@ -122,13 +181,22 @@ func NewTemplateRenderer(templatesFS fs.FS, opts ...config.RendererOption) (*Tem
 	// Parse all template files
 	tmpl := template.New("").Funcs(funcMap)
 
-	// Parse in order: layouts, partials, pages
+	// Parse in order: layouts, partials, pages, shortcodes, render hooks
 	patterns := []string{
 		"layouts/*.tmpl",
 		"partials/*.tmpl",
 		"pages/*.tmpl",
+		shortcodePattern,
+		renderHookPattern,
 	}
 
+	// Hash every file and function name, so cached pages are rendered again
+	// when the template set changes
+	fingerprint := [][]byte{[]byte(strings.Join(slices.Sorted(maps.Keys(funcMap)), ","))}
+	var shortcodeFiles, hookFiles []string
+	shortcodeFingerprint := [][]byte{fingerprint[0]}
+	hookFingerprint := [][]byte{fingerprint[0]}
+
 	for _, pattern := range patterns {
 		matches, err := fs.Glob(templatesFS, pattern)
 		if err != nil {
@@ -145,10 +213,46 @@ func NewTemplateRenderer(templatesFS fs.FS, opts ...config.RendererOption) (*Tem
 			if err != nil {
 				return nil, err
 			}
+			fingerprint = append(fingerprint, []byte(match), content)
+			if pattern == shortcodePattern {
+				shortcodeFiles = append(shortcodeFiles, match)
+				shortcodeFingerprint = append(shortcodeFingerprint, []byte(match), content)
+			}
+			if pattern == renderHookPattern {
+				hookFiles = append(hookFiles, match)
+				hookFingerprint = append(hookFingerprint, []byte(match), content)
+			}
 		}
 	}
 
-	return &TemplateRenderer{templates: tmpl}, nil
+	tr := &TemplateRenderer{templates: tmpl, fingerprint: cache.Key(fingerprint...)}
+	if len(shortcodeFiles) > 0 {
+		tr.shortcodes = make(map[string]parser.ShortcodeFunc, len(shortcodeFiles))
+		for _, match := range shortcodeFiles {
+			name := strings.TrimSuffix(path.Base(match), ".tmpl")
+			tr.shortcodes[name] = parser.TemplateShortcode(tmpl.Lookup(match))
+		}
+		tr.shortcodeFingerprint = cache.Key(shortcodeFingerprint...)
+	}
+	for _, match := range hookFiles {
+		t := tmpl.Lookup(match)
+		switch name := strings.TrimSuffix(path.Base(match), ".tmpl"); name {
+		case "link":
+			tr.renderHooks.Link = parser.TemplateRenderHook[parser.LinkData](t)
+		case "image":
+			tr.renderHooks.Image = parser.TemplateRenderHook[parser.ImageData](t)
+		case "heading":
+			tr.renderHooks.Heading = parser.TemplateRenderHook[parser.HeadingData](t)
+		case "codeblock":
+			tr.renderHooks.CodeBlock = parser.TemplateRenderHook[parser.CodeBlockData](t)
+		default:
+			return nil, fmt.Errorf("unknown render hook %s: want link, image, heading or codeblock", match)
+		}
+	}
+	if len(hookFiles) > 0 {
+		tr.renderHookFingerprint = cache.Key(hookFingerprint...)
+	}
+	return tr, nil
 }
 
 // RenderPost renders a single post page by executing pages/post.tmpl with
@@ -190,3 +294,13 @@ func (tr *TemplateRenderer) RenderTagsIndex(data models.TagsIndexPageData) ([]by
 	slog.Debug("Rendered tags index page", slog.Int("total tags", data.TotalTags))
 	return buf.Bytes(), err
 }
+
+// RenderSeries renders a series page by executing pages/series.tmpl with the
+// supplied [models.SeriesPageData]. Returns the rendered HTML or any error
+// from template execution.
+func (tr *TemplateRenderer) RenderSeries(data models.SeriesPageData) ([]byte, error) {
+	var buf bytes.Buffer
+	err := tr.templates.ExecuteTemplate(&buf, "pages/series.tmpl", data)
+	slog.Debug("Rendered series page " + data.Slug)
+	return buf.Bytes(), err
+}
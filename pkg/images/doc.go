@@ -0,0 +1,41 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package images prepares the raster images of page bundles for publishing.
+//
+// A Processor takes the bytes of a JPEG or PNG file and returns the image
+// with its metadata removed, its intrinsic size, and narrower copies of it at
+// each configured width. The parser uses the size and widths to give <img>
+// elements width, height, srcset, sizes and loading="lazy" attributes, and
+// the generator publishes the copies next to the original as
+// "<name>-<width>w.<ext>".
+//
+// Everything is done in pure Go: images are decoded with the standard
+// library and scaled with golang.org/x/image/draw.
+//
+// # Basic Usage
+//
+// Most callers never use a Processor directly; they enable images in the
+// generator:
+//
+//	gen := generator.New(postsFS, renderer, config.WithImages("", 480, 960))
+//
+// # Metadata
+//
+// Camera metadata can hold the place a photo was taken. JPEG files lose their
+// EXIF, XMP, IPTC and comment segments, and PNG files their text, time and
+// eXIf chunks; the image data, colour profile and everything else are copied
+// unchanged. A JPEG whose EXIF orientation says it is rotated or mirrored is
+// turned upright and encoded again instead, since browsers would otherwise
+// show it the wrong way round once the orientation is gone. The resized
+// copies never carry metadata.
+//
+// # Caching
+//
+// Processing is done once per image content and set of widths. Results are
+// kept in memory for the life of the Processor and, with WithCache, in the
+// "images" bucket of a cache.Store between builds.
+//
+// A Processor is safe for concurrent use by multiple goroutines.
+package images
@@ -0,0 +1,309 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package images
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/cache"
+	"golang.org/x/image/draw"
+)
+
+// DefaultSizes is the sizes attribute used when none is configured. It
+// matches the width of post content in the default theme.
+const DefaultSizes = "(min-width: 56rem) 52rem, 100vw"
+
+// DefaultWidths returns the widths, in pixels, of the copies made when none
+// are configured.
+func DefaultWidths() []int {
+	return []int{480, 960, 1440}
+}
+
+const (
+	// cacheBucket is the cache bucket holding processed images.
+	cacheBucket = "images"
+
+	// cacheFormat versions cached images. It must be changed whenever
+	// processing changes its output for the same input.
+	cacheFormat = "goblog-image-1"
+
+	// jpegQuality is the quality JPEG copies are encoded at.
+	jpegQuality = 85
+
+	// maxPixels bounds the size of the images decoded, so that a small file
+	// claiming huge dimensions cannot exhaust memory.
+	maxPixels = 100_000_000
+)
+
+// ErrUnsupported is returned by Process for data that is not a JPEG or PNG
+// image.
+var ErrUnsupported = errors.New("unsupported image format")
+
+// Variant is a copy of an image scaled to a narrower width.
+type Variant struct {
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Image is the result of processing an image.
+type Image struct {
+	// Width and Height are the intrinsic size of the image, the right way up.
+	Width  int
+	Height int
+
+	// Data is the image with its metadata removed, in its original format.
+	Data []byte
+
+	// Variants are the scaled copies, narrowest first. Only configured widths
+	// narrower than the image itself get one.
+	Variants []Variant
+}
+
+// Option configures a Processor.
+type Option func(*Processor)
+
+// WithWidths sets the widths, in pixels, of the scaled copies. Widths that are
+// not positive are ignored; none at all means DefaultWidths.
+func WithWidths(widths ...int) Option {
+	return func(p *Processor) {
+		p.widths = widths
+	}
+}
+
+// WithSizes sets the sizes attribute given to images, which tells browsers
+// how wide the image will be drawn so they can pick a copy before layout.
+// Empty means DefaultSizes.
+func WithSizes(sizes string) Option {
+	return func(p *Processor) {
+		p.sizes = sizes
+	}
+}
+
+// WithCache keeps processed images in store between builds.
+func WithCache(store *cache.Store) Option {
+	return func(p *Processor) {
+		p.store = store
+	}
+}
+
+// Processor strips metadata from images and makes their scaled copies.
+// Create one with New.
+type Processor struct {
+	widths []int
+	sizes  string
+	store  *cache.Store
+
+	mu   sync.Mutex
+	done map[string]*Image // Images processed by this Processor, by cache key
+}
+
+// New returns a Processor configured by opts.
+func New(opts ...Option) *Processor {
+	p := &Processor{done: make(map[string]*Image)}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	var widths []int
+	for _, w := range p.widths {
+		if w > 0 {
+			widths = append(widths, w)
+		}
+	}
+	if len(widths) == 0 {
+		widths = DefaultWidths()
+	}
+	slices.Sort(widths)
+	p.widths = slices.Compact(widths)
+
+	if p.sizes == "" {
+		p.sizes = DefaultSizes
+	}
+	return p
+}
+
+// Widths returns the widths of the scaled copies, narrowest first.
+func (p *Processor) Widths() []int {
+	return slices.Clone(p.widths)
+}
+
+// Sizes returns the sizes attribute given to images.
+func (p *Processor) Sizes() string {
+	return p.sizes
+}
+
+// Fingerprint describes everything about p that affects the HTML written for
+// an image, for use in cache keys. It is empty for a nil Processor.
+func (p *Processor) Fingerprint() string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s %v %q", cacheFormat, p.widths, p.sizes)
+}
+
+// IsRaster reports whether name has the extension of an image format Process
+// supports: .jpg, .jpeg or .png.
+func IsRaster(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jpg", ".jpeg", ".png":
+		return true
+	}
+	return false
+}
+
+// VariantName returns the name of the copy of the image at name scaled to
+// width, such as "photos/cat-480w.jpg" for "photos/cat.jpg".
+func VariantName(name string, width int) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-" + strconv.Itoa(width) + "w" + ext
+}
+
+// Process strips the metadata from the JPEG or PNG image in data and scales
+// it to each configured width narrower than itself.
+//
+// The result is shared with other callers processing the same data and must
+// not be modified. A result that cannot be written to the cache is still
+// returned; it is processed again next build.
+//
+// Returns ErrUnsupported for other formats, or an error if the image cannot
+// be decoded or encoded.
+func (p *Processor) Process(data []byte) (*Image, error) {
+	key := cache.Key([]byte(cacheFormat), []byte(fmt.Sprint(p.widths)), data)
+
+	p.mu.Lock()
+	img, ok := p.done[key]
+	p.mu.Unlock()
+	if ok {
+		return img, nil
+	}
+
+	if img, ok = p.load(key); !ok {
+		var err error
+		if img, err = p.process(data); err != nil {
+			return nil, err
+		}
+		p.save(key, img)
+	}
+
+	p.mu.Lock()
+	p.done[key] = img
+	p.mu.Unlock()
+	return img, nil
+}
+
+// load returns the image cached under key, if there is one that can be
+// decoded.
+func (p *Processor) load(key string) (*Image, bool) {
+	if p.store == nil {
+		return nil, false
+	}
+	data, ok := p.store.Get(cacheBucket, key)
+	if !ok {
+		return nil, false
+	}
+	var img Image
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&img); err != nil {
+		return nil, false
+	}
+	return &img, true
+}
+
+// save caches img under key.
+func (p *Processor) save(key string, img *Image) {
+	if p.store == nil {
+		return
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(img); err != nil {
+		return
+	}
+	_ = p.store.Put(cacheBucket, key, buf.Bytes())
+}
+
+// process does the work of Process, without the cache.
+func (p *Processor) process(data []byte) (*Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+	if format != "jpeg" && format != "png" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, format)
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("image is too large to process (%dx%d)", cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	img := &Image{}
+	var stripped bool
+	if format == "jpeg" {
+		orientation := jpegOrientation(data)
+		src = orient(src, orientation)
+		if orientation == 1 {
+			img.Data, stripped = stripJPEG(data)
+		}
+	} else {
+		img.Data, stripped = stripPNG(data)
+	}
+	if !stripped {
+		if img.Data, err = encode(src, format); err != nil {
+			return nil, err
+		}
+	}
+
+	bounds := src.Bounds()
+	img.Width, img.Height = bounds.Dx(), bounds.Dy()
+	for _, width := range p.widths {
+		if width >= img.Width {
+			break
+		}
+		height := max(1, (img.Height*width+img.Width/2)/img.Width)
+		var dst draw.Image
+		if format == "jpeg" {
+			dst = image.NewRGBA(image.Rect(0, 0, width, height))
+		} else {
+			dst = image.NewNRGBA(image.Rect(0, 0, width, height))
+		}
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+		encoded, err := encode(dst, format)
+		if err != nil {
+			return nil, err
+		}
+		img.Variants = append(img.Variants, Variant{Width: width, Height: height, Data: encoded})
+	}
+	return img, nil
+}
+
+// encode encodes m as format, "jpeg" or "png".
+func encode(m image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, m, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(&buf, m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
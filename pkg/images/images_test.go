@@ -0,0 +1,240 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/cache"
+)
+
+// secret stands in for the GPS position held in a photo's metadata.
+const secret = "GPS 51.5007N 0.1246W"
+
+// testImage returns a w by h image whose left half is red and right half is
+// blue, so that rotations can be told apart.
+func testImage(w, h int) image.Image {
+	m := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			m.Set(x, y, c)
+		}
+	}
+	return m
+}
+
+// exifJPEG returns a w by h JPEG with an EXIF segment giving orientation and
+// holding secret.
+func exifJPEG(t *testing.T, w, h, orientation int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(w, h), nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+
+	// A big-endian TIFF header and one IFD with the orientation tag
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	tiff = append(tiff, secret...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, markerAPP1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	data := buf.Bytes()
+	return append(append([]byte{0xFF, 0xD8}, segment...), data[2:]...)
+}
+
+// textPNG returns a w by h PNG with a tEXt chunk holding secret.
+func textPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(w, h)); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	data := buf.Bytes()
+
+	body := append([]byte("tEXt"), "Location\x00"+secret...)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(body)-4))
+	chunk = append(chunk, body...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(body))
+
+	// After the signature and IHDR chunk
+	at := len(pngSignature) + 25
+	return append(append(append([]byte{}, data[:at]...), chunk...), data[at:]...)
+}
+
+// TestProcess_JPEG tests that JPEG metadata is removed and copies are made at
+// each width narrower than the image.
+func TestProcess_JPEG(t *testing.T) {
+	t.Parallel()
+
+	p := New(WithWidths(100, 50, 400))
+	img, err := p.Process(exifJPEG(t, 200, 100, 1))
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if img.Width != 200 || img.Height != 100 {
+		t.Errorf("size = %dx%d, want 200x100", img.Width, img.Height)
+	}
+	if bytes.Contains(img.Data, []byte(secret)) || bytes.Contains(img.Data, []byte("Exif")) {
+		t.Error("Data should not contain the EXIF segment")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(img.Data)); err != nil {
+		t.Errorf("Data should still decode: %v", err)
+	}
+
+	if len(img.Variants) != 2 {
+		t.Fatalf("got %d variants, want 2 (400 is wider than the image)", len(img.Variants))
+	}
+	for i, want := range []int{50, 100} {
+		v := img.Variants[i]
+		if v.Width != want || v.Height != want/2 {
+			t.Errorf("variant %d is %dx%d, want %dx%d", i, v.Width, v.Height, want, want/2)
+		}
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(v.Data))
+		if err != nil {
+			t.Fatalf("variant %d does not decode: %v", i, err)
+		}
+		if cfg.Width != v.Width || cfg.Height != v.Height {
+			t.Errorf("variant %d decodes as %dx%d, want %dx%d", i, cfg.Width, cfg.Height, v.Width, v.Height)
+		}
+	}
+}
+
+// TestProcess_Orientation tests that a rotated JPEG is turned upright before
+// its orientation is removed.
+func TestProcess_Orientation(t *testing.T) {
+	t.Parallel()
+
+	img, err := New().Process(exifJPEG(t, 200, 100, 6))
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if img.Width != 100 || img.Height != 200 {
+		t.Fatalf("size = %dx%d, want 100x200", img.Width, img.Height)
+	}
+	if bytes.Contains(img.Data, []byte(secret)) {
+		t.Error("Data should not contain the EXIF segment")
+	}
+
+	// Turned clockwise, the red left half ends up on top
+	m, err := jpeg.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		t.Fatalf("Data does not decode: %v", err)
+	}
+	top, _, _, _ := m.At(50, 20).RGBA()
+	bottom, _, _, _ := m.At(50, 180).RGBA()
+	if top < 0x8000 || bottom > 0x8000 {
+		t.Errorf("red at top = %#x, bottom = %#x; want the image turned clockwise", top, bottom)
+	}
+}
+
+// TestProcess_PNG tests that PNG text chunks are removed losslessly.
+func TestProcess_PNG(t *testing.T) {
+	t.Parallel()
+
+	data := textPNG(t, 120, 60)
+	img, err := New(WithWidths(60)).Process(data)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if bytes.Contains(img.Data, []byte(secret)) {
+		t.Error("Data should not contain the tEXt chunk")
+	}
+	if len(img.Data) >= len(data) {
+		t.Errorf("Data is %d bytes, want fewer than the %d of the original", len(img.Data), len(data))
+	}
+	if _, err := png.Decode(bytes.NewReader(img.Data)); err != nil {
+		t.Errorf("Data should still decode: %v", err)
+	}
+	if len(img.Variants) != 1 || img.Variants[0].Width != 60 || img.Variants[0].Height != 30 {
+		t.Errorf("Variants = %+v, want one 60x30 copy", img.Variants)
+	}
+}
+
+// TestProcess_Unsupported tests that formats other than JPEG and PNG are
+// rejected.
+func TestProcess_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	_, err := New().Process([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>"))
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Process() error = %v, want ErrUnsupported", err)
+	}
+}
+
+// TestProcess_Cache tests that results are stored in, and read back from, a
+// cache.Store.
+func TestProcess_Cache(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "cache")
+	store, err := cache.Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data := exifJPEG(t, 200, 100, 1)
+	first, err := New(WithCache(store), WithWidths(100)).Process(data)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, cacheBucket))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("cache bucket holds %d entries (%v), want 1", len(entries), err)
+	}
+
+	second, err := New(WithCache(store), WithWidths(100)).Process(data)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if !bytes.Equal(first.Variants[0].Data, second.Variants[0].Data) {
+		t.Error("a second Processor should read the same copy back from the cache")
+	}
+
+	if _, err := New(WithCache(store), WithWidths(50)).Process(data); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, cacheBucket)); len(entries) != 2 {
+		t.Errorf("cache bucket holds %d entries, want 2 after changing the widths", len(entries))
+	}
+}
+
+// TestVariantName tests the names given to scaled copies.
+func TestVariantName(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"cat.jpg":        "cat-480w.jpg",
+		"photos/dog.PNG": "photos/dog-480w.PNG",
+		"a.b.jpeg":       "a.b-480w.jpeg",
+	}
+	for name, want := range tests {
+		if got := VariantName(name, 480); got != want {
+			t.Errorf("VariantName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// JPEG markers read by this file.
+const (
+	markerSOS   = 0xDA // Start of scan; entropy-coded data follows
+	markerAPP0  = 0xE0 // JFIF
+	markerAPP1  = 0xE1 // EXIF or XMP
+	markerAPP2  = 0xE2 // ICC profile or MPF
+	markerAPP14 = 0xEE // Adobe colour transform
+	markerAPP15 = 0xEF
+	markerCOM   = 0xFE // Comment
+)
+
+// exifOrientationTag is the EXIF tag holding the orientation of the image.
+const exifOrientationTag = 0x0112
+
+// jpegSegments calls fn with the marker and whole segment, marker included,
+// of each segment of the JPEG in data up to the start of the first scan. It
+// returns the offset of that scan, or -1 if data is not a well-formed JPEG
+// or fn returns false.
+func jpegSegments(data []byte, fn func(marker byte, segment []byte) bool) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return -1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return -1
+		}
+		marker := data[i+1]
+		if marker == 0xFF {
+			// Fill byte before a marker
+			i++
+			continue
+		}
+		if marker == markerSOS {
+			return i
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end < i+4 || end > len(data) {
+			return -1
+		}
+		if !fn(marker, data[i:end]) {
+			return -1
+		}
+		i = end
+	}
+	return -1
+}
+
+// jpegOrientation returns the EXIF orientation of the JPEG in data, from 1
+// (upright) to 8, or 1 when it has none.
+func jpegOrientation(data []byte) int {
+	orientation := 1
+	jpegSegments(data, func(marker byte, segment []byte) bool {
+		payload := segment[4:]
+		if marker != markerAPP1 || !bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+			return true
+		}
+		orientation = tiffOrientation(payload[6:])
+		return false
+	})
+	return orientation
+}
+
+// tiffOrientation returns the orientation tag of the first IFD of the TIFF
+// structure in an EXIF segment, or 1 when it has none.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := uint64(order.Uint32(tiff[4:]))
+	if ifd+2 > uint64(len(tiff)) {
+		return 1
+	}
+	entries := uint64(order.Uint16(tiff[ifd:]))
+	for e := range entries {
+		at := ifd + 2 + e*12
+		if at+12 > uint64(len(tiff)) {
+			return 1
+		}
+		if order.Uint16(tiff[at:]) == exifOrientationTag {
+			// A SHORT value sits at the start of the entry's value field
+			if v := int(order.Uint16(tiff[at+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// keepJPEGSegment reports whether a segment is needed to show the image. The
+// JFIF and Adobe segments affect how colours are decoded and an ICC profile
+// how they are shown; other application segments and comments are metadata.
+func keepJPEGSegment(marker byte, segment []byte) bool {
+	switch {
+	case marker == markerCOM:
+		return false
+	case marker == markerAPP2:
+		return bytes.HasPrefix(segment[4:], []byte("ICC_PROFILE\x00"))
+	case marker == markerAPP0 || marker == markerAPP14:
+		return true
+	case marker >= markerAPP0 && marker <= markerAPP15:
+		return false
+	}
+	return true
+}
+
+// stripJPEG returns the JPEG in data without its metadata segments and
+// without anything after its end marker, such as the extra images of a
+// multi-picture file, which carry metadata of their own. It reports false if
+// data is not a well-formed JPEG.
+func stripJPEG(data []byte) ([]byte, bool) {
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	scan := jpegSegments(data, func(marker byte, segment []byte) bool {
+		if keepJPEGSegment(marker, segment) {
+			out = append(out, segment...)
+		}
+		return true
+	})
+	if scan < 0 {
+		return nil, false
+	}
+	// 0xFF is always followed by 0x00 or a restart marker inside
+	// entropy-coded data, so the first FF D9 after the scan is the end.
+	end := bytes.Index(data[scan:], []byte{0xFF, 0xD9})
+	if end < 0 {
+		return nil, false
+	}
+	return append(out, data[scan:scan+end+2]...), true
+}
+
+// pngSignature begins every PNG file.
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// strippedPNGChunks are the ancillary PNG chunks holding metadata.
+var strippedPNGChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// stripPNG returns the PNG in data without its metadata chunks. It reports
+// false if data is not a well-formed PNG.
+func stripPNG(data []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, false
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	for i := len(pngSignature); i+12 <= len(data); {
+		// Length, type, data and CRC
+		end := uint64(i) + 12 + uint64(binary.BigEndian.Uint32(data[i:]))
+		if end > uint64(len(data)) {
+			return nil, false
+		}
+		chunk := data[i:end]
+		kind := string(chunk[4:8])
+		if !strippedPNGChunks[kind] {
+			out = append(out, chunk...)
+		}
+		if kind == "IEND" {
+			return out, true
+		}
+		i = int(end)
+	}
+	return nil, false
+}
+
+// orient returns src turned upright according to an EXIF orientation, where
+// 1 is already upright, 3, 6 and 8 are rotations and the rest are mirrored.
+func orient(src image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := range h {
+		for x := range w {
+			var dx, dy int
+			switch orientation {
+			case 2: // Mirrored horizontally
+				dx, dy = w-1-x, y
+			case 3: // Rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // Mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // Transposed
+				dx, dy = y, x
+			case 6: // Needs turning 90° clockwise
+				dx, dy = h-1-y, x
+			case 7: // Transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // Needs turning 90° anticlockwise
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -42,6 +42,12 @@ type BaseData struct {
 	//   {{if .TagsEnabled}}<a href="{{.BlogRoot}}tags">Tags</a>{{end}}
 	TagsEnabled bool
 
+	// CopyButton indicates whether highlighted code blocks carry a
+	// copy-to-clipboard button, as set by config.WithCopyButton. The default
+	// templates only include the script that makes the buttons work when it
+	// is true.
+	CopyButton bool
+
 	// Custom holds arbitrary key-value data injected by the calling application
 	// via config.WithCustomData. It is nil when no custom data was configured.
 	//
@@ -16,26 +16,65 @@ import (
 // Post represents a blog post with metadata and content
 type Post struct {
 	// Frontmatter fields
-	Title       string    `yaml:"title"`
-	Date        time.Time `yaml:"date"`
-	Description string    `yaml:"description"`
-	Tags        []string  `yaml:"tags"`
+	Title       string    `yaml:"title" toml:"title"`
+	Date        time.Time `yaml:"date" toml:"date"`
+	Description string    `yaml:"description" toml:"description"`
+	Tags        []string  `yaml:"tags" toml:"tags"`
 	// Author is the name of the post's author. It is optional; when not declared
 	// in the front matter it defaults to an empty string.
-	Author string `yaml:"author"`
+	Author string `yaml:"author" toml:"author"`
 	// LastEdited is the date the post was last edited after publication. It is
 	// optional; when not declared in the front matter it remains the zero time
 	// and the default templates omit any "edited on" line.
-	LastEdited time.Time `yaml:"lastEdited"`
+	LastEdited time.Time `yaml:"lastEdited" toml:"lastEdited"`
+	// ExpiryDate is the time after which the post is no longer published. It is
+	// optional; when not declared in the front matter it remains the zero time
+	// and the post never expires.
+	ExpiryDate time.Time `yaml:"expiryDate" toml:"expiryDate"`
+	// Draft marks the post as a work in progress. Drafts are excluded from all
+	// generated output unless the generator is configured with
+	// config.WithDrafts(), in which case templates can flag them via this field.
+	Draft bool `yaml:"draft" toml:"draft"`
+	// Slug is the URL-friendly identifier used in the post's URL. It is
+	// optional; when declared in the front matter it must already be in slug
+	// form (lowercase letters, digits and single hyphens) and keeps the URL
+	// stable across title changes. When omitted it is generated from the title
+	// or filename by GenerateSlug.
+	Slug string `yaml:"slug" toml:"slug"`
+	// ShowTOC controls whether a table of contents is extracted for the post.
+	// It is optional; when not declared in the front matter it is nil and the
+	// table of contents is built. Set toc: false to opt out.
+	ShowTOC *bool `yaml:"toc" toml:"toc"`
+	// Series is the name of the multi-part series the post belongs to. It is
+	// optional; posts sharing a series name are linked together and listed on
+	// a page of their own.
+	Series string `yaml:"series" toml:"series"`
+	// SeriesOrder is the post's position within its series, starting at 1. It
+	// is optional; parts without it follow the numbered parts in date order.
+	SeriesOrder int `yaml:"seriesOrder" toml:"seriesOrder"`
+	// Params holds every front matter key that has no field of its own, keyed
+	// as written, so that custom templates can use site-specific metadata:
+	//
+	//   {{with .Post.Params.hero}}<img src="{{.}}" alt="">{{end}}
+	//
+	// Values keep the type the front matter format gives them, with nested
+	// tables as map[string]any and arrays as []any. The paramString, paramInt,
+	// paramFloat, paramBool, paramTime and paramStrings template functions
+	// convert them. It is nil when there are no such keys. This is the
+	// per-post counterpart of BaseData.Custom.
+	Params map[string]any `yaml:"-" toml:"-"`
 
 	// Generated fields
-	Slug               string        // URL-friendly identifier
 	Content            []byte        // Rendered HTML content
 	HTMLContent        template.HTML // HTML content for templates (not escaped)
 	RawContent         string        // Original markdown content
 	SourcePath         string        // Path to source markdown file
 	BlogRoot           string        // Blog root path for URLs (e.g., "/" or "/blog/")
 	ReadingTimeMinutes int           // Estimated reading time in minutes (0 = disabled)
+	TOC                TOC           `yaml:"-" toml:"-"` // Nested table of contents (nil when toc: false)
+	Summary            template.HTML `yaml:"-" toml:"-"` // Rendered content before <!--more-->, or an automatic excerpt (the default cards show Description)
+	BundleDir          string        `yaml:"-" toml:"-"` // Source directory of a page bundle (empty for single-file posts)
+	Assets             []string      `yaml:"-" toml:"-"` // Bundle files published alongside the post, relative to BundleDir
 }
 
 // Validate checks if the post has all required fields.
@@ -43,6 +82,11 @@ type Post struct {
 //   - Title: must be non-empty
 //   - Date: must be non-zero
 //   - Description: must be non-empty
+//   - LastEdited: when set, must not be before Date
+//   - ExpiryDate: when set, must be after Date
+//   - Slug: when set, must already be in slug form (see GenerateSlug)
+//   - Series: when set, must contain at least one letter or digit
+//   - SeriesOrder: must not be negative, and requires Series
 //
 // The returned error includes the source file path for debugging purposes.
 func (p *Post) Validate() error {
@@ -63,15 +107,41 @@ func (p *Post) Validate() error {
 			p.LastEdited.Format("2006-01-02"), p.Date.Format("2006-01-02"), p.SourcePath)
 	}
 
+	if p.Slug != "" {
+		if want := slugify(p.Slug); want != p.Slug {
+			return fmt.Errorf("post has invalid slug %q (did you mean %q?) (source: %s)",
+				p.Slug, want, p.SourcePath)
+		}
+	}
+
+	if p.Series != "" && p.SeriesSlug() == "" {
+		return fmt.Errorf("post has series %q with no letters or digits (source: %s)", p.Series, p.SourcePath)
+	}
+
+	if p.SeriesOrder < 0 {
+		return fmt.Errorf("post has negative seriesOrder %d (source: %s)", p.SeriesOrder, p.SourcePath)
+	}
+
+	if p.SeriesOrder != 0 && p.Series == "" {
+		return fmt.Errorf("post has seriesOrder but no series (source: %s)", p.SourcePath)
+	}
+
+	if !p.ExpiryDate.IsZero() && !p.ExpiryDate.After(p.Date) {
+		return fmt.Errorf("post has expiryDate (%s) not after date (%s) (source: %s)",
+			p.ExpiryDate.Format("2006-01-02"), p.Date.Format("2006-01-02"), p.SourcePath)
+	}
+
 	return nil
 }
 
 // GenerateSlug creates a URL-friendly slug from the title or filename.
-// If the Slug field is already set, this method does nothing.
+// If the Slug field is already set (for example from a slug: key in the front
+// matter), this method does nothing.
 //
 // The slug generation process:
 //  1. Attempts to use the post's Title if available
-//  2. Falls back to the filename (without extension) if no Title exists
+//  2. Falls back to the bundle directory name for page bundles, or the
+//     filename (without extension) otherwise, if no Title exists
 //  3. Converts to lowercase
 //  4. Replaces spaces and underscores with hyphens
 //  5. Removes all non-alphanumeric characters except hyphens
@@ -89,6 +159,12 @@ func (p *Post) GenerateSlug() {
 		return
 	}
 
+	// Fall back to the directory name for page bundles
+	if p.BundleDir != "" {
+		p.Slug = slugify(filepath.Base(p.BundleDir))
+		return
+	}
+
 	// Fall back to filename without extension
 	if p.SourcePath != "" {
 		filename := filepath.Base(p.SourcePath)
@@ -172,6 +248,45 @@ func (p *Post) ShortLastEdited() string {
 	return p.LastEdited.Format("2006-01-02")
 }
 
+// TOCEnabled reports whether a table of contents should be built for the post.
+// It returns true unless the front matter sets toc: false.
+func (p *Post) TOCEnabled() bool {
+	return p.ShowTOC == nil || *p.ShowTOC
+}
+
+// SeriesSlug returns the URL-friendly form of the post's series name, used
+// for the series page at series/<slug>. It returns the empty string when the
+// post is not part of a series.
+func (p *Post) SeriesSlug() string {
+	if p.Series == "" {
+		return ""
+	}
+	return slugify(p.Series)
+}
+
+// IsBundle reports whether the post is the index.md of a page bundle, whose
+// sibling files are published under posts/<slug>/.
+func (p *Post) IsBundle() bool {
+	return p.BundleDir != ""
+}
+
+// HasExpiryDate reports whether the post has an expiry date set.
+// Returns false when ExpiryDate is the zero time (i.e. the front matter did
+// not include an expiryDate key).
+func (p *Post) HasExpiryDate() bool {
+	return !p.ExpiryDate.IsZero()
+}
+
+// IsLive reports whether the post should be published at the given time.
+// A post is live once now has reached its Date and, when an ExpiryDate is
+// set, until now reaches the ExpiryDate.
+func (p *Post) IsLive(now time.Time) bool {
+	if p.Date.After(now) {
+		return false
+	}
+	return p.ExpiryDate.IsZero() || now.Before(p.ExpiryDate)
+}
+
 // PostList is a collection of posts with helper methods
 type PostList []*Post
 
@@ -188,6 +303,91 @@ func (pl PostList) FilterByTag(tag string) PostList {
 	return filtered
 }
 
+// FilterBySeries returns a new PostList containing the posts whose
+// SeriesSlug matches slug, in reading order: numbered parts by SeriesOrder,
+// then unnumbered parts, with ties broken by date (oldest first). The original
+// PostList is not modified.
+func (pl PostList) FilterBySeries(slug string) PostList {
+	var parts PostList
+	for _, post := range pl {
+		if post.Series != "" && post.SeriesSlug() == slug {
+			parts = append(parts, post)
+		}
+	}
+	sort.SliceStable(parts, func(i, j int) bool {
+		a, b := parts[i], parts[j]
+		if a.SeriesOrder != b.SeriesOrder {
+			if a.SeriesOrder == 0 || b.SeriesOrder == 0 {
+				return b.SeriesOrder == 0
+			}
+			return a.SeriesOrder < b.SeriesOrder
+		}
+		return a.Date.Before(b.Date)
+	})
+	return parts
+}
+
+// GetAllSeries returns the unique series slugs used across all posts in the
+// collection, sorted alphabetically. If no post belongs to a series, an empty
+// slice is returned.
+func (pl PostList) GetAllSeries() []string {
+	seen := make(map[string]bool)
+	series := []string{}
+	for _, post := range pl {
+		if slug := post.SeriesSlug(); slug != "" && !seen[slug] {
+			seen[slug] = true
+			series = append(series, slug)
+		}
+	}
+	sort.Strings(series)
+	return series
+}
+
+// ExcludeDrafts returns a new PostList containing only posts whose Draft field
+// is false. The original PostList is not modified. If every post is a draft,
+// an empty PostList is returned.
+func (pl PostList) ExcludeDrafts() PostList {
+	var published PostList
+	for _, post := range pl {
+		if !post.Draft {
+			published = append(published, post)
+		}
+	}
+	return published
+}
+
+// FilterLive returns a new PostList containing only posts that are live at
+// the given time (see Post.IsLive). Future-dated and expired posts are left
+// out. The original PostList is not modified.
+func (pl PostList) FilterLive(now time.Time) PostList {
+	var live PostList
+	for _, post := range pl {
+		if post.IsLive(now) {
+			live = append(live, post)
+		}
+	}
+	return live
+}
+
+// NextChange returns the earliest time strictly after now at which a post in
+// the collection goes live or expires. It returns the zero time when no post
+// is scheduled to change after now.
+func (pl PostList) NextChange(now time.Time) time.Time {
+	var next time.Time
+	consider := func(t time.Time) {
+		if t.After(now) && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	for _, post := range pl {
+		consider(post.Date)
+		if !post.ExpiryDate.IsZero() {
+			consider(post.ExpiryDate)
+		}
+	}
+	return next
+}
+
 // SortByDate sorts the posts in-place by date in descending order (newest first).
 // This method modifies the PostList directly rather than returning a new one.
 // Posts with equal dates maintain their relative order (stable sort).
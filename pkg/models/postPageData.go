@@ -13,4 +13,18 @@ type PostPageData struct {
 	// Post is the blog post to display.
 	// See models.Post for available fields.
 	Post *Post
+
+	// Series lists every part of the post's series in reading order, or is
+	// nil when the post is not part of a series. Link to the series page with
+	// {{.BlogRoot}}series/{{.Post.SeriesSlug}}.
+	Series PostList
+
+	// SeriesPart is the 1-based position of the post within Series, or 0 when
+	// the post is not part of a series.
+	SeriesPart int
+
+	// PrevInSeries and NextInSeries are the neighbouring parts of the series,
+	// or nil at either end and for posts outside a series.
+	PrevInSeries *Post
+	NextInSeries *Post
 }
@@ -6,6 +6,7 @@ package models
 
 import (
 	"html/template"
+	"strings"
 	"testing"
 	"time"
 )
@@ -63,6 +64,43 @@ func TestPost_Validate(t *testing.T) {
 			expectErr: true,
 			errText:   "description",
 		},
+		{
+			name: "seriesOrder without series",
+			post: Post{
+				Title:       "Test Post",
+				Date:        now,
+				Description: "A test post",
+				SeriesOrder: 2,
+				SourcePath:  "test.md",
+			},
+			expectErr: true,
+			errText:   "seriesOrder",
+		},
+		{
+			name: "negative seriesOrder",
+			post: Post{
+				Title:       "Test Post",
+				Date:        now,
+				Description: "A test post",
+				Series:      "Go Basics",
+				SeriesOrder: -1,
+				SourcePath:  "test.md",
+			},
+			expectErr: true,
+			errText:   "seriesOrder",
+		},
+		{
+			name: "series without letters or digits",
+			post: Post{
+				Title:       "Test Post",
+				Date:        now,
+				Description: "A test post",
+				Series:      "☕",
+				SourcePath:  "test.md",
+			},
+			expectErr: true,
+			errText:   "series",
+		},
 		{
 			name: "empty author is valid",
 			post: Post{
@@ -104,6 +142,50 @@ func TestPost_Validate(t *testing.T) {
 			expectErr: true,
 			errText:   "lastEdited",
 		},
+		{
+			name: "expiryDate after date is valid",
+			post: Post{
+				Title:       "Test Post",
+				Date:        now,
+				Description: "A test post",
+				ExpiryDate:  now.Add(24 * time.Hour),
+			},
+			expectErr: false,
+		},
+		{
+			name: "expiryDate before date is invalid",
+			post: Post{
+				Title:       "Test Post",
+				Date:        now,
+				Description: "A test post",
+				ExpiryDate:  now.Add(-24 * time.Hour),
+				SourcePath:  "test.md",
+			},
+			expectErr: true,
+			errText:   "expiryDate",
+		},
+		{
+			name: "explicit slug in slug form is valid",
+			post: Post{
+				Title:       "Test Post",
+				Date:        now,
+				Description: "A test post",
+				Slug:        "stable-url-2024",
+			},
+			expectErr: false,
+		},
+		{
+			name: "explicit slug not in slug form is invalid",
+			post: Post{
+				Title:       "Test Post",
+				Date:        now,
+				Description: "A test post",
+				Slug:        "Not A Slug!",
+				SourcePath:  "test.md",
+			},
+			expectErr: true,
+			errText:   "not-a-slug",
+		},
 	}
 
 	for _, tt := range tests {
@@ -184,6 +266,15 @@ func TestPost_GenerateSlug(t *testing.T) {
 			},
 			expectedSlug: "my-post",
 		},
+		{
+			name: "fallback to bundle directory",
+			post: Post{
+				Title:      "",
+				SourcePath: "posts/My Bundle/index.md",
+				BundleDir:  "posts/My Bundle",
+			},
+			expectedSlug: "my-bundle",
+		},
 		{
 			name: "unicode characters removed",
 			post: Post{
@@ -358,6 +449,33 @@ func TestPost_ShortLastEdited(t *testing.T) {
 }
 
 // TestPostList_FilterByTag tests filtering posts by tag
+// TestPostList_FilterBySeries tests grouping and ordering series parts
+func TestPostList_FilterBySeries(t *testing.T) {
+	t.Parallel()
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	posts := PostList{
+		{Title: "Unnumbered", Series: "Go Basics", Date: day(1)},
+		{Title: "Part 2", Series: "Go Basics", SeriesOrder: 2, Date: day(2)},
+		{Title: "Other", Series: "Other Series", SeriesOrder: 1, Date: day(3)},
+		{Title: "Part 1", Series: "go basics", SeriesOrder: 1, Date: day(4)},
+		{Title: "Standalone", Date: day(5)},
+	}
+
+	got := posts.FilterBySeries("go-basics")
+	var titles []string
+	for _, p := range got {
+		titles = append(titles, p.Title)
+	}
+	want := []string{"Part 1", "Part 2", "Unnumbered"}
+	if strings.Join(titles, ",") != strings.Join(want, ",") {
+		t.Errorf("FilterBySeries() = %v, want %v", titles, want)
+	}
+
+	if all := posts.GetAllSeries(); strings.Join(all, ",") != "go-basics,other-series" {
+		t.Errorf("GetAllSeries() = %v, want [go-basics other-series]", all)
+	}
+}
+
 func TestPostList_FilterByTag(t *testing.T) {
 	t.Parallel()
 	now := time.Now()
@@ -467,6 +585,104 @@ func TestPostList_GetAllTags_Empty(t *testing.T) {
 	}
 }
 
+// TestPostList_ExcludeDrafts tests removing draft posts from a list
+func TestPostList_ExcludeDrafts(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	posts := PostList{
+		{Title: "Published", Date: now, Description: "desc1"},
+		{Title: "Draft", Date: now, Description: "desc2", Draft: true},
+		{Title: "Also Published", Date: now, Description: "desc3"},
+	}
+
+	published := posts.ExcludeDrafts()
+
+	if len(published) != 2 {
+		t.Fatalf("expected 2 published posts, got %d", len(published))
+	}
+	for _, post := range published {
+		if post.Draft {
+			t.Errorf("post %q is a draft and should have been excluded", post.Title)
+		}
+	}
+	if len(posts) != 3 {
+		t.Errorf("original list should be unchanged, got %d posts", len(posts))
+	}
+}
+
+// TestPost_IsLive tests scheduled publishing and expiry
+func TestPost_IsLive(t *testing.T) {
+	t.Parallel()
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	expiry := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		post Post
+		now  time.Time
+		want bool
+	}{
+		{"before date", Post{Date: date}, date.Add(-time.Second), false},
+		{"at date", Post{Date: date}, date, true},
+		{"after date without expiry", Post{Date: date}, date.AddDate(10, 0, 0), true},
+		{"before expiry", Post{Date: date, ExpiryDate: expiry}, expiry.Add(-time.Second), true},
+		{"at expiry", Post{Date: date, ExpiryDate: expiry}, expiry, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.post.IsLive(tt.now); got != tt.want {
+				t.Errorf("IsLive(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPostList_NextChange tests finding the next scheduled change
+func TestPostList_NextChange(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	posts := PostList{
+		{Title: "Past", Date: now.AddDate(0, -1, 0)},
+		{Title: "Future", Date: now.AddDate(0, 0, 10)},
+		{Title: "Expiring", Date: now.AddDate(0, -1, 0), ExpiryDate: now.AddDate(0, 0, 5)},
+	}
+
+	if got, want := posts.NextChange(now), now.AddDate(0, 0, 5); !got.Equal(want) {
+		t.Errorf("NextChange() = %v, want %v", got, want)
+	}
+
+	if live := posts.FilterLive(now); len(live) != 2 {
+		t.Errorf("FilterLive() returned %d posts, want 2", len(live))
+	}
+
+	if got := posts.NextChange(now.AddDate(1, 0, 0)); !got.IsZero() {
+		t.Errorf("NextChange() after all changes = %v, want zero", got)
+	}
+}
+
+// TestTOC_Len tests counting nested table of contents entries
+func TestTOC_Len(t *testing.T) {
+	t.Parallel()
+	toc := TOC{
+		{Level: 2, Text: "A", Children: TOC{
+			{Level: 3, Text: "A.1"},
+			{Level: 3, Text: "A.2"},
+		}},
+		{Level: 2, Text: "B"},
+	}
+
+	if got := toc.Len(); got != 4 {
+		t.Errorf("Len() = %d, want 4", got)
+	}
+
+	var empty TOC
+	if got := empty.Len(); got != 0 {
+		t.Errorf("Len() of nil TOC = %d, want 0", got)
+	}
+}
+
 // TestPost_HTMLContent tests HTML content handling
 func TestPost_HTMLContent(t *testing.T) {
 	t.Parallel()
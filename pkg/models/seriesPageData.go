@@ -0,0 +1,27 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package models
+
+// SeriesPageData is the data passed to pages/series.tmpl by
+// generator.TemplateRenderer.RenderSeries. It lists every part of a
+// multi-part series in reading order.
+type SeriesPageData struct {
+	BaseData
+
+	// Series is the display name of the series, taken from the series front
+	// matter of its first part.
+	// Example: "Building a Blog in Go"
+	Series string
+
+	// Slug is the URL-friendly form of the series name.
+	// Example: "building-a-blog-in-go"
+	Slug string
+
+	// Posts is the list of parts, ordered by seriesOrder.
+	Posts PostList
+
+	// PostCount is the number of parts in the series.
+	PostCount int
+}
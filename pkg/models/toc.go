@@ -0,0 +1,39 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package models
+
+// TOCEntry is a single heading in a post's table of contents.
+type TOCEntry struct {
+	// Level is the heading level, from 1 (<h1>) to 6 (<h6>).
+	Level int
+	// Text is the plain text of the heading with inline markup removed.
+	Text string
+	// ID is the heading's anchor ID, suitable for href="#{{.ID}}".
+	ID string
+	// Children holds the headings nested beneath this one.
+	Children TOC
+}
+
+// TOC is a nested table of contents built from a post's headings. Top-level
+// entries are the shallowest headings within the configured level range; deeper
+// headings are nested under the closest preceding shallower heading.
+//
+// Render it recursively in templates:
+//
+//	{{define "toc-entries"}}<ul>{{range .}}
+//	  <li><a href="#{{.ID}}">{{.Text}}</a>{{with .Children}}{{template "toc-entries" .}}{{end}}</li>
+//	{{end}}</ul>{{end}}
+type TOC []*TOCEntry
+
+// Len returns the total number of entries in the table of contents, including
+// nested children. Templates can use it to show a table of contents only for
+// longer posts: {{if ge .Post.TOC.Len 3}}...{{end}}.
+func (t TOC) Len() int {
+	n := 0
+	for _, entry := range t {
+		n += 1 + entry.Children.Len()
+	}
+	return n
+}
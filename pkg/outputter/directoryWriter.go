@@ -76,7 +76,11 @@ func NewDirectoryWriter(outputDir string, opts ...config.GeneratorOption) Direct
 //
 // The method creates the following structure in the output directory:
 //   - index.html: the main blog index page
+//   - chroma.css: the stylesheet for highlighted code (only if blog.ChromaCSS is non-empty)
 //   - posts/{slug}.html: individual post files, one per post
+//   - posts/{slug}/{file}: assets from page bundles, written as-is, including
+//     the resized image copies made with config.WithImages
+//   - series/{series}.html: series pages (only if any post belongs to a series)
 //   - tags/{tag}.html: tag pages (only if RawOutput and DisableTags are false)
 //   - tags/index.html: tags index page (only if RawOutput and DisableTags are false)
 //
@@ -90,6 +94,9 @@ func NewDirectoryWriter(outputDir string, opts ...config.GeneratorOption) Direct
 // directory is not created. Posts and the index page are still written with
 // full templates.
 //
+// Page bundle assets are written in every mode, since rewritten links in the
+// post content point at them.
+//
 // All necessary directories are created automatically with permissions 0755.
 // Files are written with permissions 0644.
 //
@@ -111,11 +118,29 @@ func (dw DirectoryWriter) HandleGeneratedBlog(ctx context.Context, blog *generat
 		return err
 	}
 
+	if err := writeAssets(blog.Assets, filepath.Join(dw.outputDir, "posts")); err != nil {
+		return err
+	}
+
 	// Always write index.html
 	if err := os.WriteFile(filepath.Join(dw.outputDir, "index.html"), blog.Index, 0644); err != nil {
 		return err
 	}
 
+	// Write the code highlighting stylesheet linked by the templates
+	if len(blog.ChromaCSS) > 0 {
+		if err := os.WriteFile(filepath.Join(dw.outputDir, "chroma.css"), blog.ChromaCSS, 0644); err != nil {
+			return err
+		}
+	}
+
+	// Write series pages when any post belongs to a series
+	if len(blog.Series) > 0 {
+		if err := writeMapToFiles(blog.Series, filepath.Join(dw.outputDir, "series")); err != nil {
+			return err
+		}
+	}
+
 	// Only write tags and tags index if NOT in RawOutput or DisableTags mode
 	if !dw.RawOutput.RawOutput && !dw.DisableTags.Disable {
 		if err := writeMapToFiles(blog.Tags, filepath.Join(dw.outputDir, "tags")); err != nil {
@@ -156,3 +181,23 @@ func writeMapToFiles(data map[string][]byte, outputDir string) error {
 	}
 	return nil
 }
+
+// writeAssets writes page bundle assets beneath outputDir, keeping the
+// "<slug>/<file>" structure of their keys.
+//
+// Parent directories are created as needed with permissions 0755. Files are
+// written with permissions 0644.
+//
+// Returns an error if directory creation or any file write fails.
+func writeAssets(assets map[string][]byte, outputDir string) error {
+	for name, content := range assets {
+		path := filepath.Join(outputDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
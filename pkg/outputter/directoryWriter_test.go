@@ -315,6 +315,100 @@ func TestDirectoryWriter_WritesPostFiles(t *testing.T) {
 	}
 }
 
+// TestDirectoryWriter_WritesBundleAssets verifies page bundle assets are
+// written under posts/<slug>/, including nested directories.
+func TestDirectoryWriter_WritesBundleAssets(t *testing.T) {
+	t.Parallel()
+
+	outputDir := t.TempDir()
+	writer := NewDirectoryWriter(outputDir, config.WithRawOutput())
+
+	blog := &generator.GeneratedBlog{
+		Posts: map[string][]byte{"my-post": []byte("<h1>Post</h1>")},
+		Index: []byte("<h1>Index</h1>"),
+		Tags:  make(map[string][]byte),
+		Assets: map[string][]byte{
+			"my-post/diagram.png":    []byte("png"),
+			"my-post/files/data.csv": []byte("a,b"),
+		},
+	}
+
+	if err := writer.HandleGeneratedBlog(context.Background(), blog); err != nil {
+		t.Fatalf("HandleGeneratedBlog failed: %v", err)
+	}
+
+	for name, want := range blog.Assets {
+		got, err := os.ReadFile(filepath.Join(outputDir, "posts", filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("asset %s should exist: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("asset %s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+// TestDirectoryWriter_WritesChromaCSS verifies that the highlighting
+// stylesheet is written to chroma.css at the root of the output directory,
+// and that no file is written when the blog has none.
+func TestDirectoryWriter_WritesChromaCSS(t *testing.T) {
+	t.Parallel()
+
+	outputDir := t.TempDir()
+	writer := NewDirectoryWriter(outputDir)
+	blog := &generator.GeneratedBlog{
+		Index:     []byte("<h1>Index</h1>"),
+		ChromaCSS: []byte(".chroma { color: #000 }"),
+	}
+	if err := writer.HandleGeneratedBlog(context.Background(), blog); err != nil {
+		t.Fatalf("HandleGeneratedBlog failed: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(outputDir, "chroma.css"))
+	if err != nil {
+		t.Fatalf("chroma.css should exist: %v", err)
+	}
+	if !bytes.Equal(got, blog.ChromaCSS) {
+		t.Errorf("chroma.css: got %q, want %q", got, blog.ChromaCSS)
+	}
+
+	emptyDir := t.TempDir()
+	if err := NewDirectoryWriter(emptyDir).HandleGeneratedBlog(context.Background(), &generator.GeneratedBlog{Index: []byte("i")}); err != nil {
+		t.Fatalf("HandleGeneratedBlog failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(emptyDir, "chroma.css")); !os.IsNotExist(err) {
+		t.Errorf("expected no chroma.css without a stylesheet, got err = %v", err)
+	}
+}
+
+// TestDirectoryWriter_WritesSeriesFiles verifies series pages are written to
+// the series subdirectory.
+func TestDirectoryWriter_WritesSeriesFiles(t *testing.T) {
+	t.Parallel()
+
+	outputDir := t.TempDir()
+	writer := NewDirectoryWriter(outputDir)
+
+	blog := &generator.GeneratedBlog{
+		Posts:  map[string][]byte{"part-one": []byte("<h1>One</h1>")},
+		Index:  []byte("<h1>Index</h1>"),
+		Tags:   make(map[string][]byte),
+		Series: map[string][]byte{"go-basics": []byte("<h1>Go Basics</h1>")},
+	}
+
+	if err := writer.HandleGeneratedBlog(context.Background(), blog); err != nil {
+		t.Fatalf("HandleGeneratedBlog failed: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(outputDir, "series", "go-basics.html"))
+	if err != nil {
+		t.Fatalf("series file should exist: %v", err)
+	}
+	if string(got) != "<h1>Go Basics</h1>" {
+		t.Errorf("series file content = %q", got)
+	}
+}
+
 // TestDirectoryWriter_WritesTagFiles verifies tags subdirectory and files
 // are created correctly.
 func TestDirectoryWriter_WritesTagFiles(t *testing.T) {
@@ -40,9 +40,15 @@
 //
 //	output/
 //	├── index.html           # Blog index page
+//	├── chroma.css           # Stylesheet for highlighted code
 //	├── posts/               # Individual post pages
 //	│   ├── slug-1.html
-//	│   └── slug-2.html
+//	│   ├── slug-2.html
+//	│   └── slug-2/          # Page bundle assets, if slug-2 is a bundle
+//	│       ├── diagram.png
+//	│       └── diagram-480w.png # Resized copies, with config.WithImages
+//	├── series/              # Series pages (when posts set series)
+//	│   └── series-1.html
 //	└── tags/                # Tag pages (unless RawOutput is enabled)
 //	    ├── tag-1.html
 //	    ├── tag-2.html
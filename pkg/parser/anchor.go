@@ -0,0 +1,154 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	defaultAnchorSymbol   = "#"
+	defaultAnchorMinLevel = 2
+	defaultAnchorLabel    = "Permalink to %s"
+)
+
+// AnchorPosition is where a heading's permalink anchor is placed relative to
+// the heading text.
+type AnchorPosition string
+
+const (
+	// AnchorAfter places the anchor after the heading text. It is the
+	// default.
+	AnchorAfter AnchorPosition = "after"
+
+	// AnchorBefore places the anchor before the heading text.
+	AnchorBefore AnchorPosition = "before"
+)
+
+// ParseAnchorPosition returns the AnchorPosition named s, or an error listing
+// the valid names.
+func ParseAnchorPosition(s string) (AnchorPosition, error) {
+	switch pos := AnchorPosition(s); pos {
+	case AnchorAfter, AnchorBefore:
+		return pos, nil
+	}
+	return "", fmt.Errorf("unknown heading anchor position %q (want %s or %s)", s, AnchorAfter, AnchorBefore)
+}
+
+// HeadingAnchor describes the permalink anchors added to headings by
+// WithHeadingAnchors. Zero fields take their defaults.
+type HeadingAnchor struct {
+	// Symbol is the HTML shown as the link, such as "¶" or an inline <svg>
+	// icon. It is written as given. The default is "#".
+	Symbol string
+
+	// Position places the anchor before or after the heading text. The
+	// default is AnchorAfter.
+	Position AnchorPosition
+
+	// MinLevel is the shallowest heading level given an anchor, counted after
+	// any heading shift. The default is 2, since level 1 is usually the post
+	// title.
+	MinLevel int
+
+	// Label is the anchor's aria-label. A %s in it is replaced by the heading
+	// text. The default is "Permalink to %s".
+	Label string
+}
+
+// withDefaults returns a with its zero fields set to their defaults.
+func (a HeadingAnchor) withDefaults() HeadingAnchor {
+	if a.Symbol == "" {
+		a.Symbol = defaultAnchorSymbol
+	}
+	if a.Position == "" {
+		a.Position = AnchorAfter
+	}
+	if a.MinLevel == 0 {
+		a.MinLevel = defaultAnchorMinLevel
+	}
+	a.MinLevel = clampHeadingLevel(a.MinLevel)
+	if a.Label == "" {
+		a.Label = defaultAnchorLabel
+	}
+	return a
+}
+
+// headingAnchorRenderer renders headings as the goldmark HTML renderer does,
+// with a link to the heading's own id inside them.
+//
+// The anchor is added while rendering rather than to the AST, so heading IDs,
+// tables of contents and summary word counts never see its text.
+type headingAnchorRenderer struct {
+	anchor HeadingAnchor
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r headingAnchorRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHeading, r.renderHeading)
+}
+
+// renderHeading writes a heading, with its anchor if it has an id and its
+// level is at least the anchor's MinLevel.
+func (r headingAnchorRenderer) renderHeading(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.Heading)
+	id, hasID := n.AttributeString("id")
+	idBytes, _ := id.([]byte)
+	anchored := hasID && len(idBytes) > 0 && n.Level >= r.anchor.MinLevel
+
+	if entering {
+		_, _ = w.WriteString("<h")
+		_ = w.WriteByte("0123456"[n.Level])
+		if n.Attributes() != nil {
+			goldmarkhtml.RenderAttributes(w, node, goldmarkhtml.HeadingAttributeFilter)
+		}
+		_ = w.WriteByte('>')
+		if anchored && r.anchor.Position == AnchorBefore {
+			r.writeAnchor(w, n, source, idBytes)
+			_ = w.WriteByte(' ')
+		}
+	} else {
+		if anchored && r.anchor.Position != AnchorBefore {
+			_ = w.WriteByte(' ')
+			r.writeAnchor(w, n, source, idBytes)
+		}
+		_, _ = w.WriteString("</h")
+		_ = w.WriteByte("0123456"[n.Level])
+		_, _ = w.WriteString(">\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+// writeAnchor writes the permalink to the heading n with the given id.
+func (r headingAnchorRenderer) writeAnchor(w util.BufWriter, n *ast.Heading, source []byte, id []byte) {
+	label := strings.ReplaceAll(r.anchor.Label, "%s", nodeText(n, source))
+	_, _ = w.WriteString(`<a class="heading-anchor" href="#`)
+	_, _ = w.Write(util.EscapeHTML(util.URLEscape(id, false)))
+	_, _ = w.WriteString(`" aria-label="`)
+	_, _ = w.Write(util.EscapeHTML([]byte(label)))
+	_, _ = w.WriteString(`">`)
+	_, _ = w.WriteString(r.anchor.Symbol)
+	_, _ = w.WriteString(`</a>`)
+}
+
+// headingAnchors is the goldmark extension enabled by
+// Config.EnableHeadingAnchors.
+type headingAnchors struct {
+	anchor HeadingAnchor
+}
+
+// Extend implements goldmark.Extender.
+func (e headingAnchors) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(headingAnchorRenderer{anchor: e.anchor}, 500),
+	))
+}
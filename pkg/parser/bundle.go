@@ -0,0 +1,112 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// bundleIndex is the name of the markdown file that turns a directory into a
+// page bundle.
+const bundleIndex = "index.md"
+
+// isBundleDir reports whether dir, a directory below the root of fsys,
+// contains an index.md and is therefore a page bundle.
+func isBundleDir(fsys fs.FS, dir string) bool {
+	if dir == "." {
+		return false
+	}
+	info, err := fs.Stat(fsys, bundleIndexPath(dir))
+	return err == nil && !info.IsDir()
+}
+
+// bundleIndexPath returns the path of the index.md inside bundle directory dir.
+func bundleIndexPath(dir string) string {
+	return path.Join(dir, bundleIndex)
+}
+
+// bundleDir returns the bundle directory for the markdown file at p, or the
+// empty string when p is not the index.md of a page bundle.
+func bundleDir(p string) string {
+	dir := path.Dir(p)
+	if path.Base(p) != bundleIndex || dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// bundleAssets lists the files in a bundle directory other than markdown
+// files and hidden files, as slash-separated paths relative to dir, in
+// lexical order.
+func bundleAssets(fsys fs.FS, dir string) ([]string, error) {
+	var assets []string
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && p != dir {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || isMarkdown(p) {
+			return nil
+		}
+		assets = append(assets, strings.TrimPrefix(p, dir+"/"))
+		return nil
+	})
+	return assets, err
+}
+
+// isMarkdown reports whether p has a markdown file extension.
+func isMarkdown(p string) bool {
+	ext := strings.ToLower(path.Ext(p))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// rewriteBundleLinks points link and image destinations that name one of the
+// bundle's assets at the asset's published location, base + asset. Other
+// destinations, including absolute URLs and fragments, are left untouched.
+func rewriteBundleLinks(doc ast.Node, assets []string, base string) {
+	if len(assets) == 0 {
+		return
+	}
+	known := make(map[string]bool, len(assets))
+	for _, a := range assets {
+		known[a] = true
+	}
+
+	rewrite := func(dest []byte) []byte {
+		u, err := url.Parse(string(dest))
+		if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+			return dest
+		}
+		asset := path.Clean(u.Path)
+		if !known[asset] {
+			return dest
+		}
+		out := &url.URL{Path: base + asset, RawQuery: u.RawQuery, Fragment: u.Fragment}
+		return []byte(out.String())
+	}
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := n.(type) {
+		case *ast.Link:
+			t.Destination = rewrite(t.Destination)
+		case *ast.Image:
+			t.Destination = rewrite(t.Destination)
+		}
+		return ast.WalkContinue, nil
+	})
+}
@@ -0,0 +1,135 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/cache"
+	"github.com/harrydayexe/GoBlog/v2/pkg/images"
+	"github.com/harrydayexe/GoBlog/v2/pkg/models"
+)
+
+// postCacheBucket is the cache bucket holding parsed posts.
+const postCacheBucket = "posts"
+
+// cacheFormat versions cached posts. It must be changed whenever parsing or
+// rendering changes the Post produced from the same source, so that entries
+// written by older code are not reused.
+const cacheFormat = "goblog-post-1"
+
+// cachedPost is a parsed and rendered post as stored in the cache.
+type cachedPost struct {
+	Post models.Post
+
+	// WikiIndex is the wikiIndexKey of the posts the post's wiki links were
+	// resolved against, or empty when wiki links were disabled.
+	WikiIndex string
+}
+
+func init() {
+	// Register the types that front matter decoders put in Post.Params.
+	gob.Register([]any{})
+	gob.Register(map[string]any{})
+	gob.Register(time.Time{})
+}
+
+// configFingerprint describes everything in cfg that affects the Post parsed
+// from a file, for use in cache keys. Highlight styles only change the
+// stylesheet, not the post, so they are left out.
+func configFingerprint(cfg Config) string {
+	imagesFingerprint := cfg.Images.Fingerprint()
+	// Functions cannot be printed meaningfully, so the names of the
+	// shortcodes and hooks set stand in for them, with their versions
+	shortcodes := strings.Join(slices.Sorted(maps.Keys(cfg.Shortcodes)), ",")
+	hooks := cfg.RenderHooks.String()
+	cfg.Logger, cfg.Cache, cfg.Images, cfg.Shortcodes, cfg.Jobs = nil, nil, nil, nil, 0
+	// Which posts are published only changes wiki links, which are checked
+	// against the cached post's WikiIndex instead, and which are excluded
+	// only changes slug collisions, which are checked after parsing
+	cfg.Published, cfg.Excluded = nil, nil
+	cfg.RenderHooks = RenderHooks{}
+	cfg.HighlightStyle, cfg.HighlightDarkStyle = "", ""
+	return fmt.Sprintf("%s %+v %s %s %s", cacheFormat, cfg, imagesFingerprint, shortcodes, hooks)
+}
+
+// postKey returns the cache key of the file at path with the given content.
+// Page bundles also depend on the names of their assets, since links to them
+// are rewritten, and with responsive images on the contents of their JPEG
+// and PNG assets, since their sizes are written into the post.
+func (p *Parser) postKey(fsys fs.FS, path string, content []byte) (string, error) {
+	var assets []string
+	parts := [][]byte{[]byte(p.fingerprint), []byte(path), content}
+	if dir := bundleDir(path); dir != "" {
+		var err error
+		if assets, err = bundleAssets(fsys, dir); err != nil {
+			return "", err
+		}
+		if p.config.Images != nil {
+			for _, asset := range assets {
+				if !images.IsRaster(asset) {
+					continue
+				}
+				data, err := fs.ReadFile(fsys, dir+"/"+asset)
+				if err != nil {
+					return "", err
+				}
+				parts = append(parts, []byte(asset), []byte(cache.Key(data)))
+			}
+		}
+	}
+	parts = append(parts, []byte(strings.Join(assets, "\x00")))
+	return cache.Key(parts...), nil
+}
+
+// loadPost returns the post cached under key, if there is one that can be
+// decoded.
+func (p *Parser) loadPost(key string) (*cachedPost, bool) {
+	data, ok := p.config.Cache.Get(postCacheBucket, key)
+	if !ok {
+		return nil, false
+	}
+	var cp cachedPost
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&cp); err != nil {
+		p.Logger.Logger.Debug("Ignoring unreadable cache entry", slog.String("key", key), slog.Any("error", err))
+		return nil, false
+	}
+	return &cp, true
+}
+
+// storePost caches post under key. Failures are logged rather than returned,
+// since the post itself is fine and will simply be parsed again next time.
+func (p *Parser) storePost(key string, post *models.Post, wikiIndex string) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cachedPost{Post: *post, WikiIndex: wikiIndex}); err != nil {
+		p.Logger.Logger.Debug("Not caching post", slog.String("path", post.SourcePath), slog.Any("error", err))
+		return
+	}
+	if err := p.config.Cache.Put(postCacheBucket, key, buf.Bytes()); err != nil {
+		p.Logger.Logger.Warn("Failed to cache post", slog.String("path", post.SourcePath), slog.Any("error", err))
+	}
+}
+
+// wikiIndexKey identifies the contents of idx, so that a cached post whose
+// links were resolved against a different set of posts is parsed again. It
+// is empty for a nil index.
+func wikiIndexKey(idx wikiLinkIndex) string {
+	if idx == nil {
+		return ""
+	}
+	parts := make([][]byte, 0, 2*len(idx))
+	for _, target := range slices.Sorted(maps.Keys(idx)) {
+		parts = append(parts, []byte(target), []byte(idx[target]))
+	}
+	return cache.Key(parts...)
+}
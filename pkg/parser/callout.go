@@ -0,0 +1,161 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// kindCallout is the AST node kind of a GitHub-style callout.
+var kindCallout = ast.NewNodeKind("Callout")
+
+// calloutMarker matches the [!KIND] marker opening a callout, with an
+// optional title after it on the same line.
+var calloutMarker = regexp.MustCompile(`^\[!([A-Za-z][A-Za-z0-9_-]*)\][ \t]*(.*?)\s*$`)
+
+// callout is a block quote that opened with a [!KIND] marker. Its children
+// are the block quote's content without the marker line.
+type callout struct {
+	ast.BaseBlock
+	CalloutKind string // Lower-case kind, such as "note" or "warning"
+	Title       string
+}
+
+// Kind implements ast.Node.
+func (n *callout) Kind() ast.NodeKind {
+	return kindCallout
+}
+
+// Dump implements ast.Node.
+func (n *callout) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Kind": n.CalloutKind, "Title": n.Title}, nil)
+}
+
+// calloutTransformer replaces block quotes whose first line is a [!KIND]
+// marker with callout nodes.
+type calloutTransformer struct{}
+
+// Transform implements parser.ASTTransformer.
+func (calloutTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+
+	var quotes []*ast.Blockquote
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if q, ok := n.(*ast.Blockquote); ok && entering {
+			quotes = append(quotes, q)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, q := range quotes {
+		para, ok := q.FirstChild().(*ast.Paragraph)
+		if !ok || para.Lines().Len() == 0 {
+			continue
+		}
+		first := para.Lines().At(0)
+		m := calloutMarker.FindSubmatch(first.Value(source))
+		if m == nil {
+			continue
+		}
+
+		c := &callout{
+			CalloutKind: strings.ToLower(string(m[1])),
+			Title:       string(m[2]),
+		}
+		if c.Title == "" {
+			c.Title = calloutTitle(c.CalloutKind)
+		}
+
+		// Drop the inlines of the marker line, and the paragraph itself if
+		// the marker was all it held.
+		for child := para.FirstChild(); child != nil; {
+			if start, ok := inlineStart(child); ok && start >= first.Stop {
+				break
+			}
+			next := child.NextSibling()
+			para.RemoveChild(para, child)
+			if t, ok := child.(*ast.Text); ok && (t.SoftLineBreak() || t.HardLineBreak()) {
+				break
+			}
+			child = next
+		}
+		if !para.HasChildren() {
+			q.RemoveChild(q, para)
+		}
+
+		for child := q.FirstChild(); child != nil; {
+			next := child.NextSibling()
+			c.AppendChild(c, child)
+			child = next
+		}
+		q.Parent().ReplaceChild(q.Parent(), q, c)
+	}
+}
+
+// inlineStart returns the source position at which an inline node begins,
+// taken from its first text or raw HTML segment.
+func inlineStart(n ast.Node) (int, bool) {
+	switch n := n.(type) {
+	case *ast.Text:
+		return n.Segment.Start, true
+	case *ast.RawHTML:
+		if n.Segments.Len() > 0 {
+			return n.Segments.At(0).Start, true
+		}
+	}
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		if start, ok := inlineStart(child); ok {
+			return start, true
+		}
+	}
+	return 0, false
+}
+
+// calloutTitle returns the default title of a callout kind: the kind with
+// its first letter capitalised and dashes and underscores as spaces.
+func calloutTitle(kind string) string {
+	title := strings.NewReplacer("-", " ", "_", " ").Replace(kind)
+	return strings.ToUpper(title[:1]) + title[1:]
+}
+
+// calloutRenderer renders callouts as <aside> elements.
+type calloutRenderer struct{}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (calloutRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindCallout, func(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+		n := node.(*callout)
+		if entering {
+			_, _ = w.WriteString(`<aside class="callout callout-` + n.CalloutKind + `">` + "\n")
+			_, _ = w.WriteString(`<p class="callout-title">`)
+			_, _ = w.Write(util.EscapeHTML([]byte(n.Title)))
+			_, _ = w.WriteString("</p>\n")
+		} else {
+			_, _ = w.WriteString("</aside>\n")
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// callouts is the goldmark extension enabled by ExtensionCallouts.
+type callouts struct{}
+
+// Extend implements goldmark.Extender.
+func (callouts) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(calloutTransformer{}, 200),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(calloutRenderer{}, 500),
+	))
+}
@@ -0,0 +1,217 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// lineRange matches a line number or an inclusive range of them, such as 3 or
+// 3-5, in a {…} line list.
+var lineRange = regexp.MustCompile(`^[0-9]+(-[0-9]+)?$`)
+
+// codeInfoAttributes parses the words after the language in a fenced code
+// block's info string into the attributes goldmark-highlighting reads:
+//
+//	title="main.go"  a caption, quoted if it holds spaces
+//	{3-5,7}          lines to emphasise, counted from the first line
+//	linenos          line numbers; linenos=table puts them in a table
+//	linenostart=10   line numbers, starting from 10
+//
+// Unknown words are ignored. It returns nil when the info string uses
+// goldmark-highlighting's own {key=value} attribute syntax, which that
+// extension then reads itself.
+func codeInfoAttributes(info string) []ast.Attribute {
+	fields := infoFields(info)
+	if len(fields) < 2 {
+		return nil
+	}
+
+	var attrs []ast.Attribute
+	numbered := false
+	for _, field := range fields[1:] {
+		if inner, ok := strings.CutPrefix(field, "{"); ok {
+			inner = strings.TrimSuffix(inner, "}")
+			if strings.Contains(inner, "=") {
+				return nil
+			}
+			var lines []any
+			for _, r := range strings.FieldsFunc(inner, func(c rune) bool { return c == ',' || c == ' ' }) {
+				if lineRange.MatchString(r) {
+					lines = append(lines, []byte(r))
+				}
+			}
+			if len(lines) > 0 {
+				attrs = append(attrs, ast.Attribute{Name: []byte("hl_lines"), Value: lines})
+			}
+			continue
+		}
+
+		key, value, hasValue := strings.Cut(field, "=")
+		value = unquote(value)
+		switch {
+		case key == "title" && hasValue:
+			attrs = append(attrs, ast.Attribute{Name: []byte("title"), Value: []byte(value)})
+		case key == "linenos" && !hasValue:
+			attrs = append(attrs, ast.Attribute{Name: []byte("linenos"), Value: true})
+		case key == "linenos" && (value == "table" || value == "inline"):
+			attrs = append(attrs, ast.Attribute{Name: []byte("linenos"), Value: []byte(value)})
+		case key == "linenostart" && hasValue:
+			if n, err := strconv.Atoi(value); err == nil {
+				attrs = append(attrs, ast.Attribute{Name: []byte("linenostart"), Value: float64(n)})
+				numbered = true
+			}
+		}
+	}
+	// A starting line number implies line numbers
+	if numbered && !slices.ContainsFunc(attrs, func(a ast.Attribute) bool { return string(a.Name) == "linenos" }) {
+		attrs = append(attrs, ast.Attribute{Name: []byte("linenos"), Value: true})
+	}
+	return attrs
+}
+
+// infoFields splits an info string at spaces that are not inside quotes or
+// braces.
+func infoFields(info string) []string {
+	var fields []string
+	var field strings.Builder
+	var quote byte
+	braces := 0
+	for i := 0; i < len(info); i++ {
+		c := info[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{':
+			braces++
+		case c == '}' && braces > 0:
+			braces--
+		case (c == ' ' || c == '\t') && braces == 0:
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+			continue
+		}
+		field.WriteByte(c)
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+// unquote removes one pair of matching single or double quotes around s.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// codeInfoTransformer sets the attributes described by each fenced code
+// block's info string on the block.
+type codeInfoTransformer struct{}
+
+// Transform implements parser.ASTTransformer.
+func (codeInfoTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		block, ok := n.(*ast.FencedCodeBlock)
+		if !ok || !entering || block.Info == nil {
+			return ast.WalkContinue, nil
+		}
+		for _, attr := range codeInfoAttributes(string(block.Info.Segment.Value(source))) {
+			block.SetAttribute(attr.Name, attr.Value)
+		}
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+// codeBlockWrapper returns the goldmark-highlighting WrapperRenderer that
+// puts code blocks with a title, or every code block when copyButton is set,
+// in a <figure class="code-block"> holding the caption and copy button.
+func codeBlockWrapper(copyButton bool) highlighting.WrapperRenderer {
+	return func(w util.BufWriter, ctx highlighting.CodeBlockContext, entering bool) {
+		var title []byte
+		if attrs := ctx.Attributes(); attrs != nil {
+			if v, ok := attrs.GetString("title"); ok {
+				title, _ = v.([]byte)
+			}
+		}
+		wrapped := len(title) > 0 || copyButton
+
+		if entering {
+			if wrapped {
+				_, _ = w.WriteString(`<figure class="code-block">` + "\n")
+				if len(title) > 0 {
+					_, _ = w.WriteString(`<figcaption class="code-title">`)
+					_, _ = w.Write(util.EscapeHTML(title))
+					_, _ = w.WriteString("</figcaption>\n")
+				}
+				if copyButton {
+					_, _ = w.WriteString(`<button type="button" class="code-copy" aria-label="Copy code to clipboard">Copy</button>` + "\n")
+				}
+			}
+			// Highlighted blocks are written by chroma, which opens its own
+			// <pre>; the rest need one.
+			if !ctx.Highlighted() {
+				_, _ = w.WriteString("<pre><code")
+				if lang, ok := ctx.Language(); ok {
+					_, _ = w.WriteString(` class="language-`)
+					_, _ = w.Write(util.EscapeHTML(lang))
+					_ = w.WriteByte('"')
+				}
+				_ = w.WriteByte('>')
+			}
+			return
+		}
+
+		if !ctx.Highlighted() {
+			_, _ = w.WriteString("</code></pre>\n")
+		} else if wrapped {
+			_ = w.WriteByte('\n')
+		}
+		if wrapped {
+			_, _ = w.WriteString("</figure>\n")
+		}
+	}
+}
+
+// codeBlocks is the goldmark extension enabled by
+// Config.EnableCodeHighlighting. It highlights fenced code with chroma class
+// names and applies the titles, line numbers and emphasised lines given in
+// their info strings.
+type codeBlocks struct {
+	copyButton bool
+}
+
+// Extend implements goldmark.Extender.
+func (e codeBlocks) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(codeInfoTransformer{}, 300),
+	))
+	highlighting.NewHighlighting(
+		highlighting.WithFormatOptions(
+			html.WithClasses(true),
+		),
+		highlighting.WithWrapperRenderer(codeBlockWrapper(e.copyButton)),
+	).Extend(m)
+}
@@ -4,7 +4,13 @@
 
 package parser
 
-import "log/slog"
+import (
+	"log/slog"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/cache"
+	"github.com/harrydayexe/GoBlog/v2/pkg/images"
+	"github.com/harrydayexe/GoBlog/v2/pkg/models"
+)
 
 // Config contains all the options for the Parser to use when reading and
 // parsing markdown files.
@@ -13,10 +19,140 @@ type Config struct {
 	// highlighted or not
 	EnableCodeHighlighting bool
 
+	// EnableCopyButton controls whether highlighted code blocks carry a
+	// button for copying their code, for templates to wire up.
+	EnableCopyButton bool
+
+	// HighlightStyle is the chroma style HighlightCSS uses for code. When it
+	// and HighlightDarkStyle are both empty, DefaultHighlightStyle and
+	// DefaultHighlightDarkStyle are used.
+	HighlightStyle string
+
+	// HighlightDarkStyle is the chroma style HighlightCSS uses for code when
+	// the reader prefers a dark colour scheme. Empty means no dark style.
+	HighlightDarkStyle string
+
 	// EnableFootnote controls whether the parser should allow the use of PHP
 	// Markdown Extra Footnotes.
 	EnableFootnote bool
 
+	// EnableWikiLinks controls whether [[Post]], [[slug|label]] and
+	// ![[file]] wiki links are recognised and resolved.
+	EnableWikiLinks bool
+
+	// EnableMath controls whether $inline$ and $$display$$ TeX math is
+	// recognised and rendered to MathML.
+	EnableMath bool
+
+	// EnableHeadingAnchors controls whether headings get a permalink anchor
+	// linking to their own id.
+	EnableHeadingAnchors bool
+
+	// HeadingAnchor configures the anchors added when EnableHeadingAnchors is
+	// set. Zero fields take their defaults.
+	HeadingAnchor HeadingAnchor
+
+	// EnableExternalLinks controls whether links to other sites are given
+	// the attributes described by ExternalLinks.
+	EnableExternalLinks bool
+
+	// ExternalLinks configures the attributes added to external links when
+	// EnableExternalLinks is set.
+	ExternalLinks ExternalLinks
+
+	// EnableShortcodes controls whether {{< name >}} shortcodes are expanded.
+	// The built-in shortcodes are always available when it is set.
+	EnableShortcodes bool
+
+	// Shortcodes are the handlers of shortcodes, by name, available when
+	// EnableShortcodes is set. They are added to the built-in shortcodes,
+	// replacing any of the same name.
+	Shortcodes map[string]ShortcodeFunc
+
+	// ShortcodeVersion identifies the behaviour of Shortcodes for cache keys,
+	// since handlers cannot be compared. Change it whenever a handler's
+	// output changes, such as when a shortcode template is edited.
+	ShortcodeVersion string
+
+	// RenderHooks replace how links, images, headings and code blocks are
+	// rendered. Nil hooks leave their nodes rendered as usual.
+	RenderHooks RenderHooks
+
+	// RenderHooksVersion identifies the behaviour of RenderHooks for cache
+	// keys, since hooks cannot be compared. Change it whenever a hook's
+	// output changes, such as when a hook template is edited.
+	RenderHooksVersion string
+
+	// EnableUnsafeHTML controls whether raw HTML written in posts is kept.
+	// When it is set, every post's rendered HTML is sanitised with
+	// HTMLPolicy.
+	EnableUnsafeHTML bool
+
+	// HTMLPolicy is the allowlist of elements and attributes kept when
+	// EnableUnsafeHTML is set. A policy without Elements means
+	// DefaultHTMLPolicy.
+	HTMLPolicy HTMLPolicy
+
+	// Dialect selects the markdown extensions enabled by default. Empty means
+	// DialectCommonMark.
+	Dialect Dialect
+
+	// Extensions switches individual extensions on or off, overriding the
+	// choice made by Dialect.
+	Extensions map[Extension]bool
+
+	// HeadingShift is added to the level of every heading, clamped to 1–6.
+	// A shift of 1 renders # headings as <h2>.
+	HeadingShift int
+
+	// TOCMinLevel is the shallowest heading level included in a post's table
+	// of contents. Zero means the default of 2, since level 1 is usually the
+	// post title.
+	TOCMinLevel int
+
+	// TOCMaxLevel is the deepest heading level included in a post's table of
+	// contents. Zero means the default of 3.
+	TOCMaxLevel int
+
+	// Schema holds rules for front matter keys that every post must follow,
+	// such as required keys, types and allowed values. A Schema without
+	// Fields checks nothing.
+	Schema Schema
+
+	// SummaryWords is the approximate number of words in an automatic
+	// excerpt, used when a post has no <!--more--> marker. Zero means the
+	// default of 70.
+	SummaryWords int
+
+	// Published reports whether a post will be published, for callers that
+	// leave some posts out, such as drafts or posts that are not yet live.
+	// Only published posts can be the target of a published post's wiki
+	// links. Nil means every post is published.
+	Published func(post *models.Post) bool
+
+	// Excluded reports whether a post is left out of the blog altogether,
+	// such as a draft when drafts are not included. Excluded posts claim no
+	// slug, while scheduled posts do, so that they cannot take the slug of
+	// a live post when they are published. Nil means no post is excluded.
+	Excluded func(post *models.Post) bool
+
+	// Cache stores parsed posts between ParseDirectory calls, so that files
+	// which have not changed skip markdown conversion. Nil disables caching.
+	Cache *cache.Store
+
+	// Images measures and resizes the JPEG and PNG images of page bundles,
+	// so that they can be given width, height, srcset and sizes attributes.
+	// Nil leaves images as written.
+	Images *images.Processor
+
+	// Jobs is the number of files ParseDirectory parses and renders at the
+	// same time. Zero or less means runtime.GOMAXPROCS(0).
+	Jobs int
+
+	// BlogRoot is the root path the blog is served from, used to build the
+	// URLs of page bundle assets. Empty means "/".
+	BlogRoot string
+
 	// Logger is the structured logger used by the parser. When nil,
 	// [log/slog.Default] is used.
 	Logger *slog.Logger
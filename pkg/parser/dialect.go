@@ -0,0 +1,166 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/yuin/goldmark"
+	emoji "github.com/yuin/goldmark-emoji"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Dialect is a named set of markdown extensions.
+type Dialect string
+
+const (
+	// DialectCommonMark is plain CommonMark with no syntax extensions. It is
+	// the default.
+	DialectCommonMark Dialect = "commonmark"
+
+	// DialectGFM adds the GitHub Flavored Markdown extensions: tables,
+	// strikethrough, task lists and linkify.
+	DialectGFM Dialect = "gfm"
+
+	// DialectExtended adds definition lists, typographic punctuation and
+	// :emoji: shortcodes to DialectGFM.
+	DialectExtended Dialect = "extended"
+)
+
+// Extension names a markdown feature that can be switched on or off
+// individually, overriding the Dialect.
+type Extension string
+
+const (
+	ExtensionTables          Extension = "tables"        // GFM pipe tables
+	ExtensionStrikethrough   Extension = "strikethrough" // ~~struck~~ text
+	ExtensionTaskLists       Extension = "tasklists"     // - [x] list items
+	ExtensionLinkify         Extension = "linkify"       // Bare URLs become links
+	ExtensionDefinitionLists Extension = "deflist"       // Term / : definition lists
+	ExtensionTypographer     Extension = "typographer"   // Smart quotes, dashes and ellipses
+	ExtensionEmoji           Extension = "emoji"         // :smile: shortcodes
+	ExtensionCallouts        Extension = "callouts"      // > [!NOTE] block quotes become <aside> callouts
+	ExtensionHardWraps       Extension = "hardwraps"     // Line breaks within a paragraph are kept
+	ExtensionXHTML           Extension = "xhtml"         // Void elements are self-closed, as in <br />
+)
+
+// Extensions lists every Extension in the order they are documented.
+var Extensions = []Extension{
+	ExtensionTables,
+	ExtensionStrikethrough,
+	ExtensionTaskLists,
+	ExtensionLinkify,
+	ExtensionDefinitionLists,
+	ExtensionTypographer,
+	ExtensionEmoji,
+	ExtensionCallouts,
+	ExtensionHardWraps,
+	ExtensionXHTML,
+}
+
+// dialectExtensions lists the extensions each dialect turns on. Hard wraps
+// and XHTML output are GoBlog's long-standing rendering style, and callouts
+// only change block quotes that open with a [!KIND] marker, so every dialect
+// keeps them.
+var dialectExtensions = map[Dialect][]Extension{
+	DialectCommonMark: {ExtensionCallouts, ExtensionHardWraps, ExtensionXHTML},
+	DialectGFM: {
+		ExtensionTables, ExtensionStrikethrough, ExtensionTaskLists, ExtensionLinkify,
+		ExtensionCallouts, ExtensionHardWraps, ExtensionXHTML,
+	},
+	DialectExtended: {
+		ExtensionTables, ExtensionStrikethrough, ExtensionTaskLists, ExtensionLinkify,
+		ExtensionDefinitionLists, ExtensionTypographer, ExtensionEmoji,
+		ExtensionCallouts, ExtensionHardWraps, ExtensionXHTML,
+	},
+}
+
+// ParseDialect returns the Dialect named s, or an error listing the valid
+// names.
+func ParseDialect(s string) (Dialect, error) {
+	d := Dialect(s)
+	if _, ok := dialectExtensions[d]; !ok {
+		return "", fmt.Errorf("unknown markdown dialect %q (want %s, %s or %s)", s, DialectCommonMark, DialectGFM, DialectExtended)
+	}
+	return d, nil
+}
+
+// ParseExtension returns the Extension named s, or an error listing the
+// valid names.
+func ParseExtension(s string) (Extension, error) {
+	e := Extension(s)
+	if !slices.Contains(Extensions, e) {
+		return "", fmt.Errorf("unknown markdown extension %q (want one of %v)", s, Extensions)
+	}
+	return e, nil
+}
+
+// enabledExtensions resolves the dialect and per-extension switches of cfg
+// into the set of extensions to use. An empty or unknown dialect is treated
+// as DialectCommonMark.
+func enabledExtensions(cfg *Config) map[Extension]bool {
+	d, ok := dialectExtensions[cfg.Dialect]
+	if !ok {
+		d = dialectExtensions[DialectCommonMark]
+	}
+	enabled := make(map[Extension]bool, len(Extensions))
+	for _, e := range d {
+		enabled[e] = true
+	}
+	for e, on := range cfg.Extensions {
+		enabled[e] = on
+	}
+	return enabled
+}
+
+// syntaxExtensions returns the goldmark extenders for the enabled syntax
+// extensions. Hard wraps and XHTML are renderer options and are applied
+// separately.
+func syntaxExtensions(enabled map[Extension]bool) []goldmark.Extender {
+	var exts []goldmark.Extender
+	if enabled[ExtensionTables] {
+		exts = append(exts, extension.Table)
+	}
+	if enabled[ExtensionStrikethrough] {
+		exts = append(exts, extension.Strikethrough)
+	}
+	if enabled[ExtensionTaskLists] {
+		exts = append(exts, extension.TaskList)
+	}
+	if enabled[ExtensionLinkify] {
+		exts = append(exts, extension.Linkify)
+	}
+	if enabled[ExtensionDefinitionLists] {
+		exts = append(exts, extension.DefinitionList)
+	}
+	if enabled[ExtensionTypographer] {
+		exts = append(exts, extension.Typographer)
+	}
+	if enabled[ExtensionEmoji] {
+		exts = append(exts, emoji.Emoji)
+	}
+	if enabled[ExtensionCallouts] {
+		exts = append(exts, callouts{})
+	}
+	return exts
+}
+
+// headingShift is an AST transformer that moves every heading down by its
+// value, so that a post's # headings can sit below the page's own <h1>.
+type headingShift int
+
+// Transform implements parser.ASTTransformer.
+func (s headingShift) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := n.(*ast.Heading); ok && entering {
+			h.Level = clampHeadingLevel(h.Level + int(s))
+		}
+		return ast.WalkContinue, nil
+	})
+}
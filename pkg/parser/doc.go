@@ -2,15 +2,25 @@
 // License, v. 2.0. If a copy of the MPL was not distributed with this
 // file, You can obtain one at https://mozilla.org/MPL/2.0/.
 
-// Package parser reads markdown files with YAML frontmatter and converts them
-// to Post objects for the GoBlog system.
+// Package parser reads markdown files with YAML, TOML or JSON frontmatter and
+// converts them to Post objects for the GoBlog system.
 //
 // The parser uses goldmark for markdown processing and supports:
-//   - YAML frontmatter for post metadata
+//   - YAML (---), TOML (+++) or JSON ({ ... }) frontmatter for post metadata
 //   - Syntax highlighting for code blocks
 //   - Footnotes
 //   - Auto-generated heading IDs
-//   - HTML sanitization
+//   - Tables of contents and post summaries
+//   - GitHub-style callouts (> [!NOTE], > [!WARNING], > [!TIP] and custom kinds)
+//   - Page bundles (a directory holding index.md and the files it links to)
+//   - Optional rel, target and class attributes on external links
+//   - Optional responsive images for the JPEG and PNG files of page bundles
+//   - Optional wiki links ([[Post]], [[slug|label]], ![[image.png]])
+//   - Optional $inline$ and $$display$$ TeX math, rendered to MathML
+//   - Optional Hugo-style {{< shortcodes >}} with Go or template handlers
+//   - Optional render hooks for links, images, headings and code blocks
+//   - Optional raw HTML, sanitised with a configurable allowlist
+//   - Optional front matter schemas of required keys, types and allowed values
 //
 // Basic usage:
 //
@@ -43,6 +53,12 @@
 //	// Enable footnote support
 //	p := parser.New(parser.WithFootnote())
 //
+//	// Resolve [[wiki links]] between posts
+//	p := parser.New(parser.WithWikiLinks())
+//
+//	// Render TeX math to MathML
+//	p := parser.New(parser.WithMath())
+//
 //	// Inject a structured logger
 //	p := parser.New(parser.WithLogger(myLogger))
 //
@@ -63,24 +79,179 @@
 // but will not be visually styled until a matching stylesheet is included
 // in your page.
 //
-// Generate the stylesheet for a given style at startup and embed it in your
-// templates:
+// Parser.HighlightCSS returns a stylesheet for those classes. It holds the
+// rules of the light style, followed by the dark style's inside a
+// prefers-color-scheme: dark media query, so code follows the reader's colour
+// scheme. The styles default to github and github-dark; choose others with
+// WithHighlightStyle:
 //
-//	import (
-//	    chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
-//	    "github.com/alecthomas/chroma/v2/styles"
-//	    "strings"
-//	)
+//	p := parser.New(parser.WithHighlightStyle("monokailight", "monokai"))
+//	css, err := p.HighlightCSS() // serve as a stylesheet, or embed in a <style> tag
 //
-//	formatter := chromahtml.New(chromahtml.WithClasses(true))
-//	style := styles.Get("monokai")
-//	var sb strings.Builder
-//	formatter.WriteCSS(&sb, style)
-//	chromaCSS := sb.String() // embed in a <style> tag in your template
+// The generator publishes this stylesheet as chroma.css, which the default
+// templates link.
 //
 // The class names follow the Pygments short-name convention. A full reference
 // is available in the chroma source:
 // https://github.com/alecthomas/chroma/blob/master/types.go
 //
+// # Code Block Options
+//
+// The info string of a highlighted fenced code block may follow the language
+// with a title, lines to emphasise and line numbers:
+//
+//	```go title="main.go" {3-5,8} linenos
+//
+// The title becomes a <figcaption class="code-title"> in a
+// <figure class="code-block"> around the code. Emphasised lines, counted from
+// the first line of the block, and line numbers are rendered by chroma with
+// the .hl and .ln classes, which HighlightCSS styles. linenos=table puts the
+// numbers in a separate table column, and linenostart=10 numbers from 10.
+// goldmark-highlighting's own {hl_lines=[3,4] linenos=true} syntax is also
+// accepted. With WithCopyButton, every highlighted block is wrapped in the
+// figure, with a <button class="code-copy"> for copying its code. None of
+// these apply when highlighting is disabled.
+//
+// # Math
+//
+// With WithMath, TeX math is converted to MathML while the post is parsed, so
+// it displays in current browsers without a client-side library. The common
+// subset of LaTeX math is supported: scripts, fractions, roots, Greek letters
+// and symbols, accents, \mathbb and other fonts, \text, \left and \right,
+// and matrix, cases and aligned environments. An expression using anything
+// else fails the file with a MathError giving the line it starts on.
+//
+// # Callouts
+//
+// A block quote whose first line is a [!KIND] marker is rendered as a
+// callout rather than a quote:
+//
+//	> [!WARNING] Mind the gap
+//	> Text of the callout.
+//
+// becomes an <aside class="callout callout-warning"> holding a
+// <p class="callout-title"> with the title, followed by the quote's content.
+// The title defaults to the kind, so > [!NOTE] is titled "Note". Any kind
+// made of letters, digits, dashes and underscores is accepted; the default
+// templates style note, tip, important, warning and caution. Disable with
+// WithExtension(ExtensionCallouts, false).
+//
+// # Heading Anchors
+//
+// WithHeadingAnchors adds a permalink inside each heading:
+//
+//	<h2 id="setup">Setup <a class="heading-anchor" href="#setup" aria-label="Permalink to Setup">#</a></h2>
+//
+// The symbol, its position before or after the text, the shallowest level
+// that gets one and the aria-label are set by HeadingAnchor. The default
+// templates hide the anchor until the heading is hovered or focused.
+//
+// # External Links
+//
+// WithExternalLinks marks up links to other sites, leaving links to the blog's
+// own host and to trusted domains alone:
+//
+//	[Go](https://go.dev)
+//
+// becomes, with Rel set to nofollow, NewTab set and Class "external",
+//
+//	<a href="https://go.dev" rel="nofollow noopener" target="_blank" class="external">Go</a>
+//
+// # Responsive Images
+//
+// WithImages takes an images.Processor and uses it on every markdown image
+// that names a JPEG or PNG file in the post's page bundle:
+//
+//	![A cat](cat.jpg)
+//
+// becomes
+//
+//	<img src="/posts/cats/cat.jpg" alt="A cat" width="2000" height="1500" loading="lazy" decoding="async"
+//	    srcset="/posts/cats/cat-480w.jpg 480w, /posts/cats/cat-960w.jpg 960w, /posts/cats/cat.jpg 2000w"
+//	    sizes="(min-width: 56rem) 52rem, 100vw">
+//
+// An image that cannot be processed fails its post. The parser only writes
+// the attributes: the generator publishes the copies and the original with its
+// metadata removed.
+//
+// # Shortcodes
+//
+// WithShortcodes expands Hugo-style shortcodes, written on their own or in a
+// line of text:
+//
+//	{{< youtube dQw4w9WgXcQ >}}
+//	{{< figure src="cat.jpg" caption="Our cat" >}}
+//	{{< details "Spoilers" >}}
+//	The *butler* did it.
+//	{{< /details >}}
+//
+// Arguments are separated by spaces and may be quoted; name=value arguments
+// are named. A paired shortcode receives the markdown between its tags, both
+// as written and rendered to HTML. The youtube, figure and details
+// shortcodes are built in, and others are registered as a ShortcodeFunc,
+// either Go code or an html/template wrapped by TemplateShortcode:
+//
+//	p := parser.New(parser.WithShortcodes(map[string]parser.ShortcodeFunc{
+//	    "note": parser.TemplateShortcode(template.Must(template.New("note").Parse(
+//	        `<aside class="note">{{.InnerHTML}}</aside>`))),
+//	}))
+//
+// Shortcodes in code spans and code blocks are left as written, and
+// {{</* name */>}} shows a tag as text. A shortcode with no handler fails its
+// post with a ShortcodeError wrapping ErrUnknownShortcode.
+//
+// # Render Hooks
+//
+// WithRenderHooks replaces the HTML written for links, images, headings and
+// code blocks. Each hook receives a LinkData, ImageData, HeadingData or
+// CodeBlockData holding the node's destination, title, text, level or
+// language, and the HTML the node would otherwise be rendered as, so a hook
+// can wrap it rather than start again:
+//
+//	p := parser.New(parser.WithRenderHooks(parser.RenderHooks{
+//	    Link: parser.TemplateRenderHook[parser.LinkData](template.Must(template.New("link").Parse(
+//	        `{{.HTML}}{{if .Attributes.target}} <span aria-hidden="true">↗</span>{{end}}`))),
+//	}))
+//
+// Hooks run after every other feature, so they see rewritten bundle links,
+// external link attributes and highlighted code.
+//
+// # Raw HTML
+//
+// Raw HTML written in posts is left out unless WithUnsafeHTML is given.
+// With it, the rendered HTML of each post is passed through an allowlist
+// sanitiser, so that authors can use elements such as <details>, <kbd>,
+// <sup> and <video> without being able to run scripts in readers' browsers:
+// <script> elements, on* event handlers and javascript: URLs are always
+// removed. DefaultHTMLPolicy keeps everything the parser itself writes;
+// WithHTMLPolicy replaces it:
+//
+//	policy := parser.DefaultHTMLPolicy()
+//	policy.Allow("video", "src", "controls")
+//	p := parser.New(parser.WithUnsafeHTML(), parser.WithHTMLPolicy(policy))
+//
+// The output of shortcodes and render hooks is sanitised too, so any element
+// they write must be allowed.
+//
+// # Front Matter Schemas
+//
+// Every post needs a title, date and description. WithSchema adds rules of a
+// site's own for any front matter key: that it is required, its type, the
+// values it may take, its length and a pattern it must match. A schema is
+// usually kept in a schema.yaml file read with ParseSchema:
+//
+//	fields:
+//	  author:
+//	    required: true
+//	    type: string
+//	  tags:
+//	    type: list
+//	    enum: [go, web, release]
+//	  description:
+//	    maxLength: 160
+//
+// A post that breaks any rule is left out, and its FileError wraps a
+// SchemaError for each broken rule, so that authors can fix them all at once.
+//
 // A Parser is safe for concurrent use by multiple goroutines after creation.
 package parser
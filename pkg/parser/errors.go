@@ -25,6 +25,53 @@ func (fe FileError) Unwrap() error {
 	return fe.Err
 }
 
+// SlugCollisionError reports that a post resolved to a slug already claimed by
+// another post. It is wrapped in a FileError whose Path is the later file, so
+// the full message names both source files.
+type SlugCollisionError struct {
+	Slug         string // The slug both posts resolved to
+	ExistingPath string // Path to the file that claimed the slug first
+}
+
+// Error implements the error interface.
+func (sc SlugCollisionError) Error() string {
+	return fmt.Sprintf("slug %q is already used by %s", sc.Slug, sc.ExistingPath)
+}
+
+// BrokenLinkError reports a wiki link whose target could not be found. It is
+// wrapped in a FileError whose Path is the file containing the link.
+type BrokenLinkError struct {
+	Target string // The link target as written, e.g. "Other Post"
+	Embed  bool   // Whether the link was an embed (![[...]])
+}
+
+// Error implements the error interface.
+func (bl BrokenLinkError) Error() string {
+	if bl.Embed {
+		return fmt.Sprintf("unresolved wiki embed ![[%s]]", bl.Target)
+	}
+	return fmt.Sprintf("unresolved wiki link [[%s]]", bl.Target)
+}
+
+// MathError reports a math expression that could not be converted to MathML.
+// It is wrapped in a FileError whose Path is the file containing the
+// expression.
+type MathError struct {
+	Line int    // Line of the file on which the expression starts
+	Expr string // The expression as written, without its $ delimiters
+	Err  error  // Why the expression could not be converted
+}
+
+// Error implements the error interface.
+func (me MathError) Error() string {
+	return fmt.Sprintf("line %d: invalid math %q: %v", me.Line, me.Expr, me.Err)
+}
+
+// Unwrap returns the underlying error for error wrapping support.
+func (me MathError) Unwrap() error {
+	return me.Err
+}
+
 // ParseErrors aggregates multiple parsing errors encountered during
 // directory-wide parsing operations.
 //
@@ -0,0 +1,220 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/harrydayexe/GoBlog/v2/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatterFormat names the syntax a post's front matter is written in. It
+// is used in error messages so authors know how their file was read.
+type frontMatterFormat string
+
+const (
+	formatYAML frontMatterFormat = "YAML"
+	formatTOML frontMatterFormat = "TOML"
+	formatJSON frontMatterFormat = "JSON"
+)
+
+// detectFrontMatter reports which front matter format content opens with:
+// YAML between --- lines, TOML between +++ lines, or a JSON object. It
+// returns the empty string when content has no recognisable front matter.
+//
+// Content starting with { is only JSON front matter when a whole object
+// decodes there, or when the object opens with a quoted key, so that a
+// malformed object is still reported as JSON. Markdown may start with a
+// brace too, as in a {{< figure >}} shortcode or a {.class} attribute.
+func detectFrontMatter(content []byte) frontMatterFormat {
+	content = bytes.TrimPrefix(content, []byte("\ufeff"))
+	switch {
+	case bytes.HasPrefix(content, []byte("---")):
+		return formatYAML
+	case bytes.HasPrefix(content, []byte("+++")):
+		return formatTOML
+	}
+	object, ok := bytes.CutPrefix(bytes.TrimLeft(content, " \t\r\n"), []byte("{"))
+	if !ok {
+		return ""
+	}
+	if bytes.HasPrefix(bytes.TrimLeft(object, " \t\r\n"), []byte(`"`)) {
+		return formatJSON
+	}
+	if _, _, err := splitJSONFrontMatter(content); err == nil {
+		return formatJSON
+	}
+	return ""
+}
+
+// splitJSONFrontMatter separates a leading JSON object from the markdown body
+// that follows it. It returns an error if the object is not valid JSON.
+func splitJSONFrontMatter(content []byte) (frontMatter, body []byte, err error) {
+	content = bytes.TrimPrefix(content, []byte("\ufeff"))
+	dec := json.NewDecoder(bytes.NewReader(content))
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
+		return nil, nil, err
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		return nil, nil, fmt.Errorf("front matter must be a JSON object")
+	}
+	return raw, content[dec.InputOffset():], nil
+}
+
+// decodeFrontMatter decodes YAML or TOML front matter, in the given format,
+// into post with decode, and returns every key it sets, as frontMatterFields
+// does.
+func decodeFrontMatter(format frontMatterFormat, decode func(any) error, post *models.Post) (map[string]any, error) {
+	if format == formatTOML {
+		return decodeTOMLFrontMatter(decode, post)
+	}
+	var node yaml.Node
+	if err := decode(&node); err != nil {
+		return nil, err
+	}
+	return decodeYAMLFrontMatter(&node, post)
+}
+
+// decodeJSONFrontMatter decodes a JSON front matter object into post, and
+// returns every key it sets, as frontMatterFields does.
+//
+// JSON is a subset of YAML, so the object is read as YAML with
+// decodeYAMLFrontMatter and the yaml struct tags on models.Post apply
+// unchanged.
+func decodeJSONFrontMatter(raw []byte, post *models.Post) (map[string]any, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(raw, &node); err != nil {
+		return nil, err
+	}
+	return decodeYAMLFrontMatter(&node, post)
+}
+
+// decodeYAMLFrontMatter decodes YAML front matter, parsed into node, into
+// post, and returns every key it sets, as frontMatterFields does.
+//
+// String values of time.Time fields are retagged as timestamps first, so
+// that a quoted date such as "2024-01-02", and every date in JSON, which has
+// no date type, is read just as an unquoted YAML date would be.
+func decodeYAMLFrontMatter(node *yaml.Node, post *models.Post) (map[string]any, error) {
+	if len(node.Content) == 1 && node.Content[0].Kind == yaml.MappingNode {
+		fields := node.Content[0].Content
+		for i := 0; i+1 < len(fields); i += 2 {
+			value := fields[i+1]
+			if value.Kind == yaml.ScalarNode && value.Tag == "!!str" && timeFields[fields[i].Value] {
+				value.Tag = "!!timestamp"
+				value.Style = 0
+			}
+		}
+	}
+	if err := node.Decode(post); err != nil {
+		return nil, err
+	}
+	fields, err := frontMatterFields(node.Decode)
+	if err != nil {
+		return nil, err
+	}
+	post.Params = frontMatterParams(fields)
+	return fields, nil
+}
+
+// decodeTOMLFrontMatter decodes TOML front matter into post with decode, and
+// returns every key it sets, as frontMatterFields does.
+//
+// TOML has a date type, but a date written as a string, such as
+// date = "2024-01-02", does not decode into a time.Time unless it is in RFC
+// 3339 form. Such strings are parsed as dates first, and the front matter
+// decoded again from the fixed values, so that they work as in YAML.
+func decodeTOMLFrontMatter(decode func(any) error, post *models.Post) (map[string]any, error) {
+	fields, err := frontMatterFields(decode)
+	if err != nil {
+		return nil, err
+	}
+	fixed := maps.Clone(fields)
+	dated := false
+	for key, value := range fields {
+		if s, ok := value.(string); ok && timeFields[key] {
+			if t, err := time.Parse(time.DateOnly, s); err == nil {
+				fixed[key] = t
+				dated = true
+			}
+		}
+	}
+	if dated {
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(fixed); err != nil {
+			return nil, err
+		}
+		decode = func(v any) error {
+			_, err := toml.Decode(buf.String(), v)
+			return err
+		}
+	}
+	if err := decode(post); err != nil {
+		return nil, err
+	}
+	post.Params = frontMatterParams(fields)
+	return fields, nil
+}
+
+// frontMatterFields decodes the front matter a second time, with decode,
+// into a map of every key it sets, for schema validation and Params.
+func frontMatterFields(decode func(any) error) (map[string]any, error) {
+	var fields map[string]any
+	if err := decode(&fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// frontMatterParams returns the keys of fields that have no field of their
+// own in models.Post. It returns nil when there are none.
+func frontMatterParams(fields map[string]any) map[string]any {
+	var params map[string]any
+	for key, value := range fields {
+		if postFields[key] {
+			continue
+		}
+		if params == nil {
+			params = make(map[string]any)
+		}
+		params[key] = value
+	}
+	return params
+}
+
+// postFields holds the front matter keys of models.Post.
+var postFields = frontMatterKeys(func(reflect.StructField) bool { return true })
+
+// timeFields holds the front matter keys of models.Post whose fields are
+// time.Time values.
+var timeFields = frontMatterKeys(func(f reflect.StructField) bool {
+	return f.Type == reflect.TypeFor[time.Time]()
+})
+
+// frontMatterKeys returns the yaml keys of the models.Post fields for which
+// match reports true.
+func frontMatterKeys(match func(reflect.StructField) bool) map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeFor[models.Post]()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !match(f) {
+			continue
+		}
+		if name, _, _ := strings.Cut(f.Tag.Get("yaml"), ","); name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
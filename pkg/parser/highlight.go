@@ -0,0 +1,73 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"bytes"
+	"fmt"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+const (
+	// DefaultHighlightStyle is the chroma style used for code when no light
+	// style is configured.
+	DefaultHighlightStyle = "github"
+
+	// DefaultHighlightDarkStyle is the chroma style used for code in dark
+	// mode when neither style is configured.
+	DefaultHighlightDarkStyle = "github-dark"
+)
+
+// ParseHighlightStyle returns name if it is a registered chroma style, or an
+// error naming it otherwise. The available styles are listed at
+// https://xyproto.github.io/splash/docs/.
+func ParseHighlightStyle(name string) (string, error) {
+	if _, ok := styles.Registry[name]; !ok {
+		return "", fmt.Errorf("unknown highlight style %q", name)
+	}
+	return name, nil
+}
+
+// HighlightCSS returns the stylesheet for the class names emitted by code
+// highlighting: the rules of the light style, followed by those of the dark
+// style inside a prefers-color-scheme: dark media query. An empty light
+// style means DefaultHighlightStyle, and an empty dark style leaves the light
+// style in use in dark mode too.
+//
+// Returns an error if either style is not a registered chroma style.
+func HighlightCSS(light, dark string) ([]byte, error) {
+	if light == "" {
+		light = DefaultHighlightStyle
+	}
+	formatter := chromahtml.New(chromahtml.WithClasses(true))
+
+	var buf bytes.Buffer
+	if _, err := ParseHighlightStyle(light); err != nil {
+		return nil, err
+	}
+	if err := formatter.WriteCSS(&buf, styles.Get(light)); err != nil {
+		return nil, fmt.Errorf("failed to write %s highlight CSS: %w", light, err)
+	}
+
+	if dark != "" {
+		if _, err := ParseHighlightStyle(dark); err != nil {
+			return nil, err
+		}
+		buf.WriteString("@media (prefers-color-scheme: dark) {\n")
+		if err := formatter.WriteCSS(&buf, styles.Get(dark)); err != nil {
+			return nil, fmt.Errorf("failed to write %s highlight CSS: %w", dark, err)
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// HighlightCSS returns the stylesheet for the highlight styles the parser was
+// configured with, as by the package-level HighlightCSS.
+func (p *Parser) HighlightCSS() ([]byte, error) {
+	return HighlightCSS(p.config.HighlightStyle, p.config.HighlightDarkStyle)
+}
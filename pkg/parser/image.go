@@ -0,0 +1,78 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/images"
+	"github.com/yuin/goldmark/ast"
+)
+
+// responsiveImages gives each markdown image naming one of a bundle's JPEG or
+// PNG assets its intrinsic width and height, loading="lazy", and a srcset and
+// sizes listing the scaled copies proc makes of it. The bundle is the
+// directory dir of fsys, and its assets are published at base. An image proc
+// cannot process, such as a corrupt file, is left as it is, since the
+// generator publishes it unchanged.
+//
+// It must run before rewriteBundleLinks, while destinations are still
+// relative to the bundle.
+func responsiveImages(doc ast.Node, fsys fs.FS, dir string, assets []string, base string, proc *images.Processor) error {
+	known := make(map[string]bool, len(assets))
+	for _, a := range assets {
+		known[a] = true
+	}
+
+	return ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		img, ok := n.(*ast.Image)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		u, err := url.Parse(string(img.Destination))
+		if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+			return ast.WalkContinue, nil
+		}
+		asset := path.Clean(u.Path)
+		if !known[asset] || !images.IsRaster(asset) {
+			return ast.WalkContinue, nil
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, asset))
+		if err != nil {
+			return ast.WalkStop, fmt.Errorf("failed to read image %s: %w", asset, err)
+		}
+		processed, err := proc.Process(data)
+		if err != nil {
+			return ast.WalkSkipChildren, nil
+		}
+
+		img.SetAttributeString("width", []byte(strconv.Itoa(processed.Width)))
+		img.SetAttributeString("height", []byte(strconv.Itoa(processed.Height)))
+		img.SetAttributeString("loading", []byte("lazy"))
+		img.SetAttributeString("decoding", []byte("async"))
+		if len(processed.Variants) > 0 {
+			srcset := make([]string, 0, len(processed.Variants)+1)
+			for _, v := range processed.Variants {
+				srcset = append(srcset, srcsetEntry(base+images.VariantName(asset, v.Width), v.Width))
+			}
+			srcset = append(srcset, srcsetEntry(base+asset, processed.Width))
+			img.SetAttributeString("srcset", []byte(strings.Join(srcset, ", ")))
+			img.SetAttributeString("sizes", []byte(proc.Sizes()))
+		}
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+// srcsetEntry returns a srcset candidate for the image at p, width pixels
+// wide. The path is escaped, since a space would end the URL.
+func srcsetEntry(p string, width int) string {
+	return (&url.URL{Path: p}).String() + " " + strconv.Itoa(width) + "w"
+}
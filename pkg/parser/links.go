@@ -0,0 +1,166 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// linkRels are the rel values ParseLinkRel accepts.
+var linkRels = []string{"noopener", "noreferrer", "nofollow", "ugc", "sponsored", "external"}
+
+// ParseLinkRel returns rel if it is one of the link types that make sense on
+// an external link (noopener, noreferrer, nofollow, ugc, sponsored and
+// external), or an error listing them otherwise.
+func ParseLinkRel(rel string) (string, error) {
+	if !slices.Contains(linkRels, rel) {
+		return "", fmt.Errorf("unknown link rel %q (want one of %s)", rel, strings.Join(linkRels, ", "))
+	}
+	return rel, nil
+}
+
+// ExternalLinks describes how WithExternalLinks marks up links that leave the
+// site.
+type ExternalLinks struct {
+	// Site is the host the blog is published on, such as "example.com", or
+	// any URL on it. Links to it and its subdomains are not external. When
+	// empty, every link with a host is external.
+	Site string
+
+	// Rel values, such as "nofollow" or "ugc", are added to the rel
+	// attribute of external links.
+	Rel []string
+
+	// NewTab opens external links in a new tab with target="_blank". It
+	// implies rel="noopener".
+	NewTab bool
+
+	// Class is added to the class attribute of external links when not
+	// empty.
+	Class string
+
+	// Trusted lists domains, such as "github.com", whose links are left as
+	// written, like links to the site itself. A domain also covers its
+	// subdomains.
+	Trusted []string
+}
+
+// isExternal reports whether dest, a link destination, points at a host that
+// is neither the site nor a trusted domain. Relative links, fragments and
+// links without a host, such as mailto: links, are never external.
+func (l ExternalLinks) isExternal(dest string) bool {
+	u, err := url.Parse(dest)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range append([]string{l.Site}, l.Trusted...) {
+		d := domainOf(domain)
+		if d != "" && (host == d || strings.HasSuffix(host, "."+d)) {
+			return false
+		}
+	}
+	return true
+}
+
+// rel returns the rel attribute of external links, with each value once.
+func (l ExternalLinks) rel() string {
+	var rels []string
+	for _, r := range l.Rel {
+		if !slices.Contains(rels, r) {
+			rels = append(rels, r)
+		}
+	}
+	if l.NewTab && !slices.Contains(rels, "noopener") {
+		rels = append(rels, "noopener")
+	}
+	return strings.Join(rels, " ")
+}
+
+// domainOf returns the lower-case host named by s, which may be a bare
+// domain or a URL.
+func domainOf(s string) string {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if !strings.Contains(s, "//") {
+		s = "//" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// externalLinkTransformer sets the attributes described by an ExternalLinks
+// on each external link and autolink.
+type externalLinkTransformer struct {
+	links ExternalLinks
+}
+
+// Transform implements parser.ASTTransformer.
+func (t externalLinkTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	rel := t.links.rel()
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		var dest string
+		switch link := n.(type) {
+		case *ast.Link:
+			dest = string(link.Destination)
+		case *ast.AutoLink:
+			if link.AutoLinkType != ast.AutoLinkURL {
+				return ast.WalkContinue, nil
+			}
+			dest = string(link.URL(source))
+			if !strings.Contains(dest, "://") {
+				// Linkify turns www.example.com into http://www.example.com
+				dest = "http://" + dest
+			}
+		default:
+			return ast.WalkContinue, nil
+		}
+		if !t.links.isExternal(dest) {
+			return ast.WalkContinue, nil
+		}
+
+		if rel != "" {
+			n.SetAttributeString("rel", []byte(rel))
+		}
+		if t.links.NewTab {
+			n.SetAttributeString("target", []byte("_blank"))
+		}
+		if t.links.Class != "" {
+			n.SetAttributeString("class", []byte(t.links.Class))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// externalLinks is the goldmark extension enabled by
+// Config.EnableExternalLinks.
+type externalLinks struct {
+	links ExternalLinks
+}
+
+// Extend implements goldmark.Extender.
+func (e externalLinks) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(externalLinkTransformer{links: e.links}, 900),
+	))
+}
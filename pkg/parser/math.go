@@ -0,0 +1,272 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var (
+	// kindMathInline is the AST node kind of $...$ and inline $$...$$ math.
+	kindMathInline = ast.NewNodeKind("MathInline")
+
+	// kindMathBlock is the AST node kind of $$ math on lines of its own.
+	kindMathBlock = ast.NewNodeKind("MathBlock")
+)
+
+// mathInline is an inline node holding a TeX expression. MathML is empty
+// until the expression has been converted by convertMath.
+type mathInline struct {
+	ast.BaseInline
+	Expr    string
+	Display bool
+	MathML  string
+	offset  int // Position of the opening delimiter in the source
+}
+
+// Kind implements ast.Node.
+func (n *mathInline) Kind() ast.NodeKind {
+	return kindMathInline
+}
+
+// Dump implements ast.Node.
+func (n *mathInline) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Expr": n.Expr}, nil)
+}
+
+// mathBlock is a block node whose lines hold a display TeX expression.
+type mathBlock struct {
+	ast.BaseBlock
+	MathML string
+	offset int // Position of the opening delimiter in the source
+	closed bool
+}
+
+// Kind implements ast.Node.
+func (n *mathBlock) Kind() ast.NodeKind {
+	return kindMathBlock
+}
+
+// IsRaw implements ast.Node.
+func (n *mathBlock) IsRaw() bool {
+	return true
+}
+
+// Dump implements ast.Node.
+func (n *mathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// expr returns the TeX expression held in the block's lines.
+func (n *mathBlock) expr(source []byte) string {
+	var buf bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		buf.Write(line.Value(source))
+	}
+	return string(bytes.TrimSpace(buf.Bytes()))
+}
+
+// mathInlineParser parses $inline$ and $$display$$ math within a line.
+//
+// To leave prices such as "$5 and $10" alone, the opening $ of inline math
+// must be followed by a non-space, and the closing $ must follow a non-space
+// and not be followed by a digit.
+type mathInlineParser struct{}
+
+// Trigger implements parser.InlineParser.
+func (mathInlineParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+// Parse implements parser.InlineParser.
+func (mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, seg := block.PeekLine()
+	display := len(line) > 1 && line[1] == '$'
+	delim := 1
+	if display {
+		delim = 2
+	}
+	rest := line[delim:]
+
+	end := -1
+	if display {
+		end = bytes.Index(rest, []byte("$$"))
+	} else if len(rest) > 0 && !util.IsSpace(rest[0]) {
+		for i := 0; i < len(rest); i++ {
+			if rest[i] == '\\' {
+				i++
+				continue
+			}
+			if rest[i] == '$' && !util.IsSpace(rest[i-1]) && (i+1 == len(rest) || !isDigit(rest[i+1])) {
+				end = i
+				break
+			}
+		}
+	}
+	if end <= 0 {
+		return nil
+	}
+	block.Advance(delim + end + delim)
+
+	return &mathInline{
+		Expr:    string(bytes.TrimSpace(rest[:end])),
+		Display: display,
+		offset:  seg.Start,
+	}
+}
+
+// mathBlockParser parses display math opened by $$ at the start of a line
+// and closed by $$ at the end of the same or a later line.
+type mathBlockParser struct{}
+
+// Trigger implements parser.BlockParser.
+func (mathBlockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+// Open implements parser.BlockParser.
+func (mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, seg := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], []byte("$$")) {
+		return nil, parser.NoChildren
+	}
+	start := pos + 2
+	rest := util.TrimRightSpace(line[start:])
+
+	node := &mathBlock{offset: seg.Start + pos}
+	if i := bytes.Index(rest, []byte("$$")); i >= 0 {
+		// $$ ... $$ on one line is a block only if nothing follows it
+		if i != len(rest)-2 {
+			return nil, parser.NoChildren
+		}
+		rest = rest[:i]
+		node.closed = true
+	}
+	if len(util.TrimLeftSpace(rest)) > 0 {
+		node.Lines().Append(text.NewSegment(seg.Start+start, seg.Start+start+len(rest)))
+	}
+	reader.Advance(lineContentLength(line))
+	return node, parser.NoChildren
+}
+
+// Continue implements parser.BlockParser.
+func (mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	n := node.(*mathBlock)
+	if n.closed {
+		return parser.Close
+	}
+	line, seg := reader.PeekLine()
+	if line == nil {
+		return parser.Close
+	}
+	rest := util.TrimRightSpace(line)
+	if bytes.HasSuffix(rest, []byte("$$")) {
+		n.Lines().Append(text.NewSegment(seg.Start, seg.Start+len(rest)-2))
+		reader.Advance(lineContentLength(line))
+		n.closed = true
+		return parser.Close
+	}
+	n.Lines().Append(seg)
+	reader.Advance(lineContentLength(line))
+	return parser.Continue | parser.NoChildren
+}
+
+// Close implements parser.BlockParser.
+func (mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+// CanInterruptParagraph implements parser.BlockParser.
+func (mathBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+// CanAcceptIndentedLine implements parser.BlockParser.
+func (mathBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// lineContentLength returns the length of line without its newline.
+func lineContentLength(line []byte) int {
+	if bytes.HasSuffix(line, []byte("\n")) {
+		return len(line) - 1
+	}
+	return len(line)
+}
+
+// mathRenderer writes the MathML produced by convertMath.
+type mathRenderer struct{}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (mathRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMathInline, func(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			_, _ = w.WriteString(node.(*mathInline).MathML)
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	reg.Register(kindMathBlock, func(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			_, _ = w.WriteString(node.(*mathBlock).MathML)
+			_ = w.WriteByte('\n')
+		}
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+// texMath is the goldmark extension enabled by Config.EnableMath.
+type texMath struct{}
+
+// Extend implements goldmark.Extender.
+func (texMath) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(mathBlockParser{}, 90)),
+		parser.WithInlineParsers(util.Prioritized(mathInlineParser{}, 150)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(mathRenderer{}, 150),
+	))
+}
+
+// convertMath converts every math expression in doc to MathML. It returns a
+// MathError for each expression that cannot be converted, with line numbers
+// counted from firstLine, the line of the file on which source begins.
+func convertMath(doc ast.Node, source []byte, firstLine int) error {
+	var errs []error
+	convert := func(expr string, display bool, offset int) string {
+		mathml, err := texToMathML(expr, display)
+		if err != nil {
+			errs = append(errs, MathError{
+				Line: firstLine + bytes.Count(source[:offset], []byte("\n")),
+				Expr: expr,
+				Err:  err,
+			})
+		}
+		return mathml
+	}
+
+	_ = ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := node.(type) {
+		case *mathInline:
+			n.MathML = convert(n.Expr, n.Display, n.offset)
+		case *mathBlock:
+			n.MathML = convert(n.expr(source), true, n.offset)
+		}
+		return ast.WalkContinue, nil
+	})
+	return errors.Join(errs...)
+}
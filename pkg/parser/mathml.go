@@ -0,0 +1,746 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// texToMathML converts a TeX math expression to a MathML <math> element. The
+// original TeX is kept in an annotation so it can be copied or read by
+// assistive technology.
+//
+// The supported syntax is the commonly used subset of LaTeX math: letters,
+// numbers and operators, sub- and superscripts, grouping, Greek letters and
+// symbols, fractions, roots, accents, font commands, \text, \left and \right
+// delimiters, and matrix, cases and aligned environments. Anything else is
+// reported as an error rather than rendered incorrectly.
+func texToMathML(tex string, display bool) (string, error) {
+	p := &texParser{src: tex}
+	items, err := p.parseRow(nil)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<math xmlns="http://www.w3.org/1998/Math/MathML"`)
+	if display {
+		sb.WriteString(` display="block"`)
+	}
+	sb.WriteString(`><semantics><mrow>`)
+	sb.WriteString(strings.Join(items, ""))
+	sb.WriteString(`</mrow><annotation encoding="application/x-tex">`)
+	sb.WriteString(html.EscapeString(tex))
+	sb.WriteString(`</annotation></semantics></math>`)
+	return sb.String(), nil
+}
+
+// texTokenKind classifies the tokens of a TeX math expression.
+type texTokenKind int
+
+const (
+	tokEOF     texTokenKind = iota
+	tokChar                 // a single character
+	tokCommand              // \name or \ followed by one symbol
+	tokOpen                 // {
+	tokClose                // }
+	tokSup                  // ^
+	tokSub                  // _
+	tokAmp                  // &, the environment column separator
+	tokNewline              // \\, the environment row separator
+)
+
+// texToken is one token of a TeX math expression. Text holds the character,
+// or the command name without its backslash.
+type texToken struct {
+	kind texTokenKind
+	text string
+}
+
+// texParser is a recursive descent parser that emits MathML as it goes.
+type texParser struct {
+	src string
+	pos int
+
+	// font is the mathvariant set by an enclosing font command such as
+	// \mathbf, or empty outside of one.
+	font string
+}
+
+// skipSpace skips whitespace, which TeX ignores in math mode.
+func (p *texParser) skipSpace() {
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		if !unicode.IsSpace(r) {
+			return
+		}
+		p.pos += size
+	}
+}
+
+// next consumes and returns the next token.
+func (p *texParser) next() texToken {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return texToken{kind: tokEOF}
+	}
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	switch r {
+	case '{':
+		return texToken{kind: tokOpen, text: "{"}
+	case '}':
+		return texToken{kind: tokClose, text: "}"}
+	case '^':
+		return texToken{kind: tokSup, text: "^"}
+	case '_':
+		return texToken{kind: tokSub, text: "_"}
+	case '&':
+		return texToken{kind: tokAmp, text: "&"}
+	case '\\':
+		start := p.pos
+		for p.pos < len(p.src) && isASCIILetter(p.src[p.pos]) {
+			p.pos++
+		}
+		if p.pos == start && p.pos < len(p.src) {
+			_, size := utf8.DecodeRuneInString(p.src[p.pos:])
+			p.pos += size
+		}
+		name := p.src[start:p.pos]
+		if name == `\` {
+			return texToken{kind: tokNewline, text: `\\`}
+		}
+		return texToken{kind: tokCommand, text: name}
+	}
+	return texToken{kind: tokChar, text: string(r)}
+}
+
+// peek returns the next token without consuming it.
+func (p *texParser) peek() texToken {
+	pos := p.pos
+	t := p.next()
+	p.pos = pos
+	return t
+}
+
+// parseRow parses atoms until the end of input or a token for which stop
+// returns true, which is left unconsumed.
+func (p *texParser) parseRow(stop func(texToken) bool) ([]string, error) {
+	var items []string
+	for {
+		t := p.peek()
+		if t.kind == tokEOF || (stop != nil && stop(t)) {
+			return items, nil
+		}
+		if t.kind == tokCommand {
+			if attrs, ok := texStyles[t.text]; ok {
+				// Style switches apply to the rest of the enclosing group
+				p.next()
+				rest, err := p.parseRow(stop)
+				if err != nil {
+					return nil, err
+				}
+				return append(items, "<mstyle"+attrs+">"+mrow(rest)+"</mstyle>"), nil
+			}
+		}
+		atom, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, atom)
+	}
+}
+
+// parseAtom parses one element together with any sub- and superscripts and
+// primes that follow it.
+func (p *texParser) parseAtom() (string, error) {
+	base, limits, err := p.parsePrimary(false)
+	if err != nil {
+		return "", err
+	}
+
+	var sub, sup, primes string
+	var hasSub, hasSup bool
+scripts:
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokSup:
+			p.next()
+			if hasSup {
+				return "", errors.New("double superscript")
+			}
+			if sup, err = p.arg("^"); err != nil {
+				return "", err
+			}
+			hasSup = true
+		case t.kind == tokSub:
+			p.next()
+			if hasSub {
+				return "", errors.New("double subscript")
+			}
+			if sub, err = p.arg("_"); err != nil {
+				return "", err
+			}
+			hasSub = true
+		case t.kind == tokChar && t.text == "'":
+			p.next()
+			primes += "′"
+		case t.kind == tokCommand && (t.text == "limits" || t.text == "nolimits"):
+			p.next()
+			limits = t.text == "limits"
+		default:
+			break scripts
+		}
+	}
+	if primes != "" {
+		if hasSup {
+			sup = "<mrow><mo>" + primes + "</mo>" + sup + "</mrow>"
+		} else {
+			sup = "<mo>" + primes + "</mo>"
+			hasSup = true
+		}
+	}
+
+	tag := ""
+	switch {
+	case hasSub && hasSup:
+		tag = "msubsup"
+		if limits {
+			tag = "munderover"
+		}
+		return "<" + tag + ">" + base + sub + sup + "</" + tag + ">", nil
+	case hasSub:
+		tag = "msub"
+		if limits {
+			tag = "munder"
+		}
+		return "<" + tag + ">" + base + sub + "</" + tag + ">", nil
+	case hasSup:
+		tag = "msup"
+		if limits {
+			tag = "mover"
+		}
+		return "<" + tag + ">" + base + sup + "</" + tag + ">", nil
+	}
+	return base, nil
+}
+
+// arg parses the argument of a command or script: a braced group or a single
+// token.
+func (p *texParser) arg(of string) (string, error) {
+	switch p.peek().kind {
+	case tokEOF, tokClose, tokAmp, tokNewline, tokSup, tokSub:
+		return "", fmt.Errorf("missing argument for %s", of)
+	}
+	s, _, err := p.parsePrimary(true)
+	return s, err
+}
+
+// parsePrimary parses a single element without scripts. It reports whether
+// scripts attached to the element are placed above and below it, as for \sum.
+// When single is set, only one character is consumed, as TeX does for
+// unbraced arguments.
+func (p *texParser) parsePrimary(single bool) (string, bool, error) {
+	pos := p.pos
+	t := p.next()
+	switch t.kind {
+	case tokOpen:
+		items, err := p.parseRow(func(t texToken) bool { return t.kind == tokClose })
+		if err != nil {
+			return "", false, err
+		}
+		if p.next().kind != tokClose {
+			return "", false, errors.New("missing closing brace")
+		}
+		return mrow(items), false, nil
+	case tokClose:
+		return "", false, errors.New("unexpected closing brace")
+	case tokSup, tokSub:
+		// A script with nothing before it attaches to an empty base
+		p.pos = pos
+		return "<mrow></mrow>", false, nil
+	case tokAmp:
+		return "", false, errors.New("unexpected & outside an environment")
+	case tokNewline:
+		return "", false, errors.New(`unexpected \\ outside an environment`)
+	case tokCommand:
+		return p.command(t.text)
+	case tokChar:
+		return p.char(t.text, single), false, nil
+	}
+	return "", false, errors.New("unexpected end of expression")
+}
+
+// char converts a character to MathML. Unless single is set, a run of digits
+// becomes one number and, inside a font command, a run of letters becomes one
+// identifier.
+func (p *texParser) char(s string, single bool) string {
+	r, _ := utf8.DecodeRuneInString(s)
+	switch {
+	case r < utf8.RuneSelf && isDigit(byte(r)):
+		if !single {
+			s += p.takeWhile(func(b byte) bool { return isDigit(b) || b == '.' })
+		}
+		return "<mn>" + p.styled(s) + "</mn>"
+	case unicode.IsLetter(r):
+		if !single && p.font != "" {
+			s += p.takeWhile(isASCIILetter)
+		}
+		if p.font == "normal" && utf8.RuneCountInString(s) == 1 {
+			return `<mi mathvariant="normal">` + html.EscapeString(s) + "</mi>"
+		}
+		return "<mi>" + p.styled(s) + "</mi>"
+	}
+	switch s {
+	case "-":
+		s = "−"
+	case "*":
+		s = "∗"
+	case "'":
+		s = "′"
+	case "~":
+		return "<mtext>&#xA0;</mtext>"
+	}
+	return "<mo>" + html.EscapeString(s) + "</mo>"
+}
+
+// takeWhile consumes and returns the bytes immediately following the current
+// position for which ok returns true.
+func (p *texParser) takeWhile(ok func(byte) bool) string {
+	start := p.pos
+	for p.pos < len(p.src) && ok(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// styled applies the current font to s and escapes it.
+func (p *texParser) styled(s string) string {
+	if p.font == "" || p.font == "normal" {
+		return html.EscapeString(s)
+	}
+	return html.EscapeString(strings.Map(func(r rune) rune {
+		return mathAlphanumeric(p.font, r)
+	}, s))
+}
+
+// rawGroup consumes a braced group and returns its contents unparsed.
+func (p *texParser) rawGroup(of string) (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) || p.src[p.pos] != '{' {
+		return "", fmt.Errorf("missing argument for %s", of)
+	}
+	depth := 0
+	for i := p.pos; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				s := p.src[p.pos+1 : i]
+				p.pos = i + 1
+				return s, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("missing closing brace in %s", of)
+}
+
+// command converts a control sequence and its arguments to MathML.
+func (p *texParser) command(name string) (string, bool, error) {
+	if r, ok := texGreek[name]; ok {
+		if unicode.IsUpper(r) {
+			return `<mi mathvariant="normal">` + string(r) + "</mi>", false, nil
+		}
+		return "<mi>" + string(r) + "</mi>", false, nil
+	}
+	if s, ok := texIdentifiers[name]; ok {
+		return "<mi>" + s + "</mi>", false, nil
+	}
+	if s, ok := texOperators[name]; ok {
+		return "<mo>" + html.EscapeString(s) + "</mo>", false, nil
+	}
+	if s, ok := texLargeOperators[name]; ok {
+		return `<mo movablelimits="true">` + s + "</mo>", true, nil
+	}
+	if s, ok := texIntegrals[name]; ok {
+		return "<mo>" + s + "</mo>", false, nil
+	}
+	if s, ok := texLimitFunctions[name]; ok {
+		return `<mo movablelimits="true" form="prefix">` + s + "</mo>", true, nil
+	}
+	if texFunctions[name] {
+		return "<mi>" + name + "</mi>", false, nil
+	}
+	if width, ok := texSpaces[name]; ok {
+		return `<mspace width="` + width + `"/>`, false, nil
+	}
+	if variant, ok := texFonts[name]; ok {
+		font := p.font
+		p.font = variant
+		s, err := p.arg(`\` + name)
+		p.font = font
+		return s, false, err
+	}
+	if a, ok := texAccents[name]; ok {
+		return p.accent(name, a)
+	}
+	if size, ok := texDelimiterSizes[strings.TrimRight(name, "lrm")]; ok {
+		d, err := p.delimiter(`\` + name)
+		if err != nil {
+			return "", false, err
+		}
+		return `<mo minsize="` + size + `" maxsize="` + size + `">` + html.EscapeString(d) + "</mo>", false, nil
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac", "cfrac", "binom", "dbinom", "tbinom":
+		num, err := p.arg(`\` + name)
+		if err != nil {
+			return "", false, err
+		}
+		den, err := p.arg(`\` + name)
+		if err != nil {
+			return "", false, err
+		}
+		s := "<mfrac>" + num + den + "</mfrac>"
+		if strings.HasSuffix(name, "binom") {
+			s = `<mrow><mo>(</mo><mfrac linethickness="0">` + num + den + "</mfrac><mo>)</mo></mrow>"
+		}
+		switch name[0] {
+		case 'd', 'c':
+			s = `<mstyle displaystyle="true" scriptlevel="0">` + s + "</mstyle>"
+		case 't':
+			s = `<mstyle displaystyle="false">` + s + "</mstyle>"
+		}
+		return s, false, nil
+	case "sqrt":
+		return p.sqrt()
+	case "overset", "underset", "stackrel":
+		over, err := p.arg(`\` + name)
+		if err != nil {
+			return "", false, err
+		}
+		base, err := p.arg(`\` + name)
+		if err != nil {
+			return "", false, err
+		}
+		if name == "underset" {
+			return "<munder>" + base + over + "</munder>", false, nil
+		}
+		return "<mover>" + base + over + "</mover>", false, nil
+	case "text", "textrm", "textnormal", "mbox", "textit", "textbf":
+		s, err := p.rawGroup(`\` + name)
+		if err != nil {
+			return "", false, err
+		}
+		attrs := ""
+		switch name {
+		case "textit":
+			attrs = ` mathvariant="italic"`
+		case "textbf":
+			attrs = ` mathvariant="bold"`
+		}
+		return "<mtext" + attrs + ">" + html.EscapeString(s) + "</mtext>", false, nil
+	case "operatorname":
+		limits := false
+		if p.pos < len(p.src) && p.src[p.pos] == '*' {
+			p.pos++
+			limits = true
+		}
+		s, err := p.rawGroup(`\operatorname`)
+		if err != nil {
+			return "", false, err
+		}
+		s = html.EscapeString(strings.TrimSpace(s))
+		if limits {
+			return `<mo movablelimits="true" form="prefix">` + s + "</mo>", true, nil
+		}
+		if utf8.RuneCountInString(s) == 1 {
+			return `<mi mathvariant="normal">` + s + "</mi>", false, nil
+		}
+		return "<mi>" + s + "</mi>", false, nil
+	case "pmod":
+		s, err := p.arg(`\pmod`)
+		if err != nil {
+			return "", false, err
+		}
+		return `<mrow><mspace width="1em"/><mo>(</mo><mo>mod</mo><mspace width="0.3333em"/>` + s + "<mo>)</mo></mrow>", false, nil
+	case "not":
+		return p.not()
+	case "left":
+		return p.fenced()
+	case "middle":
+		d, err := p.delimiter(`\middle`)
+		if err != nil {
+			return "", false, err
+		}
+		return `<mo stretchy="true">` + html.EscapeString(d) + "</mo>", false, nil
+	case "right":
+		return "", false, errors.New(`\right without matching \left`)
+	case "begin":
+		return p.environment()
+	case "end":
+		return "", false, errors.New(`\end without matching \begin`)
+	case "limits", "nolimits":
+		return "", false, fmt.Errorf(`\%s must follow an operator`, name)
+	case "":
+		return "", false, errors.New("trailing backslash")
+	}
+	if _, ok := texStyles[name]; ok {
+		// Reached only as a lone argument, where it has nothing to style
+		return "<mrow></mrow>", false, nil
+	}
+	return "", false, fmt.Errorf(`unknown command \%s`, name)
+}
+
+// accent places a mark over or under the command's argument.
+func (p *texParser) accent(name string, a texAccent) (string, bool, error) {
+	base, err := p.arg(`\` + name)
+	if err != nil {
+		return "", false, err
+	}
+	mark := `<mo stretchy="` + fmt.Sprint(a.stretchy) + `">` + a.mark + "</mo>"
+	if a.under {
+		return `<munder accentunder="true">` + base + mark + "</munder>", a.limits, nil
+	}
+	return `<mover accent="true">` + base + mark + "</mover>", a.limits, nil
+}
+
+// sqrt parses \sqrt{x} or \sqrt[n]{x}.
+func (p *texParser) sqrt() (string, bool, error) {
+	p.skipSpace()
+	index := ""
+	if p.pos < len(p.src) && p.src[p.pos] == '[' {
+		p.pos++
+		items, err := p.parseRow(func(t texToken) bool { return t.kind == tokChar && t.text == "]" })
+		if err != nil {
+			return "", false, err
+		}
+		if t := p.next(); t.kind != tokChar || t.text != "]" {
+			return "", false, errors.New(`missing ] in \sqrt`)
+		}
+		index = mrow(items)
+	}
+	base, err := p.arg(`\sqrt`)
+	if err != nil {
+		return "", false, err
+	}
+	if index != "" {
+		return "<mroot>" + base + index + "</mroot>", false, nil
+	}
+	return "<msqrt>" + base + "</msqrt>", false, nil
+}
+
+// not negates the relation that follows it.
+func (p *texParser) not() (string, bool, error) {
+	t := p.next()
+	op := ""
+	switch t.kind {
+	case tokChar:
+		op = t.text
+	case tokCommand:
+		op = texOperators[t.text]
+	}
+	if op == "" {
+		return "", false, errors.New(`missing relation after \not`)
+	}
+	if negated, ok := texNegations[op]; ok {
+		op = negated
+	} else {
+		op += "\u0338"
+	}
+	return "<mo>" + html.EscapeString(op) + "</mo>", false, nil
+}
+
+// delimiter consumes the delimiter following \left, \right, \big and
+// friends. The null delimiter "." is returned as the empty string.
+func (p *texParser) delimiter(of string) (string, error) {
+	t := p.next()
+	switch t.kind {
+	case tokChar:
+		switch t.text {
+		case ".":
+			return "", nil
+		case "<":
+			return "⟨", nil
+		case ">":
+			return "⟩", nil
+		case "(", ")", "[", "]", "|", "/":
+			return t.text, nil
+		}
+	case tokCommand:
+		if d, ok := texDelimiters[t.text]; ok {
+			return d, nil
+		}
+	}
+	return "", fmt.Errorf("missing or invalid delimiter after %s", of)
+}
+
+// fenced parses \left( ... \right) into stretchy fences around their
+// contents.
+func (p *texParser) fenced() (string, bool, error) {
+	open, err := p.delimiter(`\left`)
+	if err != nil {
+		return "", false, err
+	}
+	items, err := p.parseRow(func(t texToken) bool { return t.kind == tokCommand && t.text == "right" })
+	if err != nil {
+		return "", false, err
+	}
+	if t := p.next(); t.kind != tokCommand || t.text != "right" {
+		return "", false, errors.New(`\left without matching \right`)
+	}
+	closing, err := p.delimiter(`\right`)
+	if err != nil {
+		return "", false, err
+	}
+	return "<mrow>" + fence(open, "prefix") + strings.Join(items, "") + fence(closing, "postfix") + "</mrow>", false, nil
+}
+
+// environment parses \begin{name} ... \end{name} into a table.
+func (p *texParser) environment() (string, bool, error) {
+	name, err := p.rawGroup(`\begin`)
+	if err != nil {
+		return "", false, err
+	}
+	env, ok := texEnvironments[name]
+	if !ok {
+		return "", false, fmt.Errorf("unknown environment %q", name)
+	}
+	if name == "array" {
+		// Column alignment is left to the renderer
+		if _, err := p.rawGroup(`\begin{array}`); err != nil {
+			return "", false, err
+		}
+	}
+
+	isCellEnd := func(t texToken) bool {
+		return t.kind == tokAmp || t.kind == tokNewline || (t.kind == tokCommand && t.text == "end")
+	}
+	var rows [][]string
+	var cells []string
+	for {
+		items, err := p.parseRow(isCellEnd)
+		if err != nil {
+			return "", false, err
+		}
+		cells = append(cells, mrow(items))
+
+		t := p.next()
+		if t.kind == tokAmp {
+			continue
+		}
+		if t.kind == tokNewline {
+			rows = append(rows, cells)
+			cells = nil
+			continue
+		}
+		if t.kind != tokCommand {
+			return "", false, fmt.Errorf(`\begin{%s} without matching \end{%s}`, name, name)
+		}
+		end, err := p.rawGroup(`\end`)
+		if err != nil {
+			return "", false, err
+		}
+		if end != name {
+			return "", false, fmt.Errorf(`\begin{%s} ended by \end{%s}`, name, end)
+		}
+		// A trailing \\ does not start another row
+		if len(rows) == 0 || len(cells) > 1 || cells[0] != "<mrow></mrow>" {
+			rows = append(rows, cells)
+		}
+		break
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<mtable" + env.attrs + ">")
+	for _, row := range rows {
+		sb.WriteString("<mtr>")
+		for _, cell := range row {
+			sb.WriteString("<mtd>" + cell + "</mtd>")
+		}
+		sb.WriteString("</mtr>")
+	}
+	sb.WriteString("</mtable>")
+	if env.open == "" && env.close == "" {
+		return sb.String(), false, nil
+	}
+	return "<mrow>" + fence(env.open, "prefix") + sb.String() + fence(env.close, "postfix") + "</mrow>", false, nil
+}
+
+// fence returns a stretchy fence operator, or the empty string for the null
+// delimiter.
+func fence(d, form string) string {
+	if d == "" {
+		return ""
+	}
+	return `<mo fence="true" form="` + form + `" stretchy="true">` + html.EscapeString(d) + "</mo>"
+}
+
+// mrow groups items into a single element.
+func mrow(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return "<mrow>" + strings.Join(items, "") + "</mrow>"
+}
+
+func isASCIILetter(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+// mathAlphabet locates a styled alphabet in Unicode's Mathematical
+// Alphanumeric Symbols block. Letters that were encoded earlier, such as ℝ,
+// are listed as exceptions because the block leaves holes in their place.
+type mathAlphabet struct {
+	upper, lower, digit rune
+	exceptions          map[rune]rune
+}
+
+var mathAlphabets = map[string]mathAlphabet{
+	"bold":          {0x1D400, 0x1D41A, 0x1D7CE, nil},
+	"italic":        {0x1D434, 0x1D44E, 0, map[rune]rune{'h': 'ℎ'}},
+	"bold-italic":   {0x1D468, 0x1D482, 0x1D7CE, nil},
+	"script":        {0x1D49C, 0x1D4B6, 0, map[rune]rune{'B': 'ℬ', 'E': 'ℰ', 'F': 'ℱ', 'H': 'ℋ', 'I': 'ℐ', 'L': 'ℒ', 'M': 'ℳ', 'R': 'ℛ', 'e': 'ℯ', 'g': 'ℊ', 'o': 'ℴ'}},
+	"fraktur":       {0x1D504, 0x1D51E, 0, map[rune]rune{'C': 'ℭ', 'H': 'ℌ', 'I': 'ℑ', 'R': 'ℜ', 'Z': 'ℨ'}},
+	"double-struck": {0x1D538, 0x1D552, 0x1D7D8, map[rune]rune{'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ'}},
+	"sans-serif":    {0x1D5A0, 0x1D5BA, 0x1D7E2, nil},
+	"monospace":     {0x1D670, 0x1D68A, 0x1D7F6, nil},
+}
+
+// mathAlphanumeric returns the styled form of an ASCII letter or digit in the
+// given variant, or r unchanged when there is none.
+func mathAlphanumeric(variant string, r rune) rune {
+	a, ok := mathAlphabets[variant]
+	if !ok {
+		return r
+	}
+	if e, ok := a.exceptions[r]; ok {
+		return e
+	}
+	switch {
+	case 'A' <= r && r <= 'Z':
+		return a.upper + r - 'A'
+	case 'a' <= r && r <= 'z':
+		return a.lower + r - 'a'
+	case '0' <= r && r <= '9' && a.digit != 0:
+		return a.digit + r - '0'
+	}
+	return r
+}
@@ -0,0 +1,210 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+// This file holds the tables of TeX commands understood by texToMathML.
+
+// texGreek maps Greek letter commands to their characters. Capitals are
+// rendered upright, as TeX does.
+var texGreek = map[string]rune{
+	"alpha": 'α', "beta": 'β', "gamma": 'γ', "delta": 'δ', "epsilon": 'ϵ',
+	"varepsilon": 'ε', "zeta": 'ζ', "eta": 'η', "theta": 'θ', "vartheta": 'ϑ',
+	"iota": 'ι', "kappa": 'κ', "lambda": 'λ', "mu": 'μ', "nu": 'ν', "xi": 'ξ',
+	"omicron": 'ο', "pi": 'π', "varpi": 'ϖ', "rho": 'ρ', "varrho": 'ϱ',
+	"sigma": 'σ', "varsigma": 'ς', "tau": 'τ', "upsilon": 'υ', "phi": 'ϕ',
+	"varphi": 'φ', "chi": 'χ', "psi": 'ψ', "omega": 'ω',
+	"Gamma": 'Γ', "Delta": 'Δ', "Theta": 'Θ', "Lambda": 'Λ', "Xi": 'Ξ',
+	"Pi": 'Π', "Sigma": 'Σ', "Upsilon": 'Υ', "Phi": 'Φ', "Psi": 'Ψ',
+	"Omega": 'Ω',
+}
+
+// texIdentifiers maps symbol commands that behave as variables.
+var texIdentifiers = map[string]string{
+	"infty": "∞", "partial": "∂", "nabla": "∇", "hbar": "ℏ", "ell": "ℓ",
+	"emptyset": "∅", "varnothing": "∅", "aleph": "ℵ", "Re": "ℜ", "Im": "ℑ",
+	"wp": "℘", "imath": "ı", "jmath": "ȷ", "top": "⊤", "bot": "⊥",
+	"angle": "∠", "triangle": "△",
+}
+
+// texOperators maps operator, relation, arrow and punctuation commands.
+var texOperators = map[string]string{
+	// Binary operators
+	"pm": "±", "mp": "∓", "times": "×", "div": "÷", "cdot": "⋅", "ast": "∗",
+	"star": "⋆", "circ": "∘", "bullet": "∙", "oplus": "⊕", "ominus": "⊖",
+	"otimes": "⊗", "odot": "⊙", "oslash": "⊘", "cup": "∪", "cap": "∩",
+	"sqcup": "⊔", "sqcap": "⊓", "vee": "∨", "lor": "∨", "wedge": "∧",
+	"land": "∧", "setminus": "∖", "wr": "≀", "dagger": "†", "ddagger": "‡",
+	"amalg": "⨿", "uplus": "⊎", "bmod": "mod",
+
+	// Relations
+	"leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠",
+	"leqslant": "⩽", "geqslant": "⩾", "approx": "≈", "equiv": "≡", "sim": "∼",
+	"simeq": "≃", "cong": "≅", "propto": "∝", "ll": "≪", "gg": "≫",
+	"prec": "≺", "succ": "≻", "preceq": "⪯", "succeq": "⪰", "in": "∈",
+	"notin": "∉", "ni": "∋", "subset": "⊂", "supset": "⊃", "subseteq": "⊆",
+	"supseteq": "⊇", "subsetneq": "⊊", "supsetneq": "⊋", "perp": "⊥",
+	"parallel": "∥", "mid": "∣", "nmid": "∤", "models": "⊨", "vdash": "⊢",
+	"dashv": "⊣", "doteq": "≐", "coloneqq": "≔", "triangleq": "≜",
+	"asymp": "≍", "nleq": "≰", "ngeq": "≱", "nsubseteq": "⊈",
+
+	// Arrows
+	"to": "→", "rightarrow": "→", "gets": "←", "leftarrow": "←",
+	"leftrightarrow": "↔", "Rightarrow": "⇒", "Leftarrow": "⇐",
+	"Leftrightarrow": "⇔", "implies": "⟹", "impliedby": "⟸", "iff": "⟺",
+	"mapsto": "↦", "longrightarrow": "⟶", "longleftarrow": "⟵",
+	"longleftrightarrow": "⟷", "Longrightarrow": "⟹", "Longleftarrow": "⟸",
+	"uparrow": "↑", "downarrow": "↓", "updownarrow": "↕", "hookrightarrow": "↪",
+	"hookleftarrow": "↩", "nearrow": "↗", "searrow": "↘",
+
+	// Logic, dots and punctuation
+	"neg": "¬", "lnot": "¬", "forall": "∀", "exists": "∃", "nexists": "∄",
+	"therefore": "∴", "because": "∵", "ldots": "…", "dots": "…",
+	"cdots": "⋯", "vdots": "⋮", "ddots": "⋱", "colon": ":", "prime": "′",
+
+	// Delimiters used outside \left and \right
+	"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋",
+	"lceil": "⌈", "rceil": "⌉", "lvert": "|", "rvert": "|", "vert": "|",
+	"lVert": "‖", "rVert": "‖", "Vert": "‖", "|": "‖", "{": "{", "}": "}",
+	"lbrace": "{", "rbrace": "}", "lbrack": "[", "rbrack": "]",
+	"backslash": `\`,
+
+	// Escaped characters
+	"_": "_", "%": "%", "$": "$", "#": "#", "&": "&",
+}
+
+// texNegations maps relations to their negated forms for \not.
+var texNegations = map[string]string{
+	"=": "≠", "<": "≮", ">": "≯", "∈": "∉", "≤": "≰", "≥": "≱", "⊂": "⊄",
+	"⊃": "⊅", "⊆": "⊈", "⊇": "⊉", "≡": "≢", "∼": "≁", "≈": "≉", "≅": "≇",
+	"∣": "∤", "∥": "∦",
+}
+
+// texLargeOperators maps n-ary operators whose limits sit above and below
+// them in display math.
+var texLargeOperators = map[string]string{
+	"sum": "∑", "prod": "∏", "coprod": "∐", "bigcup": "⋃", "bigcap": "⋂",
+	"bigvee": "⋁", "bigwedge": "⋀", "bigoplus": "⨁", "bigotimes": "⨂",
+	"bigodot": "⨀", "bigsqcup": "⨆", "biguplus": "⨄",
+}
+
+// texIntegrals maps integral signs, whose limits are set as scripts.
+var texIntegrals = map[string]string{
+	"int": "∫", "iint": "∬", "iiint": "∭", "oint": "∮",
+}
+
+// texLimitFunctions maps named operators whose limits sit below them in
+// display math.
+var texLimitFunctions = map[string]string{
+	"lim": "lim", "limsup": "lim sup", "liminf": "lim inf", "max": "max",
+	"min": "min", "sup": "sup", "inf": "inf", "det": "det", "gcd": "gcd",
+	"Pr": "Pr", "argmax": "arg max", "argmin": "arg min",
+}
+
+// texFunctions lists named functions rendered upright.
+var texFunctions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "sec": true,
+	"csc": true, "sinh": true, "cosh": true, "tanh": true, "coth": true,
+	"arcsin": true, "arccos": true, "arctan": true, "log": true, "ln": true,
+	"lg": true, "exp": true, "dim": true, "ker": true, "hom": true,
+	"arg": true, "deg": true,
+}
+
+// texSpaces maps spacing commands to widths.
+var texSpaces = map[string]string{
+	",": "0.1667em", "thinspace": "0.1667em", ":": "0.2222em",
+	">": "0.2222em", "medspace": "0.2222em", ";": "0.2778em",
+	"thickspace": "0.2778em", "!": "-0.1667em", " ": "0.3333em",
+	"enspace": "0.5em", "quad": "1em", "qquad": "2em",
+}
+
+// texFonts maps font commands to the alphabet their argument is set in.
+var texFonts = map[string]string{
+	"mathrm": "normal", "mathup": "normal", "mathbf": "bold",
+	"mathit": "italic", "boldsymbol": "bold-italic", "bm": "bold-italic",
+	"mathbb": "double-struck", "mathcal": "script", "mathscr": "script",
+	"mathfrak": "fraktur", "mathsf": "sans-serif", "mathtt": "monospace",
+}
+
+// texAccent describes a mark placed over or under its argument.
+type texAccent struct {
+	mark     string
+	under    bool // The mark goes below the argument
+	stretchy bool // The mark stretches to the argument's width
+	limits   bool // Scripts go above and below, as for \overbrace
+}
+
+// texAccents maps accent commands.
+var texAccents = map[string]texAccent{
+	"hat":            {mark: "^"},
+	"widehat":        {mark: "^", stretchy: true},
+	"check":          {mark: "ˇ"},
+	"tilde":          {mark: "~"},
+	"widetilde":      {mark: "~", stretchy: true},
+	"bar":            {mark: "¯"},
+	"overline":       {mark: "‾", stretchy: true},
+	"vec":            {mark: "→"},
+	"overrightarrow": {mark: "→", stretchy: true},
+	"overleftarrow":  {mark: "←", stretchy: true},
+	"dot":            {mark: "˙"},
+	"ddot":           {mark: "¨"},
+	"acute":          {mark: "´"},
+	"grave":          {mark: "`"},
+	"breve":          {mark: "˘"},
+	"underline":      {mark: "_", under: true, stretchy: true},
+	"overbrace":      {mark: "⏞", stretchy: true, limits: true},
+	"underbrace":     {mark: "⏟", under: true, stretchy: true, limits: true},
+}
+
+// texDelimiters maps the delimiter commands accepted after \left, \right,
+// \middle and the \big family.
+var texDelimiters = map[string]string{
+	"{": "{", "}": "}", "|": "‖", "lbrace": "{", "rbrace": "}",
+	"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋",
+	"lceil": "⌈", "rceil": "⌉", "vert": "|", "lvert": "|", "rvert": "|",
+	"Vert": "‖", "lVert": "‖", "rVert": "‖", "uparrow": "↑",
+	"downarrow": "↓", "updownarrow": "↕", "backslash": `\`,
+}
+
+// texDelimiterSizes maps the \big family, without any l, r or m suffix, to
+// delimiter heights.
+var texDelimiterSizes = map[string]string{
+	"big": "1.2em", "Big": "1.623em", "bigg": "2.047em", "Bigg": "2.470em",
+}
+
+// texStyles maps style switches to the attributes of the mstyle element that
+// wraps the rest of their group.
+var texStyles = map[string]string{
+	"displaystyle":      ` displaystyle="true" scriptlevel="0"`,
+	"textstyle":         ` displaystyle="false" scriptlevel="0"`,
+	"scriptstyle":       ` displaystyle="false" scriptlevel="1"`,
+	"scriptscriptstyle": ` displaystyle="false" scriptlevel="2"`,
+}
+
+// texEnvironment describes how an environment's table is fenced and laid
+// out.
+type texEnvironment struct {
+	open, close string
+	attrs       string
+}
+
+// texEnvironments maps the supported \begin{...} environments.
+var texEnvironments = map[string]texEnvironment{
+	"matrix":      {},
+	"smallmatrix": {attrs: ` displaystyle="false" scriptlevel="1"`},
+	"pmatrix":     {open: "(", close: ")"},
+	"bmatrix":     {open: "[", close: "]"},
+	"Bmatrix":     {open: "{", close: "}"},
+	"vmatrix":     {open: "|", close: "|"},
+	"Vmatrix":     {open: "‖", close: "‖"},
+	"array":       {},
+	"cases":       {open: "{", attrs: ` columnalign="left left"`},
+	"aligned":     {attrs: ` displaystyle="true" columnalign="right left right left" columnspacing="0em 2em 0em"`},
+	"align":       {attrs: ` displaystyle="true" columnalign="right left right left" columnspacing="0em 2em 0em"`},
+	"align*":      {attrs: ` displaystyle="true" columnalign="right left right left" columnspacing="0em 2em 0em"`},
+	"split":       {attrs: ` displaystyle="true" columnalign="right left" columnspacing="0em"`},
+	"gathered":    {attrs: ` displaystyle="true"`},
+	"gather":      {attrs: ` displaystyle="true"`},
+	"gather*":     {attrs: ` displaystyle="true"`},
+}
@@ -4,7 +4,14 @@
 
 package parser
 
-import "log/slog"
+import (
+	"log/slog"
+	"maps"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/cache"
+	"github.com/harrydayexe/GoBlog/v2/pkg/images"
+	"github.com/harrydayexe/GoBlog/v2/pkg/models"
+)
 
 // Option is a function which can update the parser config
 type Option func(*Config)
@@ -27,14 +34,47 @@ func WithLogger(l *slog.Logger) Option {
 //
 // When enabled, the parser outputs CSS class names (e.g. .chroma, .k, .s)
 // rather than inline styles. A matching chroma stylesheet must be included
-// in your page — see the package documentation for how to generate one with
-// chromahtml.WriteCSS.
+// in your page — see Parser.HighlightCSS and WithHighlightStyle.
 func WithCodeHighlighting(enable bool) Option {
 	return func(c *Config) {
 		c.EnableCodeHighlighting = enable
 	}
 }
 
+// WithCopyButton puts a copy-to-clipboard button in every highlighted code
+// block. Code blocks are wrapped in a <figure class="code-block"> holding a
+// <button class="code-copy">; the button does nothing until a script handles
+// its clicks, as the default templates do.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithCopyButton())
+func WithCopyButton() Option {
+	return func(c *Config) {
+		c.EnableCopyButton = true
+	}
+}
+
+// WithHighlightStyle sets the chroma styles of the stylesheet returned by
+// Parser.HighlightCSS: light is used by default and dark when the reader
+// prefers a dark colour scheme. An empty dark style keeps the light one in
+// dark mode too. The defaults are DefaultHighlightStyle and
+// DefaultHighlightDarkStyle.
+//
+// The styles only affect the stylesheet, since highlighted code is marked up
+// with class names. Use ParseHighlightStyle to validate a name taken from user
+// input; Parser.HighlightCSS fails for one that is not registered.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithHighlightStyle("solarized-light", "solarized-dark"))
+func WithHighlightStyle(light, dark string) Option {
+	return func(c *Config) {
+		c.HighlightStyle = light
+		c.HighlightDarkStyle = dark
+	}
+}
+
 // WithFootnote enables the use of PHP Markdown Extra Footnotes.
 //
 // Footnotes allow you to add references and notes without cluttering the main text.
@@ -47,3 +87,370 @@ func WithFootnote() Option {
 		c.EnableFootnote = true
 	}
 }
+
+// WithWikiLinks enables Obsidian-style wiki links.
+//
+// [[Other Post]] links to the post with that title, slug or file name,
+// ignoring case, and [[other-post|label]] shows label as the link text.
+// ![[diagram.png]] embeds an image from the post's page bundle. Links resolve
+// to the target's URL under the configured blog root.
+//
+// Links are resolved by ParseDirectory once every post has been parsed; a
+// link that matches nothing is reported as a FileError wrapping a
+// BrokenLinkError.
+func WithWikiLinks() Option {
+	return func(c *Config) {
+		c.EnableWikiLinks = true
+	}
+}
+
+// WithMath enables TeX math, rendered to MathML when the post is parsed so
+// that pages need no JavaScript to display it.
+//
+// $E = mc^2$ is inline math and $$\sum_{i=1}^n i$$ is display math. Display
+// math may also span several lines, opened by $$ at the start of a line and
+// closed by $$ at the end of one. A $ followed by a space, or a closing $
+// followed by a digit, is left as text so prices are not mistaken for math.
+//
+// An expression that cannot be parsed fails the whole file with a MathError
+// giving its line number.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithMath())
+func WithMath() Option {
+	return func(c *Config) {
+		c.EnableMath = true
+	}
+}
+
+// WithShortcodes enables Hugo-style shortcodes such as
+// {{< youtube dQw4w9WgXcQ >}} and {{< details "More" >}}...{{< /details >}}.
+// The built-in youtube, figure and details shortcodes are always available;
+// handlers adds to them, and replaces any of the same name. Use
+// TemplateShortcode to write a handler as an html/template.
+//
+// A shortcode that has no handler, or whose handler fails, fails the post
+// with a ShortcodeError giving its line. Shortcodes in code are left as
+// written.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithShortcodes(map[string]parser.ShortcodeFunc{
+//	    "kbd": func(sc parser.Shortcode) (template.HTML, error) {
+//	        return template.HTML("<kbd>" + template.HTMLEscapeString(sc.Arg(0)) + "</kbd>"), nil
+//	    },
+//	}))
+func WithShortcodes(handlers map[string]ShortcodeFunc) Option {
+	return func(c *Config) {
+		c.EnableShortcodes = true
+		if c.Shortcodes == nil {
+			c.Shortcodes = make(map[string]ShortcodeFunc, len(handlers))
+		}
+		maps.Copy(c.Shortcodes, handlers)
+	}
+}
+
+// WithRenderHooks replaces how links, images, headings and code blocks are
+// rendered with the hooks that are set in hooks; the others are rendered as
+// usual. Each hook is given the node's destination, title, text, level or
+// language, and the HTML it would otherwise have been rendered as. Use
+// TemplateRenderHook to write a hook as an html/template.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithRenderHooks(parser.RenderHooks{
+//	    Image: parser.TemplateRenderHook[parser.ImageData](template.Must(template.New("image").Parse(
+//	        `<figure>{{.HTML}}{{with .Title}}<figcaption>{{.}}</figcaption>{{end}}</figure>`))),
+//	}))
+func WithRenderHooks(hooks RenderHooks) Option {
+	return func(c *Config) {
+		c.RenderHooks = hooks
+	}
+}
+
+// WithSchema checks the front matter of every post against schema, in
+// addition to the title, date and description every post needs. A post
+// breaking any rule fails with a SchemaError for each, so ParseDirectory
+// reports all of them in ParseErrors. Use ParseSchema to read a schema from
+// YAML.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithSchema(parser.Schema{Fields: map[string]parser.SchemaField{
+//	    "author": {Required: true, Type: parser.FieldString},
+//	    "tags":   {Type: parser.FieldList, Enum: []string{"go", "web"}},
+//	}}))
+func WithSchema(schema Schema) Option {
+	return func(c *Config) {
+		c.Schema = schema
+	}
+}
+
+// WithUnsafeHTML keeps raw HTML written in posts, such as <details> or
+// <kbd>, which is otherwise replaced with an HTML comment. The rendered HTML
+// of every post, shortcode and render hook output included, is then passed
+// through a sanitiser that keeps only the elements and attributes allowed by
+// DefaultHTMLPolicy, or by the policy set with WithHTMLPolicy, and always
+// removes scripts, event handlers and javascript: URLs.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithUnsafeHTML())
+func WithUnsafeHTML() Option {
+	return func(c *Config) {
+		c.EnableUnsafeHTML = true
+	}
+}
+
+// WithHTMLPolicy sets the allowlist used by the sanitiser enabled by
+// WithUnsafeHTML. Start from DefaultHTMLPolicy to extend it rather than
+// replace it.
+//
+// Example usage:
+//
+//	policy := parser.DefaultHTMLPolicy()
+//	policy.Allow("dl")
+//	p := parser.New(parser.WithUnsafeHTML(), parser.WithHTMLPolicy(policy))
+func WithHTMLPolicy(policy HTMLPolicy) Option {
+	return func(c *Config) {
+		c.HTMLPolicy = policy
+	}
+}
+
+// WithHeadingAnchors adds a permalink to each heading, so readers can copy a
+// link to a section. The link points at the heading's auto-generated id and
+// carries an aria-label naming the heading, so screen readers announce more
+// than its symbol. Zero fields of anchor take their defaults: a "#" symbol
+// after the heading text, on headings of level 2 and deeper, labelled
+// "Permalink to <heading>".
+//
+// The anchor is added when the heading is rendered, so it does not change
+// heading IDs, table of contents entries or summaries. Heading IDs are unique
+// within a post: repeated headings are numbered, as in "setup" and "setup-1",
+// and footnote IDs such as "fn:1" contain a colon, which a heading ID never
+// does, so they cannot clash.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithHeadingAnchors(parser.HeadingAnchor{
+//	    Symbol:   "¶",
+//	    Position: parser.AnchorBefore,
+//	}))
+func WithHeadingAnchors(anchor HeadingAnchor) Option {
+	return func(c *Config) {
+		c.EnableHeadingAnchors = true
+		c.HeadingAnchor = anchor
+	}
+}
+
+// WithDialect selects a markdown dialect: DialectCommonMark (the default),
+// DialectGFM for GitHub Flavored Markdown tables, strikethrough, task lists
+// and autolinks, or DialectExtended, which adds definition lists,
+// typographic punctuation and :emoji: shortcodes.
+//
+// Every dialect keeps hard line wraps and XHTML output. Individual extensions
+// can be switched on or off on top of the dialect with WithExtension,
+// whichever order the options are given in.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithDialect(parser.DialectGFM))
+func WithDialect(d Dialect) Option {
+	return func(c *Config) {
+		c.Dialect = d
+	}
+}
+
+// WithExtension switches a single markdown extension on or off, overriding
+// the dialect.
+//
+// Example usage:
+//
+//	// GFM without bare URLs turning into links, and with soft line breaks
+//	p := parser.New(
+//	    parser.WithDialect(parser.DialectGFM),
+//	    parser.WithExtension(parser.ExtensionLinkify, false),
+//	    parser.WithExtension(parser.ExtensionHardWraps, false),
+//	)
+func WithExtension(ext Extension, enable bool) Option {
+	return func(c *Config) {
+		if c.Extensions == nil {
+			c.Extensions = make(map[Extension]bool)
+		}
+		c.Extensions[ext] = enable
+	}
+}
+
+// WithHeadingShift moves every heading down by n levels, so # renders as
+// <h2> when n is 1. Levels are clamped to 1–6, and a negative n moves
+// headings up. Tables of contents see the shifted levels.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithHeadingShift(1))
+func WithHeadingShift(n int) Option {
+	return func(c *Config) {
+		c.HeadingShift = n
+	}
+}
+
+// WithTOCLevels sets the range of heading levels included in each post's table
+// of contents. Headings shallower than min or deeper than max are left out.
+// The default range is 2 to 3.
+//
+// Levels are clamped to 1–6, and max is raised to min if it is smaller.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithTOCLevels(2, 4))
+func WithTOCLevels(min, max int) Option {
+	return func(c *Config) {
+		c.TOCMinLevel = clampHeadingLevel(min)
+		c.TOCMaxLevel = clampHeadingLevel(max)
+		if c.TOCMaxLevel < c.TOCMinLevel {
+			c.TOCMaxLevel = c.TOCMinLevel
+		}
+	}
+}
+
+// WithSummaryWords sets the approximate length, in words, of the automatic
+// excerpt used as Post.Summary when a post has no <!--more--> marker. The
+// excerpt is cut at the end of the block in which the count is reached, so it
+// may run slightly longer. The default is 70.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithSummaryWords(40))
+func WithSummaryWords(n int) Option {
+	return func(c *Config) {
+		c.SummaryWords = max(n, 1)
+	}
+}
+
+// WithBlogRoot sets the root path the blog is served from, such as "/blog/".
+// Links to page bundle assets are rewritten to live under this root. The
+// default is "/".
+//
+// The generator sets this from config.WithBlogRoot, so it only needs to be
+// supplied when using the parser on its own.
+func WithBlogRoot(root string) Option {
+	return func(c *Config) {
+		c.BlogRoot = root
+	}
+}
+
+// WithExternalLinks marks up links that leave the site. A link is external
+// when it has an http or https URL whose host is neither links.Site nor one
+// of links.Trusted, or a subdomain of either. External links get the rel
+// values in links.Rel, target="_blank" with rel="noopener" when links.NewTab
+// is set, and links.Class as their class. Autolinks are covered too; links
+// written as raw HTML are left alone.
+//
+// Use ParseLinkRel to validate rel values taken from user input.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithExternalLinks(parser.ExternalLinks{
+//	    Site:    "example.com",
+//	    Rel:     []string{"nofollow"},
+//	    NewTab:  true,
+//	    Class:   "external",
+//	    Trusted: []string{"github.com"},
+//	}))
+func WithExternalLinks(links ExternalLinks) Option {
+	return func(c *Config) {
+		c.EnableExternalLinks = true
+		c.ExternalLinks = links
+	}
+}
+
+// WithPublished sets the function that reports whether a post will be
+// published. Posts it rejects, such as drafts or scheduled posts, are still
+// parsed and returned by ParseDirectory, but published posts cannot link to
+// them with wiki links.
+//
+// The generator sets this from config.WithDrafts and its clock, so it only
+// needs to be supplied when using the parser on its own.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithPublished(func(post *models.Post) bool {
+//	    return !post.Draft
+//	}))
+func WithPublished(fn func(post *models.Post) bool) Option {
+	return func(c *Config) {
+		c.Published = fn
+	}
+}
+
+// WithExcluded sets the function that reports whether a post is left out of
+// the blog altogether. Posts it reports, such as drafts, are still parsed
+// and returned by ParseDirectory, but do not claim their slug.
+//
+// The generator sets this from config.WithDrafts, so it only needs to be
+// supplied when using the parser on its own.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithExcluded(func(post *models.Post) bool {
+//	    return post.Draft
+//	}))
+func WithExcluded(fn func(post *models.Post) bool) Option {
+	return func(c *Config) {
+		c.Excluded = fn
+	}
+}
+
+// WithImages makes markdown images that name a JPEG or PNG file in the
+// post's page bundle responsive. Each gets its intrinsic width and height,
+// loading="lazy" and decoding="async", and, when proc makes copies narrower
+// than the image, a srcset listing them and proc's sizes attribute. The copies
+// are named as by images.VariantName; publishing them is up to the caller.
+// An image proc cannot process, such as a corrupt file, is left as it is.
+//
+// The generator sets this from config.WithImages, and publishes the copies,
+// so it only needs to be supplied when using the parser on its own.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithImages(images.New(images.WithWidths(480, 960))))
+func WithImages(proc *images.Processor) Option {
+	return func(c *Config) {
+		c.Images = proc
+	}
+}
+
+// WithJobs sets how many files ParseDirectory parses and renders at the same
+// time. The default, zero, uses runtime.GOMAXPROCS(0) workers. The posts and
+// errors returned do not depend on n.
+//
+// Example usage:
+//
+//	p := parser.New(parser.WithJobs(4))
+func WithJobs(n int) Option {
+	return func(c *Config) {
+		c.Jobs = n
+	}
+}
+
+// WithCache makes ParseDirectory keep parsed posts in store, so that files
+// which have not changed since an earlier call skip markdown conversion. A
+// cached post is reused only when the file's content, its bundle's file
+// names and the parser configuration all match.
+//
+// The generator sets this from config.WithCache, so it only needs to be
+// supplied when using the parser on its own.
+//
+// Example usage:
+//
+//	store, err := cache.Open(".goblog-cache")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	p := parser.New(parser.WithCache(store))
+func WithCache(store *cache.Store) Option {
+	return func(c *Config) {
+		c.Cache = store
+	}
+}
@@ -7,39 +7,61 @@ package parser
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"html/template"
 	"io/fs"
 	"log/slog"
-	"path/filepath"
+	"maps"
+	"slices"
 	"strings"
 
-	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/harrydayexe/GoBlog/v2/internal/workpool"
 	goblogconfig "github.com/harrydayexe/GoBlog/v2/pkg/config"
 	"github.com/harrydayexe/GoBlog/v2/pkg/models"
 	"github.com/yuin/goldmark"
-	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
 	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
 	"go.abhg.dev/goldmark/frontmatter"
 )
 
 // Parser reads markdown files and converts them to Post objects.
 // A Parser is safe for concurrent use after creation.
 type Parser struct {
-	md     goldmark.Markdown
-	config *Config
+	md          goldmark.Markdown
+	config      *Config
+	fingerprint string                   // configFingerprint of config, for cache keys
+	shortcodes  map[string]ShortcodeFunc // Built-in and configured shortcodes, when enabled
 	goblogconfig.Logger
 }
 
 // New creates a new Parser with the specified options.
 // The parser is configured with:
-// - YAML frontmatter parsing
+// - YAML, TOML and JSON frontmatter parsing
 // - Syntax highlighting for code blocks (enabled by default, use WithCodeHighlighting to disable)
+// - Code block titles, line numbers and emphasised lines from ```go title="main.go" {3-5} linenos info strings
+// - Optional copy buttons on code blocks (disabled by default, use WithCopyButton to enable)
+// - Light and dark highlight styles for HighlightCSS (github and github-dark by default, use WithHighlightStyle to change)
 // - Optional footnote support (disabled by default, use WithFootnote to enable)
 // - Auto-generated heading IDs
-// - HTML sanitization (unsafe HTML disabled by default)
+// - Optional heading permalink anchors (disabled by default, use WithHeadingAnchors to enable)
+// - Optional rel, target and class attributes on external links (disabled by default, use WithExternalLinks to enable)
+// - Table of contents extraction (levels 2–3 by default, use WithTOCLevels to change)
+// - Optional responsive bundle images with srcset, sizes and intrinsic sizes (disabled by default, use WithImages to enable)
+// - Optional wiki links and embeds (disabled by default, use WithWikiLinks to enable)
+// - Optional TeX math rendered to MathML (disabled by default, use WithMath to enable)
+// - Optional {{< shortcode >}} expansion (disabled by default, use WithShortcodes to enable)
+// - Optional render hooks replacing the HTML of links, images, headings and code blocks (use WithRenderHooks to set)
+// - A markdown dialect (CommonMark by default, use WithDialect and WithExtension to change)
+// - GitHub-style > [!NOTE] callouts, hard line wraps and XHTML output (use WithExtension to disable)
+// - Post summaries from a <!--more--> marker or an automatic excerpt (use WithSummaryWords to size it)
+// - Optional raw HTML, sanitised with an allowlist (disabled by default, use WithUnsafeHTML to enable)
+// - Optional front matter validation against a schema (use WithSchema to set)
 func New(opts ...Option) *Parser {
 	config := &Config{
 		EnableCodeHighlighting: true,
@@ -53,37 +75,102 @@ func New(opts ...Option) *Parser {
 	return NewWithConfig(config)
 }
 
+// NewWithConfig creates a new Parser from an explicit Config. Zero values in
+// config fall back to the same defaults as New, except for the boolean feature
+// switches, which are taken as given.
 func NewWithConfig(config *Config) *Parser {
+	cfg := *config
+	if cfg.TOCMinLevel == 0 {
+		cfg.TOCMinLevel = defaultTOCMinLevel
+	}
+	if cfg.TOCMaxLevel == 0 {
+		cfg.TOCMaxLevel = max(defaultTOCMaxLevel, cfg.TOCMinLevel)
+	}
+	if cfg.SummaryWords == 0 {
+		cfg.SummaryWords = defaultSummaryWords
+	}
+	if cfg.HighlightStyle == "" && cfg.HighlightDarkStyle == "" {
+		cfg.HighlightStyle = DefaultHighlightStyle
+		cfg.HighlightDarkStyle = DefaultHighlightDarkStyle
+	}
+	cfg.BlogRoot = "/" + strings.Trim(cfg.BlogRoot, "/") + "/"
+	if cfg.BlogRoot == "//" {
+		cfg.BlogRoot = "/"
+	}
+
+	enabled := enabledExtensions(&cfg)
 	var extensions []goldmark.Extender = []goldmark.Extender{
 		&frontmatter.Extender{},
 	}
+	extensions = append(extensions, syntaxExtensions(enabled)...)
 	if config.EnableFootnote {
-		extensions = append(extensions, extension.Footnote)
+		extensions = append(extensions, extension.NewFootnote(extension.WithFootnoteIDPrefixFunction(footnoteIDPrefix)))
+	}
+	if config.EnableWikiLinks {
+		extensions = append(extensions, wikiLinks{})
+	}
+	if config.EnableMath {
+		extensions = append(extensions, texMath{})
+	}
+	var handlers map[string]ShortcodeFunc
+	if cfg.EnableShortcodes {
+		handlers = BuiltinShortcodes()
+		maps.Copy(handlers, cfg.Shortcodes)
+		extensions = append(extensions, shortcodes{})
+	}
+	if cfg.EnableHeadingAnchors {
+		cfg.HeadingAnchor = cfg.HeadingAnchor.withDefaults()
+		extensions = append(extensions, headingAnchors{anchor: cfg.HeadingAnchor})
+	}
+	if cfg.EnableExternalLinks {
+		extensions = append(extensions, externalLinks{links: cfg.ExternalLinks})
 	}
 	if config.EnableCodeHighlighting {
-		extensions = append(extensions, highlighting.NewHighlighting(
-			highlighting.WithFormatOptions(
-				html.WithClasses(true),
-			),
+		extensions = append(extensions, codeBlocks{copyButton: cfg.EnableCopyButton})
+	}
+
+	parserOptions := []parser.Option{
+		parser.WithAutoHeadingID(),
+	}
+	if cfg.HeadingShift != 0 {
+		parserOptions = append(parserOptions, parser.WithASTTransformers(
+			util.Prioritized(headingShift(cfg.HeadingShift), 100),
 		))
 	}
 
+	var rendererOptions []renderer.Option
+	if enabled[ExtensionHardWraps] {
+		rendererOptions = append(rendererOptions, goldmarkhtml.WithHardWraps())
+	}
+	if enabled[ExtensionXHTML] {
+		rendererOptions = append(rendererOptions, goldmarkhtml.WithXHTML())
+	}
+	if cfg.EnableUnsafeHTML {
+		rendererOptions = append(rendererOptions, goldmarkhtml.WithUnsafe())
+	}
+
 	// Configure goldmark with extensions
-	md := goldmark.New(
-		goldmark.WithExtensions(
-			extensions...,
-		),
-		goldmark.WithParserOptions(
-			parser.WithAutoHeadingID(),
-		),
-		goldmark.WithRendererOptions(
-			goldmarkhtml.WithHardWraps(),
-			goldmarkhtml.WithXHTML(),
-		),
-	)
+	newMarkdown := func(extensions ...goldmark.Extender) goldmark.Markdown {
+		return goldmark.New(
+			goldmark.WithExtensions(
+				extensions...,
+			),
+			goldmark.WithParserOptions(parserOptions...),
+			goldmark.WithRendererOptions(rendererOptions...),
+		)
+	}
+	md := newMarkdown(extensions...)
+	if cfg.RenderHooks.enabled() {
+		// Hooks are given each node as rendered without them, by md
+		hooks := renderHooks{hooks: cfg.RenderHooks, plain: md}
+		md = newMarkdown(append(slices.Clone(extensions), hooks)...)
+	}
 
 	p := &Parser{
-		md: md,
+		md:          md,
+		config:      &cfg,
+		fingerprint: configFingerprint(cfg),
+		shortcodes:  handlers,
 	}
 
 	if config.Logger != nil {
@@ -99,61 +186,270 @@ func NewWithConfig(config *Config) *Parser {
 // It extracts frontmatter metadata, validates required fields, renders the
 // markdown content to HTML, and returns a fully populated Post.
 //
-// The file must contain YAML frontmatter with at minimum: title, date, and
-// description. The markdown body is rendered to HTML with syntax highlighting
-// and footnote support.
+// The file must open with frontmatter containing at minimum: title, date, and
+// description. The frontmatter may be YAML between --- lines, TOML between +++
+// lines, or a JSON object; all three decode into the same Post fields, and
+// decoding errors name the format that was detected. The markdown body is
+// rendered to HTML with syntax highlighting and footnote support.
+//
+// Unless the front matter sets toc: false, the post's headings within the
+// configured level range are collected into Post.TOC.
+//
+// Post.Summary holds the rendered content before a <!--more--> marker, or an
+// automatic excerpt of whole blocks roughly Config.SummaryWords long when the
+// post has no marker.
+//
+// A file named index.md inside a subdirectory is a page bundle: the other
+// non-markdown files in that directory are listed in Post.Assets, and links or
+// images that refer to them relatively are rewritten to
+// <BlogRoot>posts/<slug>/<asset>, where the generator publishes them.
+//
+// When wiki links are enabled, ParseFile can only resolve links to the post
+// itself and embeds of its own bundle assets, since it does not see any other
+// post. Other wiki links are rendered as plain text without an error; use
+// ParseDirectory to resolve them and report the ones that are broken.
 //
 // Returns an error if the file cannot be read, frontmatter is invalid,
 // required fields are missing, or markdown rendering fails.
 func (p *Parser) ParseFile(ctx context.Context, fsys fs.FS, path string) (*models.Post, error) {
-	p.Logger.Logger.InfoContext(ctx, fmt.Sprintf("Parsing file %s...", path))
+	d, err := p.parseDocument(ctx, fsys, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.config.EnableWikiLinks {
+		resolveWikiLinks(d.root, d.post, newWikiLinkIndex([]*models.Post{d.post}), p.config.BlogRoot)
+	}
+
+	if err := p.render(d); err != nil {
+		return nil, err
+	}
+	return d.post, nil
+}
+
+// document is a parsed markdown file whose front matter has been decoded but
+// whose body has not yet been rendered.
+type document struct {
+	post   *models.Post
+	root   ast.Node
+	source []byte
+
+	key       string // Cache key of the file, empty when not caching
+	cached    bool   // post was read from the cache and is already rendered
+	wikiIndex string // wikiIndexKey the cached post's links were resolved against
+}
+
+// parseDocument reads the file at path, parses its markdown and front matter,
+// validates the post and assigns its slug and bundle assets.
+func (p *Parser) parseDocument(ctx context.Context, fsys fs.FS, path string) (*document, error) {
 	// Read file contents
 	content, err := fs.ReadFile(fsys, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	return p.parseContent(ctx, fsys, path, content)
+}
+
+// parseContent parses content, read from the file at path, as parseDocument
+// does.
+func (p *Parser) parseContent(ctx context.Context, fsys fs.FS, path string, content []byte) (*document, error) {
+	p.Logger.Logger.InfoContext(ctx, fmt.Sprintf("Parsing file %s...", path))
 
 	// Create parser context
 	pctx := parser.NewContext()
-
-	// Parse markdown (this also extracts frontmatter via the extension)
-	var htmlBuf bytes.Buffer
-	if err := p.md.Convert(content, &htmlBuf, parser.WithContext(pctx)); err != nil {
-		return nil, fmt.Errorf("failed to render markdown: %w", err)
+	var post models.Post
+	var fields map[string]any // Every front matter key, for the schema
+	source := content
+
+	format := detectFrontMatter(content)
+	if format == formatJSON {
+		// goldmark has no JSON frontmatter support, so split the object off
+		// and parse only the body as markdown
+		raw, body, err := splitJSONFrontMatter(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s frontmatter: %w", format, err)
+		}
+		if fields, err = decodeJSONFrontMatter(raw, &post); err != nil {
+			return nil, fmt.Errorf("failed to parse %s frontmatter: %w", format, err)
+		}
+		source = body
 	}
 
+	// Parse markdown (this also extracts YAML and TOML frontmatter via the extension)
+	doc := p.md.Parser().Parse(text.NewReader(source), parser.WithContext(pctx))
+
 	// Extract frontmatter from context
-	var post models.Post
-	fmData := frontmatter.Get(pctx)
-	if fmData == nil {
-		return nil, fmt.Errorf("no frontmatter found in file")
-	}
+	if format != formatJSON {
+		fmData := frontmatter.Get(pctx)
+		if fmData == nil {
+			return nil, fmt.Errorf("no frontmatter found in file")
+		}
 
-	if err := fmData.Decode(&post); err != nil {
-		return nil, fmt.Errorf("failed to parse frontmatter: %w", err)
+		var err error
+		if fields, err = decodeFrontMatter(format, fmData.Decode, &post); err != nil {
+			return nil, fmt.Errorf("failed to parse %s frontmatter: %w", format, err)
+		}
 	}
 
 	// Set source path before validation so error messages include it
 	post.SourcePath = path
+	post.BundleDir = bundleDir(path)
 
-	// Validate required fields
+	// Validate required fields, reporting any schema violations alongside
+	errs := p.config.Schema.validate(fields)
 	if err := post.Validate(); err != nil {
-		return nil, err
+		errs = append([]error{err}, errs...)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
 
 	// Store raw markdown content (without frontmatter)
 	// We need to extract just the body content
 	post.RawContent = string(content)
 
+	// Generate slug from title or filename
+	post.GenerateSlug()
+
+	if post.IsBundle() {
+		assets, err := bundleAssets(fsys, post.BundleDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list bundle assets: %w", err)
+		}
+		post.Assets = assets
+	}
+
+	firstLine := 1 + bytes.Count(content[:len(content)-len(source)], []byte("\n"))
+	doc, source, err := p.transform(fsys, doc, source, firstLine, &post, newDocumentIDs())
+	if err != nil {
+		return nil, err
+	}
+
+	return &document{post: &post, root: doc, source: source}, nil
+}
+
+// transform expands the shortcodes in doc, parsed from source, converts its
+// math and points its links at bundle assets to where they will be
+// published. firstLine is the line of the file on which source begins, for
+// errors. It returns the document and source to render, which differ from
+// those given when shortcodes were expanded.
+func (p *Parser) transform(fsys fs.FS, doc ast.Node, source []byte, firstLine int, post *models.Post, ids *documentIDs) (ast.Node, []byte, error) {
+	// Expand shortcodes first, since their inner markdown may hold math
+	if p.config.EnableShortcodes {
+		expanded, rendered, err := p.expandShortcodes(fsys, doc, source, firstLine, post, ids)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rendered) > 0 || !bytes.Equal(expanded, source) {
+			source = expanded
+			doc = p.reparseShortcodes(source, rendered, ids)
+		}
+	}
+
+	// Convert math now so that a bad expression fails this file
+	if p.config.EnableMath {
+		if err := convertMath(doc, source, firstLine); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Point links at bundle assets to where they will be published
+	if post.IsBundle() {
+		base := p.config.BlogRoot + "posts/" + post.Slug + "/"
+		if p.config.Images != nil {
+			if err := responsiveImages(doc, fsys, post.BundleDir, post.Assets, base, p.config.Images); err != nil {
+				return nil, nil, err
+			}
+		}
+		rewriteBundleLinks(doc, post.Assets, base)
+	}
+	return doc, source, nil
+}
+
+// loadDocument returns the post cached for the file at path if there is one,
+// and otherwise parses the file.
+func (p *Parser) loadDocument(ctx context.Context, fsys fs.FS, path string) (*document, error) {
+	if p.config.Cache == nil {
+		return p.parseDocument(ctx, fsys, path)
+	}
+
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	key, err := p.postKey(fsys, path, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bundle assets: %w", err)
+	}
+	if cp, ok := p.loadPost(key); ok {
+		p.Logger.Logger.DebugContext(ctx, fmt.Sprintf("Using cached %s", path))
+		return &document{post: &cp.Post, key: key, cached: true, wikiIndex: cp.WikiIndex}, nil
+	}
+
+	d, err := p.parseContent(ctx, fsys, path, content)
+	if err != nil {
+		return nil, err
+	}
+	d.key = key
+	return d, nil
+}
+
+// render renders the document body into the post's HTML content and derives
+// its table of contents and summary.
+func (p *Parser) render(d *document) error {
+	post := d.post
+
+	var htmlBuf bytes.Buffer
+	if err := p.md.Renderer().Render(&htmlBuf, d.source, d.root); err != nil {
+		return fmt.Errorf("failed to render markdown: %w", err)
+	}
+
 	// Store rendered HTML
 	// WARNING: Might not be safe concurrently due to buffer overwriting?
-	post.Content = htmlBuf.Bytes()
+	post.Content = p.sanitize(htmlBuf.Bytes())
 	post.HTMLContent = template.HTML(post.Content)
 
-	// Generate slug from title or filename
-	post.GenerateSlug()
+	// Extract the table of contents from the heading structure
+	if post.TOCEnabled() {
+		post.TOC = buildTOC(d.root, d.source, p.config.TOCMinLevel, p.config.TOCMaxLevel)
+	}
+
+	// Render the summary from the leading blocks of the document
+	blocks, restore := summaryBlocks(d.root, d.source, p.config.SummaryWords)
+	summary, err := renderSummary(p.md.Renderer(), d.source, blocks)
+	restore()
+	if err != nil {
+		return fmt.Errorf("failed to render summary: %w", err)
+	}
+	post.Summary = template.HTML(p.sanitize([]byte(summary)))
+
+	return nil
+}
+
+// excluded reports whether post is left out of the blog, according to
+// Config.Excluded.
+func (p *Parser) excluded(post *models.Post) bool {
+	return p.config.Excluded != nil && p.config.Excluded(post)
+}
 
-	return &post, nil
+// published reports whether post will be published, according to
+// Config.Published.
+func (p *Parser) published(post *models.Post) bool {
+	return p.config.Published == nil || p.config.Published(post)
+}
+
+// sanitize returns html passed through the configured HTMLPolicy when raw
+// HTML is enabled, or as it is otherwise, since goldmark has already left
+// out raw HTML and dangerous URLs.
+func (p *Parser) sanitize(html []byte) []byte {
+	if !p.config.EnableUnsafeHTML {
+		return html
+	}
+	policy := p.config.HTMLPolicy
+	if policy.Elements == nil {
+		policy = DefaultHTMLPolicy()
+	}
+	return policy.Sanitize(html)
 }
 
 // ParseDirectory walks the filesystem and parses all .md files found.
@@ -167,52 +463,196 @@ func (p *Parser) ParseFile(ctx context.Context, fsys fs.FS, path string) (*model
 //
 // Only files with .md or .markdown extensions are processed. Other files
 // and directories are silently skipped.
+//
+// A subdirectory containing index.md is treated as a page bundle: only its
+// index.md is parsed as a post, its other non-markdown files become the
+// post's assets, and any other markdown files inside it are ignored.
+//
+// Every post must resolve to a unique slug. When two files share a slug, the
+// file visited first (in lexical path order) is kept and the other is
+// reported as a FileError wrapping a SlugCollisionError that names both
+// files. Posts that Config.Excluded reports, such as drafts, are returned
+// without claiming their slug.
+//
+// When wiki links are enabled, every file is parsed before any is rendered,
+// so that [[...]] links can be resolved against the whole set of posts. A
+// link matches a post by title, slug or file name, ignoring case; an embed
+// matches a file in the post's own page bundle. A published post can only
+// link to other published posts. Each link that does not resolve is reported
+// as a FileError wrapping a BrokenLinkError, and the post containing it is
+// left out.
+//
+// Files are parsed and rendered by up to Config.Jobs workers at once. The
+// posts and errors returned are the same whatever the number of workers. If
+// ctx is canceled, ParseDirectory stops starting new files and returns
+// ctx.Err(), such as context.Canceled, with no posts.
+//
+// With Config.Cache set, each post is stored in the cache once rendered, and
+// a later call reuses it without converting the markdown again as long as the
+// file, its bundle's file names and the parser configuration are unchanged.
+// Files that fail are never cached.
 func (p *Parser) ParseDirectory(ctx context.Context, fsys fs.FS) (models.PostList, error) {
 	p.Logger.Logger.InfoContext(ctx, "Parsing posts")
-	var posts models.PostList
 	var parseErrors ParseErrors
 
-	// Walk the filesystem and collect all .md files
+	// Walk the filesystem and collect all .md files, along with any paths
+	// that could not be read, in lexical order
+	type entry struct {
+		path string
+		err  error
+	}
+	var entries []entry
 	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		if err != nil {
 			// Error accessing path - collect but continue
-			parseErrors.Errors = append(parseErrors.Errors, FileError{
-				Path: path,
-				Err:  err,
-			})
+			entries = append(entries, entry{path: path, err: err})
 			return nil
 		}
 
-		// Skip directories
+		// Parse page bundles as a single post, then skip their contents
 		if d.IsDir() {
-			return nil
+			if !isBundleDir(fsys, path) {
+				return nil
+			}
+			entries = append(entries, entry{path: bundleIndexPath(path)})
+			return fs.SkipDir
 		}
 
 		// Only process markdown files
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext != ".md" && ext != ".markdown" {
+		if !isMarkdown(path) {
 			return nil
 		}
 
-		// Parse the file
-		post, err := p.ParseFile(ctx, fsys, path)
-		if err != nil {
+		entries = append(entries, entry{path: path})
+		return nil
+	})
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	// If WalkDir itself failed, return that error
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	// Parse every file, several at a time, reusing cached posts for files
+	// that have not changed
+	parsed := make([]*document, len(entries))
+	if err := workpool.Run(ctx, p.config.Jobs, len(entries), func(i int) error {
+		e := &entries[i]
+		if e.err == nil {
+			parsed[i], e.err = p.loadDocument(ctx, fsys, e.path)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// Collect the results in walk order, so that errors are reported, and
+	// slug collisions decided, the same way on every run
+	var docs []*document
+	slugOwners := make(map[string]string)
+	for i, e := range entries {
+		if e.err != nil {
 			// Parsing failed - collect error but continue
 			parseErrors.Errors = append(parseErrors.Errors, FileError{
-				Path: path,
-				Err:  err,
+				Path: e.path,
+				Err:  e.err,
 			})
-			return nil
+			continue
+		}
+		d := parsed[i]
+
+		// Reject posts whose slug is already taken. Excluded posts, such as
+		// drafts, claim no slug, but scheduled posts do.
+		if !p.excluded(d.post) {
+			if existing, taken := slugOwners[d.post.Slug]; taken {
+				parseErrors.Errors = append(parseErrors.Errors, FileError{
+					Path: e.path,
+					Err:  SlugCollisionError{Slug: d.post.Slug, ExistingPath: existing},
+				})
+				continue
+			}
+			slugOwners[d.post.Slug] = e.path
 		}
 
 		// Successfully parsed - add to collection
-		posts = append(posts, post)
+		docs = append(docs, d)
+	}
+
+	// Resolve wiki links now that every post is known. Published posts may
+	// only link to other published posts, while posts that will not be
+	// published may link to any post.
+	var index, draftIndex wikiLinkIndex
+	if p.config.EnableWikiLinks {
+		var all, published []*models.Post
+		for _, d := range docs {
+			all = append(all, d.post)
+			if p.published(d.post) {
+				published = append(published, d.post)
+			}
+		}
+		index = newWikiLinkIndex(published)
+		draftIndex = newWikiLinkIndex(all)
+	}
+	indexKey, draftIndexKey := wikiIndexKey(index), wikiIndexKey(draftIndex)
+
+	// Render every document, several at a time
+	docErrs := make([][]error, len(docs))
+	if err := workpool.Run(ctx, p.config.Jobs, len(docs), func(i int) error {
+		d := docs[i]
+		index, indexKey := index, indexKey
+		if !p.published(d.post) {
+			index, indexKey = draftIndex, draftIndexKey
+		}
+		if d.cached {
+			if d.wikiIndex == indexKey {
+				return nil
+			}
+			// The post links to a different set of posts than when it was
+			// cached, so its links must be resolved again
+			fresh, err := p.parseDocument(ctx, fsys, d.post.SourcePath)
+			if err != nil {
+				docErrs[i] = []error{err}
+				return nil
+			}
+			fresh.key = d.key
+			docs[i], d = fresh, fresh
+		}
+		if index != nil {
+			broken := resolveWikiLinks(d.root, d.post, index, p.config.BlogRoot)
+			if len(broken) > 0 {
+				docErrs[i] = broken
+				return nil
+			}
+		}
+		if err := p.render(d); err != nil {
+			docErrs[i] = []error{err}
+			return nil
+		}
+		if d.key != "" {
+			p.storePost(d.key, d.post, indexKey)
+		}
 		return nil
-	})
+	}); err != nil {
+		return nil, err
+	}
 
-	// If WalkDir itself failed, return that error
-	if err != nil {
-		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	var posts models.PostList
+	for i, d := range docs {
+		if len(docErrs[i]) > 0 {
+			for _, err := range docErrs[i] {
+				parseErrors.Errors = append(parseErrors.Errors, FileError{
+					Path: d.post.SourcePath,
+					Err:  err,
+				})
+			}
+			continue
+		}
+		posts = append(posts, d.post)
 	}
 
 	// Sort posts by date (newest first)
@@ -7,12 +7,24 @@ package parser
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"html/template"
+	"image"
+	"image/png"
 	"log/slog"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/cache"
+	"github.com/harrydayexe/GoBlog/v2/pkg/images"
+	"github.com/harrydayexe/GoBlog/v2/pkg/models"
 )
 
 // TestNew_WithLogger verifies that a logger injected via parser.WithLogger
@@ -167,6 +179,131 @@ func TestParseFile_NoFrontmatter(t *testing.T) {
 	}
 }
 
+func TestParseFile_FrontmatterFormats(t *testing.T) {
+	t.Parallel()
+	p := New()
+	fsys := fstest.MapFS{
+		"yaml.md":        {Data: []byte("---\ntitle: Same Post\ndate: 2024-01-02\ndescription: d\ntags: [go, web]\ntoc: false\nseriesOrder: 2\nseries: Intro\n---\n# Body\n\nText.")},
+		"toml.md":        {Data: []byte("+++\ntitle = \"Same Post\"\ndate = 2024-01-02\ndescription = \"d\"\ntags = [\"go\", \"web\"]\ntoc = false\nseriesOrder = 2\nseries = \"Intro\"\n+++\n# Body\n\nText.")},
+		"json.md":        {Data: []byte("{\n  \"title\": \"Same Post\",\n  \"date\": \"2024-01-02\",\n  \"description\": \"d\",\n  \"tags\": [\"go\", \"web\"],\n  \"toc\": false,\n  \"seriesOrder\": 2,\n  \"series\": \"Intro\"\n}\n# Body\n\nText.")},
+		"quoted-yaml.md": {Data: []byte("---\ntitle: Same Post\ndate: \"2024-01-02\"\nlastEdited: \"2024-01-03\"\ndescription: d\ntags: [go, web]\ntoc: false\nseriesOrder: 2\nseries: Intro\n---\n# Body\n\nText.")},
+		"quoted-toml.md": {Data: []byte("+++\ntitle = \"Same Post\"\ndate = \"2024-01-02\"\nlastEdited = \"2024-01-03\"\ndescription = \"d\"\ntags = [\"go\", \"web\"]\ntoc = false\nseriesOrder = 2\nseries = \"Intro\"\n+++\n# Body\n\nText.")},
+	}
+
+	for _, name := range []string{"yaml.md", "toml.md", "json.md", "quoted-yaml.md", "quoted-toml.md"} {
+		post, err := p.ParseFile(context.Background(), fsys, name)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", name, err)
+		}
+		if post.Title != "Same Post" || post.Description != "d" || post.Series != "Intro" || post.SeriesOrder != 2 {
+			t.Errorf("%s: front matter not decoded: %+v", name, post)
+		}
+		if got := post.Date.Format(time.DateOnly); got != "2024-01-02" {
+			t.Errorf("%s: expected date 2024-01-02, got %s", name, got)
+		}
+		if strings.HasPrefix(name, "quoted") && post.LastEdited.Format(time.DateOnly) != "2024-01-03" {
+			t.Errorf("%s: expected lastEdited 2024-01-03, got %s", name, post.LastEdited)
+		}
+		if len(post.Tags) != 2 || post.Tags[1] != "web" {
+			t.Errorf("%s: expected tags [go web], got %v", name, post.Tags)
+		}
+		if post.ShowTOC == nil || *post.ShowTOC || post.TOC != nil {
+			t.Errorf("%s: expected toc: false to disable the table of contents", name)
+		}
+		if !strings.Contains(string(post.Content), "<h1") || strings.Contains(string(post.Content), "Same Post") {
+			t.Errorf("%s: expected only the body to be rendered, got: %s", name, post.Content)
+		}
+	}
+}
+
+// TestParseFile_BraceWithoutFrontmatter verifies that a file starting with a
+// brace that does not open a JSON object is read as having no front matter,
+// rather than failing as malformed JSON.
+func TestParseFile_BraceWithoutFrontmatter(t *testing.T) {
+	t.Parallel()
+	p := New(WithShortcodes(nil))
+	fsys := fstest.MapFS{
+		"shortcode.md": {Data: []byte("{{< youtube dQw4w9WgXcQ >}}\n\nText.")},
+		"attribute.md": {Data: []byte("{.lead}\nText.")},
+	}
+
+	for _, name := range []string{"shortcode.md", "attribute.md"} {
+		_, err := p.ParseFile(context.Background(), fsys, name)
+		if err == nil || !strings.Contains(err.Error(), "no frontmatter") || strings.Contains(err.Error(), "JSON") {
+			t.Errorf("%s: expected a missing frontmatter error, got: %v", name, err)
+		}
+	}
+}
+
+func TestParseFile_Params(t *testing.T) {
+	t.Parallel()
+	p := New()
+	fsys := fstest.MapFS{
+		"yaml.md":  {Data: []byte("---\ntitle: Post\ndate: 2024-01-02\ndescription: d\nhero: /img/hero.png\nrating: 4\nauthors: [ann, bob]\nsocial:\n  mastodon: \"@me\"\n---\nText.")},
+		"toml.md":  {Data: []byte("+++\ntitle = \"Post\"\ndate = 2024-01-02\ndescription = \"d\"\nhero = \"/img/hero.png\"\nrating = 4\nauthors = [\"ann\", \"bob\"]\n[social]\nmastodon = \"@me\"\n+++\nText.")},
+		"json.md":  {Data: []byte("{\"title\": \"Post\", \"date\": \"2024-01-02\", \"description\": \"d\", \"hero\": \"/img/hero.png\", \"rating\": 4, \"authors\": [\"ann\", \"bob\"], \"social\": {\"mastodon\": \"@me\"}}\nText.")},
+		"plain.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-02\ndescription: d\ntags: [go]\n---\nText.")},
+	}
+
+	for _, name := range []string{"yaml.md", "toml.md", "json.md"} {
+		post, err := p.ParseFile(context.Background(), fsys, name)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", name, err)
+		}
+		if len(post.Params) != 4 {
+			t.Errorf("%s: expected only the unknown keys in Params, got: %v", name, post.Params)
+		}
+		if post.Params["hero"] != "/img/hero.png" {
+			t.Errorf("%s: expected hero param, got: %v", name, post.Params["hero"])
+		}
+		if fmt.Sprint(post.Params["rating"]) != "4" || fmt.Sprint(post.Params["authors"]) != "[ann bob]" {
+			t.Errorf("%s: expected rating and authors params, got: %v", name, post.Params)
+		}
+		social, ok := post.Params["social"].(map[string]any)
+		if !ok || social["mastodon"] != "@me" {
+			t.Errorf("%s: expected nested social param, got: %#v", name, post.Params["social"])
+		}
+	}
+
+	post, err := p.ParseFile(context.Background(), fsys, "plain.md")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if post.Params != nil {
+		t.Errorf("expected nil Params without unknown keys, got: %v", post.Params)
+	}
+}
+
+func TestParseFile_FrontmatterFormatErrors(t *testing.T) {
+	t.Parallel()
+	p := New()
+	fsys := fstest.MapFS{
+		"bad.toml.md":     {Data: []byte("+++\ntitle = \"Unclosed\n+++\nBody.")},
+		"bad.json.md":     {Data: []byte("{\"title\": \"Missing comma\" \"date\": \"2024-01-02\"}\nBody.")},
+		"bad.yaml.md":     {Data: []byte("---\ntitle: [unclosed\n---\nBody.")},
+		"invalid.toml.md": {Data: []byte("+++\ndate = 2024-01-02\ndescription = \"d\"\n+++\nBody.")},
+		"invalid.json.md": {Data: []byte("{\"date\": \"2024-01-02\", \"description\": \"d\"}\nBody.")},
+	}
+
+	tests := map[string]string{
+		"bad.toml.md":     "TOML",
+		"bad.json.md":     "JSON",
+		"bad.yaml.md":     "YAML",
+		"invalid.toml.md": "title",
+		"invalid.json.md": "title",
+	}
+	for name, want := range tests {
+		_, err := p.ParseFile(context.Background(), fsys, name)
+		if err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+			continue
+		}
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error to mention %q, got: %v", name, want, err)
+		}
+	}
+}
+
 func TestParseFile_WithCodeBlocks(t *testing.T) {
 	t.Parallel()
 	p := New()
@@ -269,6 +406,1657 @@ func TestParseDirectory(t *testing.T) {
 	}
 }
 
+func TestParseFile_ExplicitSlug(t *testing.T) {
+	t.Parallel()
+	p := New()
+	fsys := fstest.MapFS{
+		"renamed.md":  {Data: []byte("---\ntitle: A Brand New Title\ndate: 2024-01-01\ndescription: d\nslug: original-title\n---\nBody.")},
+		"bad-slug.md": {Data: []byte("---\ntitle: Bad Slug\ndate: 2024-01-01\ndescription: d\nslug: Bad Slug\n---\nBody.")},
+	}
+
+	post, err := p.ParseFile(context.Background(), fsys, "renamed.md")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if post.Slug != "original-title" {
+		t.Errorf("expected slug from front matter 'original-title', got: %s", post.Slug)
+	}
+
+	if _, err := p.ParseFile(context.Background(), fsys, "bad-slug.md"); err == nil {
+		t.Fatal("expected error for slug not in slug form, got nil")
+	} else if !strings.Contains(err.Error(), "bad-slug") {
+		t.Errorf("expected error to suggest a valid slug, got: %v", err)
+	}
+}
+
+func TestParseDirectory_SlugCollision(t *testing.T) {
+	t.Parallel()
+	p := New()
+	fsys := fstest.MapFS{
+		"a-first.md":    {Data: []byte("---\ntitle: Same Title\ndate: 2024-01-01\ndescription: d\n---\nFirst.")},
+		"b-second.md":   {Data: []byte("---\ntitle: Same Title\ndate: 2024-01-02\ndescription: d\n---\nSecond.")},
+		"c-explicit.md": {Data: []byte("---\ntitle: Something Else\ndate: 2024-01-03\ndescription: d\nslug: same-title\n---\nThird.")},
+		"d-unique.md":   {Data: []byte("---\ntitle: Unique\ndate: 2024-01-04\ndescription: d\n---\nFourth.")},
+	}
+
+	posts, err := p.ParseDirectory(context.Background(), fsys)
+
+	var parseErrs ParseErrors
+	if !errors.As(err, &parseErrs) {
+		t.Fatalf("expected ParseErrors, got: %T (%v)", err, err)
+	}
+	if len(parseErrs.Errors) != 2 {
+		t.Fatalf("expected 2 collision errors, got %d: %v", len(parseErrs.Errors), parseErrs)
+	}
+
+	for i, wantPath := range []string{"b-second.md", "c-explicit.md"} {
+		fe := parseErrs.Errors[i]
+		if fe.Path != wantPath {
+			t.Errorf("error %d path = %q, want %q", i, fe.Path, wantPath)
+		}
+		var collision SlugCollisionError
+		if !errors.As(fe, &collision) {
+			t.Fatalf("error %d: expected SlugCollisionError, got %T", i, fe.Err)
+		}
+		if collision.Slug != "same-title" || collision.ExistingPath != "a-first.md" {
+			t.Errorf("error %d: got collision %+v", i, collision)
+		}
+		if msg := fe.Error(); !strings.Contains(msg, wantPath) || !strings.Contains(msg, "a-first.md") {
+			t.Errorf("error %d message should name both files, got: %s", i, msg)
+		}
+	}
+
+	if len(posts) != 2 {
+		t.Fatalf("expected 2 valid posts, got %d", len(posts))
+	}
+	for _, post := range posts {
+		if post.Slug == "same-title" && post.SourcePath != "a-first.md" {
+			t.Errorf("slug same-title should belong to a-first.md, got %s", post.SourcePath)
+		}
+	}
+}
+
+// TestParseDirectory_SlugCollisionWithDraft verifies that excluded posts do
+// not claim their slug.
+func TestParseDirectory_SlugCollisionWithDraft(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"a-draft.md":     {Data: []byte("---\ntitle: Same Title\ndate: 2024-01-01\ndescription: d\ndraft: true\n---\nDraft.")},
+		"b-published.md": {Data: []byte("---\ntitle: Same Title\ndate: 2024-01-02\ndescription: d\n---\nPublished.")},
+		"c-draft.md":     {Data: []byte("---\ntitle: Other\ndate: 2024-01-03\ndescription: d\nslug: same-title\ndraft: true\n---\nDraft.")},
+	}
+	excluded := func(post *models.Post) bool { return post.Draft }
+
+	posts, err := New(WithExcluded(excluded)).ParseDirectory(context.Background(), fsys)
+	if err != nil {
+		t.Fatalf("expected drafts not to collide, got: %v", err)
+	}
+	if len(posts) != 3 {
+		t.Errorf("expected 3 posts, got %d", len(posts))
+	}
+
+	// No post is excluded by default, so the first file keeps the slug
+	_, err = New().ParseDirectory(context.Background(), fsys)
+	var parseErrs ParseErrors
+	if !errors.As(err, &parseErrs) || len(parseErrs.Errors) != 2 {
+		t.Fatalf("expected 2 collision errors, got %v", err)
+	}
+	var collision SlugCollisionError
+	if !errors.As(parseErrs.Errors[0], &collision) || collision.ExistingPath != "a-draft.md" {
+		t.Errorf("expected a collision with a-draft.md, got %v", parseErrs.Errors[0])
+	}
+}
+
+func TestParseFile_TOC(t *testing.T) {
+	t.Parallel()
+	source := `---
+title: TOC Post
+date: 2024-01-01
+description: d
+---
+# Title
+
+## Getting Started
+
+### Install *the* ` + "`goblog`" + ` binary
+
+#### Too deep
+
+## Next Steps
+
+### Deploy
+`
+	fsys := fstest.MapFS{
+		"toc.md":    {Data: []byte(source)},
+		"no-toc.md": {Data: []byte(strings.Replace(source, "description: d\n", "description: d\ntoc: false\n", 1))},
+	}
+
+	post, err := New().ParseFile(context.Background(), fsys, "toc.md")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if len(post.TOC) != 2 {
+		t.Fatalf("expected 2 top-level entries, got %d", len(post.TOC))
+	}
+	first := post.TOC[0]
+	if first.Level != 2 || first.Text != "Getting Started" || first.ID != "getting-started" {
+		t.Errorf("unexpected first entry: %+v", first)
+	}
+	if len(first.Children) != 1 {
+		t.Fatalf("expected 1 child under first entry, got %d", len(first.Children))
+	}
+	if got := first.Children[0].Text; got != "Install the goblog binary" {
+		t.Errorf("expected inline markup stripped from heading text, got %q", got)
+	}
+	if got := post.TOC.Len(); got != 4 {
+		t.Errorf("expected 4 entries with default levels 2-3, got %d", got)
+	}
+
+	// Wider range includes the h1 and h4.
+	post, err = New(WithTOCLevels(1, 4)).ParseFile(context.Background(), fsys, "toc.md")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(post.TOC) != 1 || post.TOC[0].Text != "Title" {
+		t.Errorf("expected the h1 to be the only top-level entry, got %+v", post.TOC)
+	}
+	if got := post.TOC.Len(); got != 6 {
+		t.Errorf("expected 6 entries with levels 1-4, got %d", got)
+	}
+
+	// toc: false opts out.
+	post, err = New().ParseFile(context.Background(), fsys, "no-toc.md")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if post.TOC != nil {
+		t.Errorf("expected nil TOC with toc: false, got %+v", post.TOC)
+	}
+}
+
+func TestParseFile_Summary(t *testing.T) {
+	t.Parallel()
+	const frontMatter = "---\ntitle: Summary Post\ndate: 2024-01-01\ndescription: d\n---\n"
+	fsys := fstest.MapFS{
+		"marker.md":  {Data: []byte(frontMatter + "First **bold** paragraph.\n\nSecond paragraph.\n\n<!--more-->\n\nAfter the fold.\n")},
+		"excerpt.md": {Data: []byte(frontMatter + "one two three\nfour five\n\n- six seven\n- eight\n\nnine ten\n\neleven\n")},
+		"inline.md":  {Data: []byte(frontMatter + "Intro **bold**. <!--more--> Rest of it.\n\nAfter the fold.\n")},
+		"spaced.md":  {Data: []byte(frontMatter + "Intro.\n\n<!-- more -->\n\nRest of it.\n\nAfter the fold.\n")},
+		"quote.md":   {Data: []byte(frontMatter + "Intro.\n\n> Quoted.\n>\n> <!--more-->\n>\n> Rest of it.\n\nAfter the fold.\n")},
+		"leading.md": {Data: []byte(frontMatter + "Intro.\n\n> <!--more-->\n>\n> Rest of it.\n\nAfter the fold.\n")},
+	}
+
+	post, err := New().ParseFile(context.Background(), fsys, "marker.md")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	summary := string(post.Summary)
+	if !strings.Contains(summary, "<strong>bold</strong>") || !strings.Contains(summary, "Second paragraph.") {
+		t.Errorf("expected rendered content before the marker, got %q", summary)
+	}
+	if strings.Contains(summary, "After the fold") || strings.Contains(summary, "more") {
+		t.Errorf("expected summary to stop at the marker, got %q", summary)
+	}
+	if !strings.Contains(string(post.HTMLContent), "After the fold") {
+		t.Error("expected full content to include text after the marker")
+	}
+
+	// The marker may be inline, spaced out or nested in a blockquote, and
+	// is still left in the full content.
+	for file, want := range map[string]string{
+		"inline.md":  "<p>Intro <strong>bold</strong>. </p>\n",
+		"spaced.md":  "<p>Intro.</p>\n",
+		"quote.md":   "<p>Intro.</p>\n<blockquote>\n<p>Quoted.</p>\n</blockquote>\n",
+		"leading.md": "<p>Intro.</p>\n",
+	} {
+		post, err := New(WithUnsafeHTML()).ParseFile(context.Background(), fsys, file)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", file, err)
+		}
+		if got := string(post.Summary); got != want {
+			t.Errorf("%s: expected summary %q, got %q", file, want, got)
+		}
+		if html := string(post.HTMLContent); !strings.Contains(html, "Rest of it.") || !strings.Contains(html, "After the fold") {
+			t.Errorf("%s: expected full content to include everything after the marker, got %q", file, html)
+		}
+	}
+
+	// Without a marker the excerpt ends on the block that reaches the limit.
+	post, err = New(WithSummaryWords(6)).ParseFile(context.Background(), fsys, "excerpt.md")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	summary = string(post.Summary)
+	if !strings.Contains(summary, "<li>eight</li>") || !strings.HasSuffix(strings.TrimSpace(summary), "</ul>") {
+		t.Errorf("expected excerpt to end after the list, got %q", summary)
+	}
+	if strings.Contains(summary, "nine") {
+		t.Errorf("expected excerpt to stop before later blocks, got %q", summary)
+	}
+
+	// The default length covers the whole of a short post.
+	post, err = New().ParseFile(context.Background(), fsys, "excerpt.md")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !strings.Contains(string(post.Summary), "eleven") {
+		t.Errorf("expected short post summary to include all blocks, got %q", post.Summary)
+	}
+}
+
+func TestParseDirectory_PageBundle(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"my-post/index.md": {Data: []byte("---\ntitle: Bundled\ndate: 2024-01-01\ndescription: d\n---\n" +
+			"![Diagram](diagram.png)\n\n[Data](./files/data.csv#top) [Other](other.png) [Site](https://example.com/diagram.png)\n")},
+		"my-post/diagram.png":    {Data: []byte("png")},
+		"my-post/files/data.csv": {Data: []byte("a,b")},
+		"my-post/notes.md":       {Data: []byte("not a post")},
+		"my-post/.DS_Store":      {Data: []byte("noise")},
+		"plain.md":               {Data: []byte("---\ntitle: Plain\ndate: 2024-01-02\ndescription: d\n---\n![Diagram](diagram.png)\n")},
+	}
+
+	posts, err := New(WithBlogRoot("/blog/")).ParseDirectory(context.Background(), fsys)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("expected 2 posts (notes.md is a bundle asset), got %d", len(posts))
+	}
+
+	var bundled, plain *models.Post
+	for _, post := range posts {
+		if post.IsBundle() {
+			bundled = post
+		} else {
+			plain = post
+		}
+	}
+	if bundled == nil || plain == nil {
+		t.Fatalf("expected one bundle and one plain post, got %+v", posts)
+	}
+
+	if bundled.BundleDir != "my-post" {
+		t.Errorf("expected BundleDir my-post, got %q", bundled.BundleDir)
+	}
+	wantAssets := []string{"diagram.png", "files/data.csv"}
+	if !reflect.DeepEqual(bundled.Assets, wantAssets) {
+		t.Errorf("expected assets %v, got %v", wantAssets, bundled.Assets)
+	}
+
+	html := string(bundled.HTMLContent)
+	for _, want := range []string{
+		`src="/blog/posts/bundled/diagram.png"`,
+		`href="/blog/posts/bundled/files/data.csv#top"`,
+		`href="other.png"`,
+		`href="https://example.com/diagram.png"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s in bundle HTML, got %s", want, html)
+		}
+	}
+
+	if !strings.Contains(string(plain.HTMLContent), `src="diagram.png"`) {
+		t.Errorf("expected links in single-file posts to be untouched, got %s", plain.HTMLContent)
+	}
+}
+
+// TestParseDirectory_ResponsiveImages verifies that bundle images get their
+// intrinsic size, lazy loading and a srcset of their scaled copies, and that
+// an image which cannot be processed is left as it is.
+func TestParseDirectory_ResponsiveImages(t *testing.T) {
+	t.Parallel()
+	var photo bytes.Buffer
+	if err := png.Encode(&photo, image.NewNRGBA(image.Rect(0, 0, 200, 100))); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	fsys := fstest.MapFS{
+		"gallery/index.md": {Data: []byte("---\ntitle: Gallery\ndate: 2024-01-01\ndescription: d\n---\n" +
+			"![A photo](photo.png) ![Remote](https://example.com/photo.png) ![Vector](chart.svg)\n")},
+		"gallery/photo.png": {Data: photo.Bytes()},
+		"gallery/chart.svg": {Data: []byte("<svg/>")},
+		"broken/index.md":   {Data: []byte("---\ntitle: Broken\ndate: 2024-01-01\ndescription: d\n---\n![Bad](bad.jpg)\n")},
+		"broken/bad.jpg":    {Data: []byte("not a jpeg")},
+	}
+
+	proc := images.New(images.WithWidths(50, 100, 400), images.WithSizes("100vw"))
+	posts, err := New(WithBlogRoot("/blog/"), WithImages(proc)).ParseDirectory(context.Background(), fsys)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(posts))
+	}
+
+	if html, want := string(posts[0].HTMLContent), `<img src="/blog/posts/broken/bad.jpg" alt="Bad" />`; !strings.Contains(html, want) {
+		t.Errorf("expected %s to be left plain, got %s", want, html)
+	}
+
+	html := string(posts[1].HTMLContent)
+	want := `<img src="/blog/posts/gallery/photo.png" alt="A photo" width="200" height="100" loading="lazy" decoding="async" ` +
+		`srcset="/blog/posts/gallery/photo-50w.png 50w, /blog/posts/gallery/photo-100w.png 100w, /blog/posts/gallery/photo.png 200w" sizes="100vw"`
+	if !strings.Contains(html, want) {
+		t.Errorf("expected %s in HTML, got %s", want, html)
+	}
+	for _, want := range []string{
+		`<img src="https://example.com/photo.png" alt="Remote" />`,
+		`<img src="/blog/posts/gallery/chart.svg" alt="Vector" />`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s to be left as written, got %s", want, html)
+		}
+	}
+}
+
+func TestParseDirectory_WikiLinks(t *testing.T) {
+	t.Parallel()
+	const frontMatter = "---\ntitle: %s\ndate: 2024-01-01\ndescription: d\n---\n"
+	fsys := fstest.MapFS{
+		"a.md": {Data: []byte(fmt.Sprintf(frontMatter, "Getting Started") +
+			"See [[Advanced Topics]], [[advanced-topics|the next step]] and [[advanced.md]].\n")},
+		"advanced.md": {Data: []byte(fmt.Sprintf(frontMatter, "Advanced Topics") +
+			"Back to [[getting started]].\n")},
+		"gallery/index.md": {Data: []byte(fmt.Sprintf(frontMatter, "Gallery") +
+			"![[photo.jpg|A photo]]\n")},
+		"gallery/photo.jpg": {Data: []byte("jpg")},
+		"broken.md": {Data: []byte(fmt.Sprintf(frontMatter, "Broken") +
+			"[[Nowhere]] and ![[missing.png]]\n")},
+		"plain.md": {Data: []byte(fmt.Sprintf(frontMatter, "Plain") +
+			"[not a wiki link](https://example.com) and [single]\n")},
+	}
+
+	posts, err := New(WithWikiLinks(), WithBlogRoot("/blog/")).ParseDirectory(context.Background(), fsys)
+
+	var parseErrs ParseErrors
+	if !errors.As(err, &parseErrs) {
+		t.Fatalf("expected ParseErrors, got %v", err)
+	}
+	if len(parseErrs.Errors) != 2 {
+		t.Fatalf("expected 2 broken links, got %v", parseErrs.Errors)
+	}
+	for _, fe := range parseErrs.Errors {
+		var broken BrokenLinkError
+		if fe.Path != "broken.md" || !errors.As(fe, &broken) {
+			t.Errorf("expected BrokenLinkError for broken.md, got %v", fe)
+		}
+	}
+	if !strings.Contains(parseErrs.Error(), "[[Nowhere]]") || !strings.Contains(parseErrs.Error(), "![[missing.png]]") {
+		t.Errorf("expected error to name both targets, got %q", parseErrs.Error())
+	}
+
+	bySlug := make(map[string]*models.Post)
+	for _, post := range posts {
+		bySlug[post.Slug] = post
+	}
+	if _, ok := bySlug["broken"]; ok {
+		t.Error("expected post with broken links to be left out")
+	}
+	if len(bySlug) != 4 {
+		t.Fatalf("expected 4 posts, got %d", len(bySlug))
+	}
+
+	start := string(bySlug["getting-started"].HTMLContent)
+	for _, want := range []string{
+		`<a href="/blog/posts/advanced-topics.html">Advanced Topics</a>`,
+		`<a href="/blog/posts/advanced-topics.html">the next step</a>`,
+		`<a href="/blog/posts/advanced-topics.html">advanced.md</a>`,
+	} {
+		if !strings.Contains(start, want) {
+			t.Errorf("expected %s in %s", want, start)
+		}
+	}
+	if got := string(bySlug["advanced-topics"].HTMLContent); !strings.Contains(got, `href="/blog/posts/getting-started.html"`) {
+		t.Errorf("expected case-insensitive title match, got %s", got)
+	}
+	if got := string(bySlug["gallery"].HTMLContent); !strings.Contains(got, `<img src="/blog/posts/gallery/photo.jpg" alt="A photo" />`) {
+		t.Errorf("expected embed of bundle asset, got %s", got)
+	}
+	if got := string(bySlug["plain"].HTMLContent); !strings.Contains(got, `href="https://example.com"`) || !strings.Contains(got, "[single]") {
+		t.Errorf("expected ordinary links to be unaffected, got %s", got)
+	}
+}
+
+func TestParseFile_WikiLinksDisabled(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"a.md": {Data: []byte("---\ntitle: A\ndate: 2024-01-01\ndescription: d\n---\nSee [[Other]].\n")},
+	}
+
+	post, err := New().ParseFile(context.Background(), fsys, "a.md")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if strings.Contains(string(post.HTMLContent), "<a") {
+		t.Errorf("expected wiki links to be ignored by default, got %s", post.HTMLContent)
+	}
+}
+
+func TestParseFile_Math(t *testing.T) {
+	t.Parallel()
+	p := New(WithMath())
+	fsys := fstest.MapFS{
+		"math.md": {Data: []byte("---\ntitle: Math\ndate: 2024-01-01\ndescription: d\n---\n" +
+			"Energy is $E = mc^2$ and costs $5 or $10.\n\n" +
+			"$$\n\\sum_{i=1}^n i = \\frac{n(n+1)}{2}\n$$\n\n" +
+			"$$\\sqrt{2}$$\n")},
+	}
+
+	post, err := p.ParseFile(context.Background(), fsys, "math.md")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	html := string(post.Content)
+
+	if !strings.Contains(html, `<math xmlns="http://www.w3.org/1998/Math/MathML"><semantics><mrow><mi>E</mi><mo>=</mo><mi>m</mi><msup><mi>c</mi><mn>2</mn></msup></mrow>`) {
+		t.Errorf("expected inline math rendered to MathML, got: %s", html)
+	}
+	if !strings.Contains(html, "costs $5 or $10.") {
+		t.Errorf("expected dollar amounts to be left as text, got: %s", html)
+	}
+	if strings.Count(html, `<math xmlns="http://www.w3.org/1998/Math/MathML" display="block">`) != 2 {
+		t.Errorf("expected two display math blocks, got: %s", html)
+	}
+	if !strings.Contains(html, `<munderover><mo movablelimits="true">∑</mo>`) || !strings.Contains(html, "<msqrt><mn>2</mn></msqrt>") {
+		t.Errorf("expected display math rendered to MathML, got: %s", html)
+	}
+	if strings.Contains(html, "$$") {
+		t.Errorf("expected no math delimiters left in output, got: %s", html)
+	}
+}
+
+func TestParseFile_MathDisabled(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"a.md": {Data: []byte("---\ntitle: A\ndate: 2024-01-01\ndescription: d\n---\nSee $x^2$.\n")},
+	}
+
+	post, err := New().ParseFile(context.Background(), fsys, "a.md")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if strings.Contains(string(post.Content), "<math") {
+		t.Errorf("expected math to be ignored by default, got %s", post.Content)
+	}
+}
+
+func TestParseDirectory_MathError(t *testing.T) {
+	t.Parallel()
+	p := New(WithMath())
+	fsys := fstest.MapFS{
+		"good.md":     {Data: []byte("---\ntitle: Good\ndate: 2024-01-01\ndescription: d\n---\n$\\alpha$\n")},
+		"bad.md":      {Data: []byte("---\ntitle: Bad\ndate: 2024-01-01\ndescription: d\n---\nFine.\n\n$\\frac{1}$\n")},
+		"bad.json.md": {Data: []byte("{\"title\": \"Bad JSON\", \"date\": \"2024-01-02\",\n \"description\": \"d\"}\n\n$$\n\\begin{matrix} a \\end{cases}\n$$\n")},
+	}
+
+	posts, err := p.ParseDirectory(context.Background(), fsys)
+	if len(posts) != 1 || posts[0].Title != "Good" {
+		t.Fatalf("expected only the good post, got %d posts", len(posts))
+	}
+	var parseErrs ParseErrors
+	if !errors.As(err, &parseErrs) || len(parseErrs.Errors) != 2 {
+		t.Fatalf("expected ParseErrors with two entries, got: %v", err)
+	}
+
+	want := map[string]struct {
+		line int
+		msg  string
+	}{
+		"bad.md":      {8, `missing argument for \frac`},
+		"bad.json.md": {4, `\begin{matrix} ended by \end{cases}`},
+	}
+	for _, fe := range parseErrs.Errors {
+		var mathErr MathError
+		if !errors.As(fe, &mathErr) {
+			t.Errorf("%s: expected a MathError, got: %v", fe.Path, fe.Err)
+			continue
+		}
+		w := want[fe.Path]
+		if mathErr.Line != w.line {
+			t.Errorf("%s: expected line %d, got %d", fe.Path, w.line, mathErr.Line)
+		}
+		if !strings.Contains(fe.Error(), w.msg) {
+			t.Errorf("%s: expected error to contain %q, got: %v", fe.Path, w.msg, fe)
+		}
+	}
+}
+
+func TestTexToMathML(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		tex  string
+		want string
+	}{
+		{`x_i^2`, `<msubsup><mi>x</mi><mi>i</mi><mn>2</mn></msubsup>`},
+		{`3.14 - r`, `<mn>3.14</mn><mo>−</mo><mi>r</mi>`},
+		{`\frac{a}{b}`, `<mfrac><mi>a</mi><mi>b</mi></mfrac>`},
+		{`\sqrt[3]{x}`, `<mroot><mi>x</mi><mn>3</mn></mroot>`},
+		{`\alpha \Gamma`, `<mi>α</mi><mi mathvariant="normal">Γ</mi>`},
+		{`\mathbb{R} \mathbf{v}`, `<mi>ℝ</mi><mi>𝐯</mi>`},
+		{`f'(x)`, `<msup><mi>f</mi><mo>′</mo></msup>`},
+		{`a \leq b`, `<mo>≤</mo>`},
+		{`a \not= b`, `<mo>≠</mo>`},
+		{`\sin x`, `<mi>sin</mi><mi>x</mi>`},
+		{`\lim_{n \to \infty}`, `<munder><mo movablelimits="true" form="prefix">lim</mo><mrow><mi>n</mi><mo>→</mo><mi>∞</mi></mrow></munder>`},
+		{`\text{if } x < 0`, `<mtext>if </mtext><mi>x</mi><mo>&lt;</mo><mn>0</mn>`},
+		{`\hat{x}`, `<mover accent="true"><mi>x</mi><mo stretchy="false">^</mo></mover>`},
+		{`\left( x \right)`, `<mrow><mo fence="true" form="prefix" stretchy="true">(</mo><mi>x</mi><mo fence="true" form="postfix" stretchy="true">)</mo></mrow>`},
+		{`\begin{pmatrix} a & b \\ c & d \end{pmatrix}`, `<mtable><mtr><mtd><mi>a</mi></mtd><mtd><mi>b</mi></mtd></mtr><mtr><mtd><mi>c</mi></mtd><mtd><mi>d</mi></mtd></mtr></mtable>`},
+	}
+	for _, tt := range tests {
+		got, err := texToMathML(tt.tex, false)
+		if err != nil {
+			t.Errorf("texToMathML(%q) error = %v", tt.tex, err)
+			continue
+		}
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("texToMathML(%q) = %s, want it to contain %s", tt.tex, got, tt.want)
+		}
+	}
+
+	errorTests := map[string]string{
+		`\foo`:                         `unknown command \foo`,
+		`{x`:                           "missing closing brace",
+		`x}`:                           "unexpected closing brace",
+		`x^2^3`:                        "double superscript",
+		`\frac{a}`:                     `missing argument for \frac`,
+		`\left( x`:                     `\left without matching \right`,
+		`a & b`:                        "unexpected & outside an environment",
+		`\begin{foo} x \end{foo}`:      `unknown environment "foo"`,
+		`\begin{matrix} a \end{cases}`: `\begin{matrix} ended by \end{cases}`,
+	}
+	for tex, want := range errorTests {
+		_, err := texToMathML(tex, false)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("texToMathML(%q) error = %v, want %q", tex, err, want)
+		}
+	}
+}
+
+func TestParseFile_Dialects(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Dialects\ndate: 2024-01-01\ndescription: d\n---\n" +
+			"| a | b |\n| - | - |\n| 1 | 2 |\n\n" +
+			"~~old~~ see https://example.com \"quoted\" :smile:\nnext line\n\n" +
+			"- [x] done\n\n" +
+			"Term\n: Definition\n")},
+	}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "commonmark default",
+			want:    []string{"| a | b |", "~~old~~", ":smile:", "<br />"},
+			notWant: []string{"<table>", "<del>", `<a href="https://example.com">`, "<dl>", "&ldquo;"},
+		},
+		{
+			name:    "gfm",
+			opts:    []Option{WithDialect(DialectGFM)},
+			want:    []string{"<table>", "<del>old</del>", `<a href="https://example.com">`, `type="checkbox"`, ":smile:"},
+			notWant: []string{"<dl>", "&ldquo;"},
+		},
+		{
+			name: "extended",
+			opts: []Option{WithDialect(DialectExtended)},
+			want: []string{"<table>", "<dl>", "&ldquo;quoted&rdquo;", "&#x1f604;"},
+		},
+		{
+			name:    "per-extension overrides",
+			opts:    []Option{WithExtension(ExtensionTables, true), WithDialect(DialectCommonMark), WithExtension(ExtensionHardWraps, false), WithExtension(ExtensionXHTML, false)},
+			want:    []string{"<table>", "~~old~~"},
+			notWant: []string{"<br"},
+		},
+		{
+			name:    "dialect switch turned off",
+			opts:    []Option{WithDialect(DialectGFM), WithExtension(ExtensionLinkify, false)},
+			want:    []string{"<table>"},
+			notWant: []string{`<a href="https://example.com">`},
+		},
+	}
+	for _, tt := range tests {
+		post, err := New(tt.opts...).ParseFile(context.Background(), fsys, "post.md")
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", tt.name, err)
+		}
+		html := string(post.Content)
+		for _, w := range tt.want {
+			if !strings.Contains(html, w) {
+				t.Errorf("%s: expected output to contain %q, got: %s", tt.name, w, html)
+			}
+		}
+		for _, w := range tt.notWant {
+			if strings.Contains(html, w) {
+				t.Errorf("%s: expected output not to contain %q, got: %s", tt.name, w, html)
+			}
+		}
+	}
+}
+
+func TestParseFile_HeadingShift(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Shift\ndate: 2024-01-01\ndescription: d\n---\n# Top\n\n## Section\n\n###### Deepest\n")},
+	}
+
+	post, err := New(WithHeadingShift(1)).ParseFile(context.Background(), fsys, "post.md")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	html := string(post.Content)
+	for _, want := range []string{`<h2 id="top">Top</h2>`, `<h3 id="section">Section</h3>`, `<h6 id="deepest">Deepest</h6>`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s in output, got: %s", want, html)
+		}
+	}
+	if len(post.TOC) != 1 || post.TOC[0].Text != "Top" || len(post.TOC[0].Children) != 1 {
+		t.Errorf("expected the table of contents to use shifted levels, got: %+v", post.TOC)
+	}
+}
+
+func TestParseDialectAndExtension(t *testing.T) {
+	t.Parallel()
+	if d, err := ParseDialect("gfm"); err != nil || d != DialectGFM {
+		t.Errorf("ParseDialect(gfm) = %q, %v", d, err)
+	}
+	if _, err := ParseDialect("github"); err == nil || !strings.Contains(err.Error(), "commonmark") {
+		t.Errorf("expected error listing valid dialects, got: %v", err)
+	}
+	if e, err := ParseExtension("deflist"); err != nil || e != ExtensionDefinitionLists {
+		t.Errorf("ParseExtension(deflist) = %q, %v", e, err)
+	}
+	if _, err := ParseExtension("footnotes"); err == nil || !strings.Contains(err.Error(), "tables") {
+		t.Errorf("expected error listing valid extensions, got: %v", err)
+	}
+}
+
+func TestParseFile_Callouts(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		body    string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "note",
+			body: "> [!NOTE]\n> Useful *information*.\n",
+			want: []string{
+				`<aside class="callout callout-note">`,
+				`<p class="callout-title">Note</p>`,
+				"<p>Useful <em>information</em>.</p>",
+				"</aside>",
+			},
+			notWant: []string{"[!NOTE]", "<blockquote>"},
+		},
+		{
+			name:    "custom title",
+			body:    "> [!WARNING] Mind <the> gap\n> Careful.\n",
+			want:    []string{`<aside class="callout callout-warning">`, `<p class="callout-title">Mind &lt;the&gt; gap</p>`, "<p>Careful.</p>"},
+			notWant: []string{"[!WARNING]"},
+		},
+		{
+			name: "custom kind with separate paragraphs",
+			body: "> [!Field-Notes]\n>\n> First.\n>\n> Second.\n",
+			want: []string{`<aside class="callout callout-field-notes">`, `<p class="callout-title">Field notes</p>`, "<p>First.</p>", "<p>Second.</p>"},
+		},
+		{
+			name:    "plain quote",
+			body:    "> Just a quote with [!NOTE] inside.\n",
+			want:    []string{"<blockquote>", "[!NOTE]"},
+			notWant: []string{"<aside"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fsys := fstest.MapFS{
+				"post.md": {Data: []byte("---\ntitle: Callout\ndate: 2024-01-01\ndescription: d\n---\n" + tt.body)},
+			}
+			post, err := New().ParseFile(context.Background(), fsys, "post.md")
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			html := string(post.Content)
+			for _, want := range tt.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("expected %s in output, got: %s", want, html)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(html, notWant) {
+					t.Errorf("expected no %s in output, got: %s", notWant, html)
+				}
+			}
+		})
+	}
+
+	fsys := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Callout\ndate: 2024-01-01\ndescription: d\n---\n> [!TIP]\n> Text.\n")},
+	}
+	post, err := New(WithExtension(ExtensionCallouts, false)).ParseFile(context.Background(), fsys, "post.md")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !strings.Contains(string(post.Content), "<blockquote>") {
+		t.Errorf("expected a plain block quote with callouts disabled, got: %s", post.Content)
+	}
+}
+
+func TestParseDirectory_Jobs(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{}
+	for i := range 40 {
+		body := fmt.Sprintf("---\ntitle: Post %d\ndate: 2024-01-%02d\ndescription: d\n---\nText %d.", i%35, i%28+1, i)
+		if i%7 == 0 {
+			body = "---\ntitle: Missing fields\n---\nText."
+		}
+		fsys[fmt.Sprintf("post-%02d.md", i)] = &fstest.MapFile{Data: []byte(body)}
+	}
+
+	summarise := func(posts models.PostList, err error) string {
+		var out strings.Builder
+		for _, post := range posts {
+			fmt.Fprintf(&out, "%s %s\n", post.Slug, post.Content)
+		}
+		var pe ParseErrors
+		if errors.As(err, &pe) {
+			for _, fe := range pe.Errors {
+				fmt.Fprintf(&out, "%s\n", fe.Error())
+			}
+		}
+		return out.String()
+	}
+
+	want := summarise(New(WithJobs(1)).ParseDirectory(context.Background(), fsys))
+	if !strings.Contains(want, "is already used by") || !strings.Contains(want, "missing required field") {
+		t.Fatalf("expected the fixture to produce slug collisions and invalid posts, got:\n%s", want)
+	}
+	for _, jobs := range []int{0, 4, 16} {
+		for range 5 {
+			got := summarise(New(WithJobs(jobs)).ParseDirectory(context.Background(), fsys))
+			if got != want {
+				t.Fatalf("WithJobs(%d) gave different results:\n%s\nwant:\n%s", jobs, got, want)
+			}
+		}
+	}
+}
+
+func TestParseDirectory_Canceled(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\nText.")},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	posts, err := New().ParseDirectory(ctx, fsys)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+	if posts != nil {
+		t.Errorf("expected no posts after cancellation, got %d", len(posts))
+	}
+}
+
+func TestParseDirectory_Cache(t *testing.T) {
+	t.Parallel()
+	store, err := cache.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	fsys := fstest.MapFS{
+		"a.md":            {Data: []byte("---\ntitle: A\ndate: 2024-01-01\ndescription: d\nhero: /a.png\n---\nSee [[B]].")},
+		"b.md":            {Data: []byte("---\ntitle: B\ndate: 2024-01-02\ndescription: d\n---\n# B\n\nText.")},
+		"bundle/index.md": {Data: []byte("---\ntitle: Bundle\ndate: 2024-01-03\ndescription: d\n---\n![pic](pic.png)")},
+		"bundle/pic.png":  {Data: []byte("png")},
+	}
+
+	// parse runs ParseDirectory with the cache and returns the posts and the
+	// files that were converted from markdown.
+	parse := func(opts ...Option) (models.PostList, string, error) {
+		var logs bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&logs, nil))
+		p := New(append([]Option{WithWikiLinks(), WithLogger(logger), WithCache(store)}, opts...)...)
+		posts, err := p.ParseDirectory(context.Background(), fsys)
+		return posts, logs.String(), err
+	}
+
+	want, err := New(WithWikiLinks()).ParseDirectory(context.Background(), fsys)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, logs, err := parse(); err != nil || strings.Count(logs, "Parsing file") != 3 {
+		t.Fatalf("expected every file to be parsed on the first run, got err %v and logs:\n%s", err, logs)
+	}
+
+	got, logs, err := parse()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if strings.Contains(logs, "Parsing file") {
+		t.Errorf("expected unchanged files to come from the cache, got logs:\n%s", logs)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cached posts differ from parsed posts:\n got: %+v\nwant: %+v", got, want)
+	}
+
+	// A changed file, or a changed configuration, is parsed again
+	fsys["b.md"] = &fstest.MapFile{Data: []byte("---\ntitle: B\ndate: 2024-01-02\ndescription: d\n---\nNew text.")}
+	if _, logs, _ := parse(); !strings.Contains(logs, "b.md") || strings.Contains(logs, "Parsing file a.md") {
+		t.Errorf("expected only b.md to be parsed again, got logs:\n%s", logs)
+	}
+	if _, logs, _ := parse(WithHeadingShift(1)); strings.Count(logs, "Parsing file") != 3 {
+		t.Errorf("expected a configuration change to parse every file, got logs:\n%s", logs)
+	}
+
+	// Wiki links are resolved again when the posts they point at change
+	delete(fsys, "b.md")
+	_, _, err = parse()
+	var pe ParseErrors
+	if !errors.As(err, &pe) || len(pe.Errors) != 1 || pe.Errors[0].Path != "a.md" {
+		t.Errorf("expected the cached post's broken link to be reported, got: %v", err)
+	}
+}
+
+// TestParseDirectory_CacheImages verifies that a cached bundle post is parsed
+// again when one of its images changes, so that its size is not stale.
+func TestParseDirectory_CacheImages(t *testing.T) {
+	t.Parallel()
+	store, err := cache.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	encode := func(width, height int) []byte {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, width, height))); err != nil {
+			t.Fatalf("png.Encode() error = %v", err)
+		}
+		return buf.Bytes()
+	}
+	fsys := fstest.MapFS{
+		"gallery/index.md":  {Data: []byte("---\ntitle: Gallery\ndate: 2024-01-01\ndescription: d\n---\n![A photo](photo.png)\n")},
+		"gallery/photo.png": {Data: encode(200, 100)},
+	}
+	parse := func() string {
+		p := New(WithCache(store), WithImages(images.New(images.WithWidths(50))))
+		posts, err := p.ParseDirectory(context.Background(), fsys)
+		if err != nil || len(posts) != 1 {
+			t.Fatalf("expected one post, got %d and error %v", len(posts), err)
+		}
+		return string(posts[0].HTMLContent)
+	}
+
+	if html := parse(); !strings.Contains(html, `width="200" height="100"`) {
+		t.Fatalf("expected the image's size in HTML, got %s", html)
+	}
+	fsys["gallery/photo.png"] = &fstest.MapFile{Data: encode(300, 150)}
+	if html := parse(); !strings.Contains(html, `width="300" height="150"`) {
+		t.Errorf("expected the new image's size in HTML, got %s", html)
+	}
+}
+
+func TestParseFile_HeadingAnchors(t *testing.T) {
+	t.Parallel()
+	const body = "# Title\n\n## Setup & Use\n\nText.[^1]\n\n### Setup & Use\n\n[^1]: A note.\n"
+	tests := []struct {
+		name    string
+		anchor  HeadingAnchor
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "defaults",
+			want: []string{
+				`<h2 id="setup--use">Setup &amp; Use <a class="heading-anchor" href="#setup--use" aria-label="Permalink to Setup &amp; Use">#</a></h2>`,
+				`<h3 id="setup--use-1">Setup &amp; Use <a class="heading-anchor" href="#setup--use-1" aria-label="Permalink to Setup &amp; Use">#</a></h3>`,
+				`<h1 id="title">Title</h1>`,
+			},
+		},
+		{
+			name:   "before with custom symbol and label",
+			anchor: HeadingAnchor{Symbol: "¶", Position: AnchorBefore, MinLevel: 1, Label: "Link to section %s"},
+			want: []string{
+				`<h1 id="title"><a class="heading-anchor" href="#title" aria-label="Link to section Title">¶</a> Title</h1>`,
+				`<h2 id="setup--use"><a class="heading-anchor" href="#setup--use" aria-label="Link to section Setup &amp; Use">¶</a> Setup &amp; Use</h2>`,
+			},
+		},
+		{
+			name:    "min level",
+			anchor:  HeadingAnchor{MinLevel: 3},
+			want:    []string{`<h2 id="setup--use">Setup &amp; Use</h2>`, `href="#setup--use-1"`},
+			notWant: []string{`href="#setup--use"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fsys := fstest.MapFS{
+				"post.md": {Data: []byte("---\ntitle: Anchors\ndate: 2024-01-01\ndescription: d\n---\n" + body)},
+			}
+			post, err := New(WithFootnote(), WithHeadingAnchors(tt.anchor)).ParseFile(context.Background(), fsys, "post.md")
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			html := string(post.Content)
+			for _, want := range tt.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("expected %s in output, got: %s", want, html)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(html, notWant) {
+					t.Errorf("expected no %s in output, got: %s", notWant, html)
+				}
+			}
+
+			// Heading and footnote IDs must not repeat
+			seen := map[string]bool{}
+			for _, m := range regexp.MustCompile(`id="([^"]*)"`).FindAllStringSubmatch(html, -1) {
+				if seen[m[1]] {
+					t.Errorf("id %q used more than once in: %s", m[1], html)
+				}
+				seen[m[1]] = true
+			}
+
+			// The anchor is not part of the heading text
+			if len(post.TOC) != 1 || post.TOC[0].Text != "Setup & Use" {
+				t.Errorf("TOC = %+v, want a single Setup & Use entry", post.TOC)
+			}
+		})
+	}
+
+	if _, err := ParseAnchorPosition("left"); err == nil || !strings.Contains(err.Error(), `unknown heading anchor position "left"`) {
+		t.Errorf("ParseAnchorPosition(left) error = %v, want an unknown position error", err)
+	}
+}
+
+func TestHighlightCSS(t *testing.T) {
+	t.Parallel()
+
+	css, err := New().HighlightCSS()
+	if err != nil {
+		t.Fatalf("HighlightCSS() unexpected error: %v", err)
+	}
+	light, dark, found := strings.Cut(string(css), "@media (prefers-color-scheme: dark) {")
+	if !found {
+		t.Fatalf("expected a dark mode media query by default, got: %s", css)
+	}
+	if !strings.Contains(light, ".chroma .k {") || !strings.Contains(dark, ".chroma .k {") {
+		t.Errorf("expected keyword rules for both schemes, got: %s", css)
+	}
+
+	css, err = New(WithHighlightStyle("monokai", "")).HighlightCSS()
+	if err != nil {
+		t.Fatalf("HighlightCSS() unexpected error: %v", err)
+	}
+	if strings.Contains(string(css), "prefers-color-scheme") {
+		t.Errorf("expected no dark mode rules without a dark style, got: %s", css)
+	}
+	if !strings.Contains(string(css), "#272822") {
+		t.Errorf("expected the monokai background colour, got: %s", css)
+	}
+
+	if _, err := New(WithHighlightStyle("github", "no-such-style")).HighlightCSS(); err == nil || !strings.Contains(err.Error(), `unknown highlight style "no-such-style"`) {
+		t.Errorf("HighlightCSS() error = %v, want an unknown style error", err)
+	}
+}
+
+func TestParseFile_CodeBlockInfo(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		opts    []Option
+		body    string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "title, lines and line numbers",
+			body: "```go title=\"main.go\" {2-3} linenos\npackage main\n\nfunc main() {}\n```\n",
+			want: []string{
+				`<figure class="code-block">`,
+				`<figcaption class="code-title">main.go</figcaption>`,
+				`<span class="line hl"><span class="ln">2</span>`,
+				`<span class="line hl"><span class="ln">3</span>`,
+				`<span class="line"><span class="ln">1</span>`,
+				"</code></pre>\n</figure>",
+			},
+			notWant: []string{"code-copy"},
+		},
+		{
+			name:    "escaped title with spaces",
+			body:    "```go title='<my> file.go'\nx := 1\n```\n",
+			want:    []string{`<figcaption class="code-title">&lt;my&gt; file.go</figcaption>`},
+			notWant: []string{`class="ln"`},
+		},
+		{
+			name: "line numbers from linenostart",
+			body: "```go linenostart=10 {1}\nx := 1\n```\n",
+			want: []string{`<span class="line hl"><span class="ln">10</span>`},
+		},
+		{
+			name: "goldmark-highlighting attributes",
+			body: "```go {hl_lines=[1] linenos=true}\nx := 1\n```\n",
+			want: []string{`<span class="line hl"><span class="ln">1</span>`},
+		},
+		{
+			name:    "plain block",
+			body:    "```go\nx := 1\n```\n",
+			want:    []string{`<pre class="chroma">`},
+			notWant: []string{"<figure", `class="ln"`, "line hl"},
+		},
+		{
+			name: "copy button",
+			opts: []Option{WithCopyButton()},
+			body: "```go\nx := 1\n```\n\n```nosuchlanguage\na<b\n```\n",
+			want: []string{
+				"<figure class=\"code-block\">\n<button type=\"button\" class=\"code-copy\" aria-label=\"Copy code to clipboard\">Copy</button>\n<pre class=\"chroma\">",
+				"<figure class=\"code-block\">\n<button type=\"button\" class=\"code-copy\" aria-label=\"Copy code to clipboard\">Copy</button>\n<pre><code class=\"language-nosuchlanguage\">a&lt;b\n</code></pre>\n</figure>",
+			},
+		},
+		{
+			name:    "highlighting disabled",
+			opts:    []Option{WithCodeHighlighting(false), WithCopyButton()},
+			body:    "```go title=\"main.go\" {1}\nx := 1\n```\n",
+			want:    []string{`<pre><code class="language-go">x := 1`},
+			notWant: []string{"<figure", "main.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fsys := fstest.MapFS{
+				"post.md": {Data: []byte("---\ntitle: Code\ndate: 2024-01-01\ndescription: d\n---\n" + tt.body)},
+			}
+			post, err := New(tt.opts...).ParseFile(context.Background(), fsys, "post.md")
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			html := string(post.Content)
+			for _, want := range tt.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("expected %s in output, got: %s", want, html)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(html, notWant) {
+					t.Errorf("expected no %s in output, got: %s", notWant, html)
+				}
+			}
+		})
+	}
+}
+
+// TestParseFile_ExternalLinks verifies that only links leaving the site and
+// its trusted domains are given the configured attributes.
+func TestParseFile_ExternalLinks(t *testing.T) {
+	t.Parallel()
+	const body = "[Go](https://go.dev/doc) [Home](https://example.com/about) [Blog](https://blog.example.com/) " +
+		"[Repo](https://github.com/harrydayexe/GoBlog) [Post](/posts/other.html) [Mail](mailto:me@go.dev) " +
+		"<https://pkg.go.dev> www.golang.org\n"
+	tests := []struct {
+		name    string
+		links   ExternalLinks
+		want    []string
+		notWant []string
+	}{
+		{
+			name:  "new tab, rel and class",
+			links: ExternalLinks{Site: "https://example.com/blog/", Rel: []string{"nofollow", "ugc"}, NewTab: true, Class: "external", Trusted: []string{"GitHub.com"}},
+			want: []string{
+				`<a href="https://go.dev/doc" rel="nofollow ugc noopener" target="_blank" class="external">Go</a>`,
+				`<a href="https://example.com/about">Home</a>`,
+				`<a href="https://blog.example.com/">Blog</a>`,
+				`<a href="https://github.com/harrydayexe/GoBlog">Repo</a>`,
+				`<a href="/posts/other.html">Post</a>`,
+				`<a href="mailto:me@go.dev">Mail</a>`,
+				`<a href="https://pkg.go.dev" rel="nofollow ugc noopener" target="_blank" class="external">https://pkg.go.dev</a>`,
+				`<a href="http://www.golang.org" rel="nofollow ugc noopener" target="_blank" class="external">www.golang.org</a>`,
+			},
+		},
+		{
+			name:    "rel only without a site",
+			links:   ExternalLinks{Rel: []string{"noopener", "noopener"}},
+			want:    []string{`<a href="https://example.com/about" rel="noopener">Home</a>`},
+			notWant: []string{`target=`, `class=`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fsys := fstest.MapFS{
+				"post.md": {Data: []byte("---\ntitle: Links\ndate: 2024-01-01\ndescription: d\n---\n" + body)},
+			}
+			p := New(WithExternalLinks(tt.links), WithExtension(ExtensionLinkify, true))
+			post, err := p.ParseFile(context.Background(), fsys, "post.md")
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+			html := string(post.HTMLContent)
+			for _, want := range tt.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("expected %s in HTML, got %s", want, html)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(html, notWant) {
+					t.Errorf("expected no %s in HTML, got %s", notWant, html)
+				}
+			}
+		})
+	}
+}
+
+// TestParseLinkRel verifies that only known rel values are accepted.
+func TestParseLinkRel(t *testing.T) {
+	t.Parallel()
+	for _, rel := range []string{"noopener", "nofollow", "ugc"} {
+		if got, err := ParseLinkRel(rel); err != nil || got != rel {
+			t.Errorf("ParseLinkRel(%q) = %q, %v", rel, got, err)
+		}
+	}
+	if _, err := ParseLinkRel("stylesheet"); err == nil {
+		t.Error("ParseLinkRel(stylesheet) should fail")
+	}
+}
+
+// TestParseFile_Shortcodes verifies that built-in and custom shortcodes are
+// expanded, paired shortcodes render their inner markdown, and shortcodes in
+// code or escaped with /* */ are left as text.
+// TestParseDirectory_WikiLinksToUnpublished verifies that published posts
+// cannot link to posts that will not be published, while those posts can
+// still link to each other.
+func TestParseDirectory_WikiLinksToUnpublished(t *testing.T) {
+	t.Parallel()
+	const frontMatter = "---\ntitle: %s\ndate: 2024-01-01\ndescription: d\ndraft: %t\n---\n"
+	fsys := fstest.MapFS{
+		"public.md": {Data: []byte(fmt.Sprintf(frontMatter, "Public", false) + "See [[Some Draft]].\n")},
+		"other.md":  {Data: []byte(fmt.Sprintf(frontMatter, "Other", false) + "See [[Public]].\n")},
+		"draft.md":  {Data: []byte(fmt.Sprintf(frontMatter, "Some Draft", true) + "See [[Another Draft]] and [[Public]].\n")},
+		"draft2.md": {Data: []byte(fmt.Sprintf(frontMatter, "Another Draft", true) + "Text.\n")},
+	}
+
+	p := New(WithWikiLinks(), WithPublished(func(post *models.Post) bool { return !post.Draft }))
+	posts, err := p.ParseDirectory(context.Background(), fsys)
+
+	var parseErrs ParseErrors
+	if !errors.As(err, &parseErrs) || len(parseErrs.Errors) != 1 {
+		t.Fatalf("expected one ParseErrors entry, got %v", err)
+	}
+	var broken BrokenLinkError
+	if fe := parseErrs.Errors[0]; fe.Path != "public.md" || !errors.As(fe.Err, &broken) || broken.Target != "Some Draft" {
+		t.Errorf("expected public.md's link to the draft to be broken, got %v", fe)
+	}
+	if len(posts) != 3 {
+		t.Fatalf("expected 3 posts, got %d", len(posts))
+	}
+	for _, post := range posts {
+		if post.Title == "Some Draft" && !strings.Contains(string(post.HTMLContent), `href="/posts/another-draft.html"`) {
+			t.Errorf("expected a draft to link to another draft, got %s", post.HTMLContent)
+		}
+	}
+}
+
+func TestParseFile_Shortcodes(t *testing.T) {
+	t.Parallel()
+	const body = "Intro {{< kbd Ctrl >}} key.\n\n" +
+		"{{< youtube dQw4w9WgXcQ title=\"A video\" >}}\n\n" +
+		"{{< figure src=\"cat.jpg\" caption=\"A cat\" link=\"https://example.com\" >}}\n\n" +
+		"{{< details \"More\" open=true >}}\n" +
+		"Some **bold** text with {{< kbd Esc >}}.\n\n" +
+		"Second paragraph.\n" +
+		"{{< /details >}}\n\n" +
+		"Inline `{{< kbd X >}}` and {{</* kbd Y */>}}.\n\n" +
+		"```\n{{< youtube abc >}}\n```\n"
+	fsys := fstest.MapFS{
+		"shortcodes/index.md": {Data: []byte("---\ntitle: Shortcodes\ndate: 2024-01-01\ndescription: \"{{< kbd Z >}}\"\n---\n" + body)},
+		"shortcodes/cat.jpg":  {Data: []byte("not really a jpeg")},
+	}
+	p := New(WithShortcodes(map[string]ShortcodeFunc{
+		"kbd": func(sc Shortcode) (template.HTML, error) {
+			return template.HTML("<kbd>" + template.HTMLEscapeString(sc.Arg(0)) + "</kbd>"), nil
+		},
+	}))
+	post, err := p.ParseFile(context.Background(), fsys, "shortcodes/index.md")
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	if post.Description != "{{< kbd Z >}}" {
+		t.Errorf("front matter should be left alone, got description %q", post.Description)
+	}
+
+	html := string(post.HTMLContent)
+	for _, want := range []string{
+		"<p>Intro <kbd>Ctrl</kbd> key.</p>",
+		`<div class="shortcode-youtube"><iframe src="https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ" title="A video" loading="lazy"`,
+		`<figure><a href="https://example.com"><img src="/posts/shortcodes/cat.jpg" alt="A cat" loading="lazy" /></a><figcaption>A cat</figcaption></figure>`,
+		"<details open><summary>More</summary>\n<p>Some <strong>bold</strong> text with <kbd>Esc</kbd>.</p>\n<p>Second paragraph.</p>\n</details>",
+		"<code>{{&lt; kbd X &gt;}}</code> and {{&lt; kbd Y &gt;}}.",
+		"{{&lt; youtube abc &gt;}}",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected HTML to contain %q, got:\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "<p><div") || strings.Contains(html, "<p><figure") || strings.Contains(html, "<p><details") {
+		t.Errorf("shortcodes standing alone should not be wrapped in <p>, got:\n%s", html)
+	}
+}
+
+// TestParseFile_ShortcodesInRawHTMLAndHeadings verifies that shortcodes in
+// raw HTML are expanded and that those in headings are left out of the IDs.
+func TestParseFile_ShortcodesInRawHTMLAndHeadings(t *testing.T) {
+	t.Parallel()
+	const body = "## Heading {{< youtube ghi >}}\n\n" +
+		"<div class=\"video\">\n{{< youtube abc >}}\n</div>\n\n" +
+		"Text <span title=\"x\">{{< youtube def >}}</span> and <a href=\"{{< ref >}}\">a link</a>.\n"
+	fsys := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Raw\ndate: 2024-01-01\ndescription: d\n---\n" + body)},
+	}
+	p := New(WithUnsafeHTML(), WithShortcodes(map[string]ShortcodeFunc{
+		"ref": func(sc Shortcode) (template.HTML, error) {
+			return "/posts/other/", nil
+		},
+	}))
+	post, err := p.ParseFile(context.Background(), fsys, "post.md")
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+
+	html := string(post.HTMLContent)
+	for _, want := range []string{
+		`<h2 id="heading">Heading <div class="shortcode-youtube"><iframe src="https://www.youtube-nocookie.com/embed/ghi"`,
+		"<div class=\"video\">\n<div class=\"shortcode-youtube\"><iframe src=\"https://www.youtube-nocookie.com/embed/abc\"",
+		`<span title="x"><div class="shortcode-youtube"><iframe src="https://www.youtube-nocookie.com/embed/def"`,
+		`<a href="/posts/other/">a link</a>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected HTML to contain %q, got:\n%s", want, html)
+		}
+	}
+	if strings.ContainsRune(html, shortcodePlaceholder) || strings.Contains(html, "{{&lt;") {
+		t.Errorf("expected every shortcode to be expanded, got:\n%s", html)
+	}
+}
+
+// TestParseFile_ShortcodeIDs verifies that headings and footnotes inside a
+// paired shortcode do not take the IDs of those around it.
+func TestParseFile_ShortcodeIDs(t *testing.T) {
+	t.Parallel()
+	const body = "## Setup\n\nOutside.[^1]\n\n" +
+		"{{< details \"More\" >}}\n## Setup\n\nInside.[^1]\n\n[^1]: Inner note.\n{{< /details >}}\n\n" +
+		"## Setup\n\n[^1]: Outer note.\n"
+	fsys := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: IDs\ndate: 2024-01-01\ndescription: d\n---\n" + body)},
+	}
+	post, err := New(WithShortcodes(nil), WithFootnote()).ParseFile(context.Background(), fsys, "post.md")
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+
+	html := string(post.HTMLContent)
+	for _, want := range []string{`id="setup"`, `id="setup-1"`, `id="setup-2"`, `id="fn:1"`, `id="sc1-fn:1"`} {
+		if n := strings.Count(html, want); n != 1 {
+			t.Errorf("expected HTML to contain %s once, found %d in:\n%s", want, n, html)
+		}
+	}
+}
+
+// TestParseDirectory_ShortcodeErrors verifies that unknown and malformed
+// shortcodes fail their file with a ShortcodeError giving the line.
+func TestParseDirectory_ShortcodeErrors(t *testing.T) {
+	t.Parallel()
+	const header = "---\ntitle: %s\ndate: 2024-01-01\ndescription: d\n---\n"
+	fsys := fstest.MapFS{
+		"good.md":    {Data: []byte(fmt.Sprintf(header, "Good") + "{{< youtube dQw4w9WgXcQ >}}\n")},
+		"unknown.md": {Data: []byte(fmt.Sprintf(header, "Unknown") + "Fine.\n\n{{< details >}}\n{{< gallery dir=\"photos\" >}}\n{{< /details >}}\n")},
+		"bad.md":     {Data: []byte(fmt.Sprintf(header, "Bad") + "{{< youtube >}}\n")},
+		"stray.md":   {Data: []byte(fmt.Sprintf(header, "Stray") + "One\n\n{{< /details >}}\n")},
+	}
+
+	posts, err := New(WithShortcodes(nil), WithMath()).ParseDirectory(context.Background(), fsys)
+	if len(posts) != 1 || posts[0].Title != "Good" {
+		t.Fatalf("expected only the good post, got %d posts", len(posts))
+	}
+	var parseErrs ParseErrors
+	if !errors.As(err, &parseErrs) || len(parseErrs.Errors) != 3 {
+		t.Fatalf("expected 3 file errors, got: %v", err)
+	}
+
+	want := map[string]struct {
+		line int
+		name string
+		err  string
+	}{
+		"bad.md":     {line: 6, name: "youtube", err: "missing video id"},
+		"stray.md":   {line: 8, name: "details", err: "closing tag has no opening tag"},
+		"unknown.md": {line: 9, name: "gallery", err: "unknown shortcode"},
+	}
+	for _, fe := range parseErrs.Errors {
+		var scErr ShortcodeError
+		if !errors.As(fe, &scErr) {
+			t.Errorf("%s: expected a ShortcodeError, got: %v", fe.Path, fe.Err)
+			continue
+		}
+		w := want[fe.Path]
+		if scErr.Line != w.line || scErr.Name != w.name || !strings.Contains(scErr.Error(), w.err) {
+			t.Errorf("%s: got %v, want line %d, shortcode %q and %q", fe.Path, scErr, w.line, w.name, w.err)
+		}
+		if fe.Path == "unknown.md" && !errors.Is(fe, ErrUnknownShortcode) {
+			t.Errorf("unknown.md: expected ErrUnknownShortcode, got: %v", fe)
+		}
+	}
+}
+
+// TestParseFile_RenderHooks verifies that render hooks replace the HTML of
+// links, images, headings and code blocks, receiving their structured data
+// and the HTML they would otherwise be rendered as.
+func TestParseFile_RenderHooks(t *testing.T) {
+	t.Parallel()
+	const body = "## Hello *world*\n\n" +
+		"See [the **docs**](https://go.dev/doc \"Go docs\") and <https://pkg.go.dev>.\n\n" +
+		"![A cat](cat.jpg \"Our cat\")\n\n" +
+		"```go title=\"main.go\"\nfmt.Println(\"<hi>\")\n```\n"
+	fsys := fstest.MapFS{
+		"hooks/index.md": {Data: []byte("---\ntitle: Hooks\ndate: 2024-01-01\ndescription: d\n---\n" + body)},
+		"hooks/cat.jpg":  {Data: []byte("not really a jpeg")},
+	}
+	hooks := RenderHooks{
+		Link: func(d LinkData) (template.HTML, error) {
+			return template.HTML(fmt.Sprintf(`<a class="hooked" href="%s" title="%s">%s</a>[%s]`,
+				template.HTMLEscapeString(d.Destination), template.HTMLEscapeString(d.Title), d.Text, template.HTMLEscapeString(d.PlainText))), nil
+		},
+		Image: TemplateRenderHook[ImageData](template.Must(template.New("image").Parse(
+			`<figure>{{.HTML}}<figcaption>{{.Title}}</figcaption></figure>`))),
+		Heading: TemplateRenderHook[HeadingData](template.Must(template.New("heading").Parse(
+			`<h{{.Level}} id="{{.ID}}" data-text="{{.PlainText}}">{{.Text}}</h{{.Level}}>`))),
+		CodeBlock: TemplateRenderHook[CodeBlockData](template.Must(template.New("code").Parse(
+			`<div class="code" data-lang="{{.Language}}" data-title="{{.Attributes.title}}"><pre>{{.Code}}</pre>{{.HTML}}</div>`))),
+	}
+	post, err := New(WithRenderHooks(hooks)).ParseFile(context.Background(), fsys, "hooks/index.md")
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+
+	html := string(post.HTMLContent)
+	for _, want := range []string{
+		`<h2 id="hello-world" data-text="Hello world">Hello <em>world</em></h2>`,
+		`<a class="hooked" href="https://go.dev/doc" title="Go docs">the <strong>docs</strong></a>[the docs]`,
+		`<a class="hooked" href="https://pkg.go.dev" title="">https://pkg.go.dev</a>[https://pkg.go.dev]`,
+		`<figure><img src="/posts/hooks/cat.jpg" alt="A cat" title="Our cat" /><figcaption>Our cat</figcaption></figure>`,
+		`<div class="code" data-lang="go" data-title="main.go"><pre>fmt.Println(&#34;&lt;hi&gt;&#34;)` + "\n</pre>",
+		`<figcaption class="code-title">main.go</figcaption>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected HTML to contain %q, got:\n%s", want, html)
+		}
+	}
+}
+
+// TestParseFile_RenderHookError verifies that an error returned by a render
+// hook fails the post.
+func TestParseFile_RenderHookError(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\n[Go](https://go.dev)\n")},
+	}
+	p := New(WithRenderHooks(RenderHooks{
+		Link: func(LinkData) (template.HTML, error) {
+			return "", errors.New("no links allowed")
+		},
+	}))
+	_, err := p.ParseFile(context.Background(), fsys, "post.md")
+	if err == nil || !strings.Contains(err.Error(), "link render hook: no links allowed") {
+		t.Errorf("ParseFile() error = %v, want the hook's error", err)
+	}
+}
+
+func TestParseFile_UnsafeHTML(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		markdown string
+		want     []string
+		notWant  []string
+	}{
+		{
+			name:     "raw html dropped by default",
+			markdown: "Press <kbd>Ctrl</kbd>\n",
+			notWant:  []string{"<kbd>"},
+		},
+		{
+			name:     "allowed elements kept",
+			markdown: "<details open>\n<summary>More</summary>\n\nHidden *text*.\n\n</details>\n\nPress <kbd>Ctrl</kbd>, see<sup>1</sup>.\n\n<video src=\"clip.mp4\" controls muted></video>\n",
+			want:     []string{"<details open>", "<summary>More</summary>", "<em>text</em>", "<kbd>Ctrl</kbd>", "<sup>1</sup>", `<video src="clip.mp4" controls muted></video>`},
+		},
+		{
+			name:     "scripts removed with their content",
+			markdown: "Hi <script>alert(1)</script> there\n\n<SCRIPT src=\"https://evil.example/x.js\"></SCRIPT>\n",
+			want:     []string{"Hi  there"},
+			notWant:  []string{"script", "SCRIPT", "alert"},
+		},
+		{
+			name:     "event handlers removed",
+			markdown: "<img src=\"cat.jpg\" alt=\"Cat\" onerror=\"alert(1)\" />\n\n<details ontoggle=alert(1)><summary>x</summary></details>\n",
+			want:     []string{`<img src="cat.jpg" alt="Cat" />`, "<details><summary>x</summary></details>"},
+			notWant:  []string{"onerror", "ontoggle", "alert"},
+		},
+		{
+			name:     "javascript urls removed",
+			markdown: "<a href=\"java&#x09;script:alert(1)\">raw</a> [md](javascript:alert(2)) <a href=\" JavaScript:alert(3)\">spaced</a> <a href=\"/about\">ok</a>\n",
+			want:     []string{"<a>raw</a>", "<a>md</a>", "<a>spaced</a>", `<a href="/about">ok</a>`},
+			notWant:  []string{"alert"},
+		},
+		{
+			name:     "unknown elements unwrapped",
+			markdown: "<marquee>Still here</marquee> <iframe srcdoc=\"x\"></iframe> <textarea>gone</textarea>\n",
+			want:     []string{"Still here"},
+			notWant:  []string{"marquee", "srcdoc", "textarea", "gone"},
+		},
+		{
+			name:     "comments and styles removed",
+			markdown: "<style>body { display: none }</style>\n\nText<!-- secret --> <span style=\"position:fixed\">styled</span>\n",
+			want:     []string{"Text <span>styled</span>"},
+			notWant:  []string{"display", "secret", "position"},
+		},
+		{
+			name:     "non-ascii raw text removed",
+			markdown: "<style>ȺȺȺȺȺȺ</style>\n\nAfter \u212a <kbd>K</kbd>\n\n<STYLE>\u212a</sTyLe>\n\nEnd\n",
+			want:     []string{"<p>After \u212a <kbd>K</kbd></p>", "<p>End</p>"},
+			notWant:  []string{"Ⱥ", "style", "STYLE"},
+		},
+		{
+			name:     "non-ascii raw text at end of post",
+			markdown: "Text\n\n<style>ȺȺȺȺȺȺ</style>\n",
+			want:     []string{"<p>Text</p>"},
+			notWant:  []string{"Ⱥ", "style"},
+		},
+		{
+			name:     "unclosed attribute quote kept as text",
+			markdown: "Intro paragraph.\n\n<div>\n<span title=\"oops>x</span>\n</div>\n\nAfter the div.\n\n<kbd>K</kbd> end\n",
+			want:     []string{"<p>Intro paragraph.</p>", `&lt;span title="oops>x`, "<p>After the div.</p>", "<kbd>K</kbd> end"},
+			notWant:  []string{"<span"},
+		},
+		{
+			name:     "unclosed comment kept as text",
+			markdown: "Intro paragraph.\n\nText <!-- oops\n\nAfter the comment <kbd>K</kbd>.\n",
+			want:     []string{"<p>Intro paragraph.</p>", "&lt;!-- oops", "<p>After the comment <kbd>K</kbd>.</p>"},
+		},
+		{
+			name:     "unclosed raw text element kept as text",
+			markdown: "Intro paragraph.\n\n<textarea>draft\n\nAfter the textarea.\n",
+			want:     []string{"<p>Intro paragraph.</p>", "draft", "After the textarea."},
+			notWant:  []string{"<textarea"},
+		},
+		{
+			name:     "table alignment kept",
+			markdown: "| a |\n|--:|\n| 1 |\n",
+			want:     []string{`<th style="text-align:right">a</th>`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fsys := fstest.MapFS{
+				"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\n" + tt.markdown)},
+			}
+			opts := []Option{WithExtension(ExtensionTables, true), WithExtension(ExtensionXHTML, false)}
+			if tt.want != nil {
+				opts = append(opts, WithUnsafeHTML())
+			}
+			post, err := New(opts...).ParseFile(context.Background(), fsys, "post.md")
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+			html := string(post.Content)
+			for _, w := range tt.want {
+				if !strings.Contains(html, w) {
+					t.Errorf("Content missing %q:\n%s", w, html)
+				}
+			}
+			for _, nw := range tt.notWant {
+				if strings.Contains(html, nw) {
+					t.Errorf("Content should not contain %q:\n%s", nw, html)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFile_HTMLPolicy(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\n" +
+			"<dl><dt>Term</dt><dd data-x=\"1\">Def</dd></dl>\n\nPress <kbd>Ctrl</kbd> <marquee behavior=\"alternate\" onclick=\"x()\">hi</marquee>\n")},
+	}
+	policy := HTMLPolicy{URLSchemes: []string{"https"}}
+	policy.Allow("p")
+	policy.Allow("MARQUEE", "behavior", "onclick")
+	p := New(WithUnsafeHTML(), WithHTMLPolicy(policy))
+	post, err := p.ParseFile(context.Background(), fsys, "post.md")
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	want := "TermDef\n<p>Press Ctrl <marquee behavior=\"alternate\">hi</marquee></p>\n"
+	if got := string(post.Content); got != want {
+		t.Errorf("Content = %q, want %q", got, want)
+	}
+}
+
+// TestParseDirectory_Schema verifies that every front matter rule a post
+// breaks is reported as a SchemaError, whatever its front matter format.
+func TestParseDirectory_Schema(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"good.md": {Data: []byte("---\ntitle: Good\ndate: 2024-01-01\ndescription: d\nauthor: Ada\n" +
+			"tags: [go, web]\nrating: 4\nupdated: 2024-02-01\nticket: BLOG-12\n---\nBody\n")},
+		"good-toml.md": {Data: []byte("+++\ntitle = \"Good TOML\"\ndate = 2024-01-01\ndescription = \"d\"\n" +
+			"author = \"Grace\"\nrating = 5\nupdated = 2024-02-01\n+++\nBody\n")},
+		"good-json.md": {Data: []byte(`{"title": "Good JSON", "date": "2024-01-01", "description": "d", "author": "Alan", "rating": 3, "updated": "2024-02-01"}` + "\nBody\n")},
+		"bad.md": {Data: []byte("---\ntitle: Bad\ndate: 2024-01-01\ndescription: d\n" +
+			"tags: [go, rust, web, cli]\nrating: high\nupdated: soon\nticket: blog12\n---\nBody\n")},
+		"empty.md": {Data: []byte("---\ntitle: Empty\ndate: 2024-01-01\ndescription: d\nauthor: \"\"\n---\nBody\n")},
+		"both.md":  {Data: []byte("---\ntitle: Both\ndate: 2024-01-01\nauthor: Ada\nrating: 4.5\n---\nBody\n")},
+	}
+	schema, err := ParseSchema([]byte(`fields:
+  author:
+    required: true
+    type: string
+    minLength: 1
+  tags:
+    type: list
+    maxLength: 3
+    enum: [go, web, cli]
+  rating:
+    type: int
+  updated:
+    type: date
+  ticket:
+    pattern: ^[A-Z]+-[0-9]+$
+`))
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+
+	posts, err := New(WithSchema(schema)).ParseDirectory(context.Background(), fsys)
+	if len(posts) != 3 {
+		t.Errorf("expected the 3 good posts, got %d posts", len(posts))
+	}
+	var parseErrs ParseErrors
+	if !errors.As(err, &parseErrs) || len(parseErrs.Errors) != 3 {
+		t.Fatalf("expected 3 file errors, got: %v", err)
+	}
+
+	want := map[string][]string{
+		"both.md": {
+			"post missing required field: description (source: both.md)",
+			`front matter "rating": want int, got number`,
+		},
+		"bad.md": {
+			`front matter "author": required key is missing`,
+			`front matter "rating": want int, got string`,
+			`front matter "tags": length 4 is more than 3`,
+			`front matter "tags": "rust" is not one of go, web, cli`,
+			`front matter "ticket": "blog12" does not match ^[A-Z]+-[0-9]+$`,
+			`front matter "updated": want date, got string`,
+		},
+		"empty.md": {`front matter "author": length 0 is less than 1`},
+	}
+	for _, fe := range parseErrs.Errors {
+		joined, ok := fe.Err.(interface{ Unwrap() []error })
+		if !ok {
+			t.Errorf("%s: expected joined schema errors, got: %v", fe.Path, fe.Err)
+			continue
+		}
+		var got []string
+		for i, e := range joined.Unwrap() {
+			var schemaErr SchemaError
+			if !errors.As(e, &schemaErr) && (fe.Path != "both.md" || i > 0) {
+				t.Errorf("%s: expected a SchemaError, got: %v", fe.Path, e)
+			}
+			got = append(got, e.Error())
+		}
+		if !slices.Equal(got, want[fe.Path]) {
+			t.Errorf("%s: got errors\n%s\nwant\n%s", fe.Path, strings.Join(got, "\n"), strings.Join(want[fe.Path], "\n"))
+		}
+		if fe.Path == "bad.md" && !errors.Is(fe, ErrMissingKey) {
+			t.Errorf("bad.md: expected ErrMissingKey, got: %v", fe)
+		}
+	}
+}
+
+func TestParseSchema_Invalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{name: "unknown rule", schema: "fields:\n  tags:\n    enums: [go]\n", wantErr: "field enums not found"},
+		{name: "unknown type", schema: "fields:\n  tags:\n    type: array\n", wantErr: `unknown type "array"`},
+		{name: "bad pattern", schema: "fields:\n  slug:\n    pattern: \"[a-\"\n", wantErr: "missing closing ]"},
+		{name: "bad lengths", schema: "fields:\n  title:\n    minLength: 10\n    maxLength: 5\n", wantErr: "bad length bounds 10 to 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := ParseSchema([]byte(tt.schema)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseSchema() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestParseErrors_Error(t *testing.T) {
 	t.Parallel()
 	testErr := fmt.Errorf("test error")
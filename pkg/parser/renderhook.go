@@ -0,0 +1,348 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// LinkData describes a link, or an autolink such as <https://go.dev>, to a
+// link render hook.
+type LinkData struct {
+	// Destination is the URL linked to, after page bundle links have been
+	// rewritten.
+	Destination string
+
+	// Title is the link's title, empty when it has none.
+	Title string
+
+	// Text is the link text rendered to HTML, with render hooks applied.
+	Text template.HTML
+
+	// PlainText is the link text without markup.
+	PlainText string
+
+	// Attributes are the attributes set on the link by other features, such
+	// as rel and target for external links.
+	Attributes map[string]string
+
+	// HTML is the link as it is rendered without render hooks.
+	HTML template.HTML
+}
+
+// ImageData describes an image to an image render hook.
+type ImageData struct {
+	// Destination is the URL of the image, after page bundle links have
+	// been rewritten.
+	Destination string
+
+	// Title is the image's title, empty when it has none.
+	Title string
+
+	// Text is the image's alt text.
+	Text string
+
+	// Attributes are the attributes set on the image by other features, such
+	// as width, height and srcset for responsive images.
+	Attributes map[string]string
+
+	// HTML is the image as it is rendered without render hooks.
+	HTML template.HTML
+}
+
+// HeadingData describes a heading to a heading render hook.
+type HeadingData struct {
+	// Level is the heading's level, from 1 to 6, after any heading shift.
+	Level int
+
+	// ID is the heading's id, empty when it has none.
+	ID string
+
+	// Text is the heading text rendered to HTML, with render hooks applied.
+	Text template.HTML
+
+	// PlainText is the heading text without markup.
+	PlainText string
+
+	// Attributes are the heading's attributes other than its id.
+	Attributes map[string]string
+
+	// HTML is the heading as it is rendered without render hooks, with its
+	// permalink anchor if heading anchors are enabled.
+	HTML template.HTML
+}
+
+// CodeBlockData describes a fenced or indented code block to a code block
+// render hook.
+type CodeBlockData struct {
+	// Language is the first word of a fenced block's info string, empty when
+	// there is none.
+	Language string
+
+	// Code is the block's code, as written.
+	Code string
+
+	// Attributes are the options given in a fenced block's info string,
+	// such as title, when code highlighting is enabled.
+	Attributes map[string]string
+
+	// HTML is the block as it is rendered without render hooks, highlighted
+	// when code highlighting is enabled.
+	HTML template.HTML
+}
+
+// RenderHooks replace how links, images, headings and code blocks are
+// rendered. Each hook returns the HTML written in place of its node, which
+// is written as given, so hooks must escape anything taken from their data.
+// An error fails the post. Nil hooks leave their nodes rendered as usual.
+type RenderHooks struct {
+	Link      func(LinkData) (template.HTML, error)
+	Image     func(ImageData) (template.HTML, error)
+	Heading   func(HeadingData) (template.HTML, error)
+	CodeBlock func(CodeBlockData) (template.HTML, error)
+}
+
+// enabled reports whether any hook is set.
+func (h RenderHooks) enabled() bool {
+	return h.Link != nil || h.Image != nil || h.Heading != nil || h.CodeBlock != nil
+}
+
+// String lists the hooks that are set, for cache keys, since functions
+// cannot be compared.
+func (h RenderHooks) String() string {
+	var set []string
+	if h.Link != nil {
+		set = append(set, "link")
+	}
+	if h.Image != nil {
+		set = append(set, "image")
+	}
+	if h.Heading != nil {
+		set = append(set, "heading")
+	}
+	if h.CodeBlock != nil {
+		set = append(set, "codeblock")
+	}
+	return strings.Join(set, ",")
+}
+
+// TemplateRenderHook returns a render hook that executes t with the hook's
+// data, so a link template can use {{.Destination}}, {{.Text}} and so on.
+func TemplateRenderHook[T LinkData | ImageData | HeadingData | CodeBlockData](t *template.Template) func(T) (template.HTML, error) {
+	return func(data T) (template.HTML, error) {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return "", err
+		}
+		return template.HTML(buf.String()), nil
+	}
+}
+
+// nodeAttributes returns the attributes of n, other than those named in
+// skip, as strings.
+func nodeAttributes(n ast.Node, skip ...string) map[string]string {
+	attrs := make(map[string]string)
+	for _, a := range n.Attributes() {
+		name := string(a.Name)
+		if slices.Contains(skip, name) {
+			continue
+		}
+		switch v := a.Value.(type) {
+		case []byte:
+			attrs[name] = string(v)
+		case string:
+			attrs[name] = v
+		default:
+			attrs[name] = fmt.Sprint(v)
+		}
+	}
+	return attrs
+}
+
+// renderHookRenderer renders nodes through RenderHooks. It renders text
+// through the goldmark.Markdown it extends, so that hooks apply inside it,
+// and the HTML of each node through plain, which has no hooks.
+type renderHookRenderer struct {
+	hooks RenderHooks
+	md    goldmark.Markdown // The Markdown the hooks are part of
+	plain goldmark.Markdown // The same Markdown without hooks
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r *renderHookRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	if r.hooks.Link != nil {
+		reg.Register(ast.KindLink, r.renderLink)
+		reg.Register(ast.KindAutoLink, r.renderLink)
+	}
+	if r.hooks.Image != nil {
+		reg.Register(ast.KindImage, r.renderImage)
+	}
+	if r.hooks.Heading != nil {
+		reg.Register(ast.KindHeading, r.renderHeading)
+	}
+	if r.hooks.CodeBlock != nil {
+		reg.Register(ast.KindFencedCodeBlock, r.renderCodeBlock)
+		reg.Register(ast.KindCodeBlock, r.renderCodeBlock)
+	}
+}
+
+// render renders n with md, children included.
+func render(md goldmark.Markdown, source []byte, n ast.Node) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, source, n); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// renderChildren renders the children of n with md.
+func renderChildren(md goldmark.Markdown, source []byte, n ast.Node) (template.HTML, error) {
+	var sb strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		html, err := render(md, source, c)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(string(html))
+	}
+	return template.HTML(sb.String()), nil
+}
+
+// write writes the HTML returned by a hook, or returns its error.
+func write(w util.BufWriter, kind string, html template.HTML, err error) (ast.WalkStatus, error) {
+	if err != nil {
+		return ast.WalkStop, fmt.Errorf("%s render hook: %w", kind, err)
+	}
+	_, _ = w.WriteString(string(html))
+	return ast.WalkSkipChildren, nil
+}
+
+// renderLink renders a link or autolink through the link hook.
+func (r *renderHookRenderer) renderLink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	data := LinkData{Attributes: nodeAttributes(node)}
+	var err error
+	switch n := node.(type) {
+	case *ast.Link:
+		data.Destination = string(n.Destination)
+		data.Title = string(n.Title)
+		if data.Text, err = renderChildren(r.md, source, n); err != nil {
+			return ast.WalkStop, err
+		}
+		data.PlainText = nodeText(n, source)
+	case *ast.AutoLink:
+		label := string(n.Label(source))
+		data.Destination = string(n.URL(source))
+		if n.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(strings.ToLower(data.Destination), "mailto:") {
+			data.Destination = "mailto:" + data.Destination
+		}
+		data.Text = template.HTML(template.HTMLEscapeString(label))
+		data.PlainText = label
+	}
+	if data.HTML, err = render(r.plain, source, node); err != nil {
+		return ast.WalkStop, err
+	}
+	html, err := r.hooks.Link(data)
+	return write(w, "link", html, err)
+}
+
+// renderImage renders an image through the image hook.
+func (r *renderHookRenderer) renderImage(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.Image)
+	data := ImageData{
+		Destination: string(n.Destination),
+		Title:       string(n.Title),
+		Text:        nodeText(n, source),
+		Attributes:  nodeAttributes(n),
+	}
+	var err error
+	if data.HTML, err = render(r.plain, source, n); err != nil {
+		return ast.WalkStop, err
+	}
+	html, err := r.hooks.Image(data)
+	return write(w, "image", html, err)
+}
+
+// renderHeading renders a heading through the heading hook.
+func (r *renderHookRenderer) renderHeading(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.Heading)
+	data := HeadingData{
+		Level:      n.Level,
+		PlainText:  nodeText(n, source),
+		Attributes: nodeAttributes(n, "id"),
+	}
+	if id, ok := n.AttributeString("id"); ok {
+		if b, ok := id.([]byte); ok {
+			data.ID = string(b)
+		}
+	}
+	var err error
+	if data.Text, err = renderChildren(r.md, source, n); err != nil {
+		return ast.WalkStop, err
+	}
+	if data.HTML, err = render(r.plain, source, n); err != nil {
+		return ast.WalkStop, err
+	}
+	html, err := r.hooks.Heading(data)
+	return write(w, "heading", html, err)
+}
+
+// renderCodeBlock renders a fenced or indented code block through the code
+// block hook.
+func (r *renderHookRenderer) renderCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	var code strings.Builder
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		code.Write(seg.Value(source))
+	}
+	data := CodeBlockData{Code: code.String(), Attributes: nodeAttributes(node)}
+	if n, ok := node.(*ast.FencedCodeBlock); ok {
+		data.Language = string(n.Language(source))
+	}
+	var err error
+	if data.HTML, err = render(r.plain, source, node); err != nil {
+		return ast.WalkStop, err
+	}
+	html, err := r.hooks.CodeBlock(data)
+	if err == nil && !strings.HasSuffix(string(html), "\n") {
+		html += "\n"
+	}
+	return write(w, "code block", html, err)
+}
+
+// renderHooks is the goldmark extension enabled by setting any of
+// Config.RenderHooks. plain is the Markdown it is added to, built without it.
+type renderHooks struct {
+	hooks RenderHooks
+	plain goldmark.Markdown
+}
+
+// Extend implements goldmark.Extender.
+func (e renderHooks) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&renderHookRenderer{hooks: e.hooks, md: m, plain: e.plain}, 50),
+	))
+}
@@ -0,0 +1,434 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// HTMLPolicy is the allowlist the sanitiser enabled by WithUnsafeHTML keeps
+// in rendered posts. Elements it does not allow are removed but their
+// content is kept, except for elements such as <script> and <style> whose
+// content is code, which are removed with it. Attributes it does not allow
+// are removed from the elements kept.
+//
+// Whatever the policy allows, <script> elements, on* event handler
+// attributes and URLs with a scheme not in URLSchemes, such as javascript:,
+// are always removed, and a style attribute is only kept when it sets
+// text-align, as goldmark does for table cells.
+type HTMLPolicy struct {
+	// Elements maps the name of each element kept to the attributes it may
+	// have in addition to Attributes.
+	Elements map[string][]string
+
+	// Attributes are the attributes any kept element may have. A name
+	// ending in *, such as "aria-*", allows every attribute it prefixes.
+	Attributes []string
+
+	// URLSchemes are the schemes allowed in URL attributes such as href and
+	// src. Relative URLs are always allowed.
+	URLSchemes []string
+}
+
+// urlAttributes are the attributes whose values are URLs, checked against
+// HTMLPolicy.URLSchemes.
+var urlAttributes = []string{"href", "src", "cite", "poster", "action", "formaction", "background", "longdesc", "xlink:href"}
+
+// textAlign matches the only style attribute values kept.
+var textAlign = regexp.MustCompile(`^\s*text-align:\s*(left|right|center|justify)\s*;?\s*$`)
+
+// rawTextElements are the elements whose content is not markup, and is
+// removed along with them when they are not allowed.
+var rawTextElements = []string{"script", "style", "template", "textarea", "title", "iframe", "noscript", "noembed", "noframes", "xmp", "plaintext"}
+
+// DefaultHTMLPolicy returns the policy used by WithUnsafeHTML when none is
+// set with WithHTMLPolicy. It keeps the markup GoBlog itself renders, such as
+// tables, footnotes, highlighted code, MathML and the youtube shortcode's
+// <iframe>, and the elements authors most often write by hand: <details>,
+// <summary>, <kbd>, <sup>, <sub>, <mark>, <abbr>, <video>, <audio> and
+// <source>.
+//
+// The result is a new value each time, which can be changed freely:
+//
+//	policy := parser.DefaultHTMLPolicy()
+//	policy.Allow("dl")
+//	policy.Allow("dt")
+//	policy.Allow("dd")
+//	p := parser.New(parser.WithUnsafeHTML(), parser.WithHTMLPolicy(policy))
+func DefaultHTMLPolicy() HTMLPolicy {
+	p := HTMLPolicy{
+		Elements:   make(map[string][]string),
+		Attributes: []string{"id", "class", "title", "lang", "dir", "role", "tabindex", "aria-*", "data-*"},
+		URLSchemes: []string{"http", "https", "mailto"},
+	}
+	for _, e := range []string{
+		"p", "br", "hr", "div", "span", "aside", "section", "blockquote", "pre", "code",
+		"h1", "h2", "h3", "h4", "h5", "h6", "ul", "li", "dl", "dt", "dd",
+		"em", "strong", "b", "i", "u", "s", "del", "ins", "small", "mark", "abbr", "cite", "q",
+		"kbd", "samp", "var", "sup", "sub", "figure", "figcaption", "caption",
+		"details", "summary", "table", "thead", "tbody", "tfoot", "tr",
+		// MathML written by WithMath
+		"math", "semantics", "annotation", "mrow", "mi", "mn", "mo", "mtext", "mspace",
+		"mfrac", "msqrt", "mroot", "msub", "msup", "msubsup", "munder", "mover",
+		"munderover", "mstyle", "mtable", "mtr", "mtd",
+		// Inline SVG, as used for heading anchor symbols
+		"g", "circle", "rect", "line", "polyline", "polygon",
+	} {
+		p.Elements[e] = nil
+	}
+	maps.Copy(p.Elements, map[string][]string{
+		"a":       {"href", "rel", "target", "hreflang"},
+		"img":     {"src", "alt", "width", "height", "loading", "decoding", "srcset", "sizes"},
+		"ol":      {"start", "reversed", "type"},
+		"th":      {"align", "style", "colspan", "rowspan", "scope"},
+		"td":      {"align", "style", "colspan", "rowspan"},
+		"input":   {"type", "checked", "disabled"},
+		"button":  {"type"},
+		"time":    {"datetime"},
+		"details": {"open"},
+		"video":   {"src", "poster", "width", "height", "controls", "autoplay", "loop", "muted", "playsinline", "preload"},
+		"audio":   {"src", "controls", "autoplay", "loop", "muted", "preload"},
+		"source":  {"src", "type", "srcset", "sizes", "media"},
+		"track":   {"src", "kind", "srclang", "label", "default"},
+		"iframe":  {"src", "width", "height", "loading", "allow", "allowfullscreen", "referrerpolicy"},
+
+		"math":       {"xmlns", "display"},
+		"annotation": {"encoding"},
+		"mo":         {"fence", "form", "stretchy", "movablelimits", "accent", "minsize", "maxsize", "lspace", "rspace"},
+		"mi":         {"mathvariant"},
+		"mspace":     {"width"},
+		"mfrac":      {"linethickness"},
+		"mover":      {"accent"},
+		"munder":     {"accentunder"},
+		"mstyle":     {"displaystyle", "scriptlevel", "mathvariant"},
+		"mtable":     {"columnalign", "columnspacing", "rowspacing", "displaystyle"},
+
+		"svg":  {"xmlns", "viewbox", "width", "height", "fill", "stroke", "stroke-width", "stroke-linecap", "stroke-linejoin"},
+		"path": {"d", "fill", "stroke", "stroke-width", "stroke-linecap", "stroke-linejoin"},
+	})
+	for _, e := range []string{"g", "circle", "rect", "line", "polyline", "polygon"} {
+		p.Elements[e] = []string{"fill", "stroke", "stroke-width", "cx", "cy", "r", "x", "y", "x1", "y1", "x2", "y2", "width", "height", "points"}
+	}
+	return p
+}
+
+// Allow keeps the element named name, with the attributes attrs in addition
+// to HTMLPolicy.Attributes. Allowing an element again adds to its
+// attributes.
+func (p *HTMLPolicy) Allow(name string, attrs ...string) {
+	if p.Elements == nil {
+		p.Elements = make(map[string][]string)
+	}
+	name = strings.ToLower(name)
+	for _, a := range attrs {
+		if a = strings.ToLower(a); !slices.Contains(p.Elements[name], a) {
+			p.Elements[name] = append(p.Elements[name], a)
+		}
+	}
+	if _, ok := p.Elements[name]; !ok {
+		p.Elements[name] = nil
+	}
+}
+
+// ParseHTMLAllow parses an element to allow, written as its name, such as
+// "dl", optionally followed by the attributes it may have in brackets, such
+// as "video[src controls]".
+func ParseHTMLAllow(spec string) (name string, attrs []string, err error) {
+	spec = strings.TrimSpace(spec)
+	name, rest, bracketed := strings.Cut(spec, "[")
+	if bracketed {
+		list, ok := strings.CutSuffix(rest, "]")
+		if !ok {
+			return "", nil, fmt.Errorf("invalid element %q: missing ]", spec)
+		}
+		attrs = strings.Fields(list)
+	}
+	if !isHTMLName(name) {
+		return "", nil, fmt.Errorf("invalid element %q: want a name such as kbd or video[src controls]", spec)
+	}
+	for _, a := range attrs {
+		if !isHTMLName(strings.TrimSuffix(a, "*")) {
+			return "", nil, fmt.Errorf("invalid attribute %q of element %q", a, name)
+		}
+	}
+	return strings.ToLower(name), attrs, nil
+}
+
+// isHTMLName reports whether s is a non-empty element or attribute name
+// made of letters, digits, hyphens and colons, starting with a letter.
+func isHTMLName(s string) bool {
+	if s == "" || !isASCIILetter(s[0]) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !isASCIILetter(c) && !isDigit(c) && c != '-' && c != ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// String describes p, for cache keys.
+func (p HTMLPolicy) String() string {
+	var sb strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(p.Elements)) {
+		fmt.Fprintf(&sb, "%s%v ", name, p.Elements[name])
+	}
+	fmt.Fprintf(&sb, "%v %v", p.Attributes, p.URLSchemes)
+	return sb.String()
+}
+
+// allowsAttribute reports whether element may have the attribute attr.
+func (p HTMLPolicy) allowsAttribute(element, attr string) bool {
+	if strings.HasPrefix(attr, "on") {
+		return false
+	}
+	for _, a := range slices.Concat(p.Attributes, p.Elements[element]) {
+		if a == attr || (strings.HasSuffix(a, "*") && strings.HasPrefix(attr, a[:len(a)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsURL reports whether the URL u, as written in an attribute, is
+// relative or has one of the policy's schemes.
+func (p HTMLPolicy) allowsURL(u string) bool {
+	// Browsers ignore control characters and whitespace in schemes, as in
+	// "java\tscript:"
+	u = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, html.UnescapeString(u))
+	scheme, _, ok := strings.Cut(u, ":")
+	if !ok || strings.ContainsAny(scheme, "/?#") {
+		return true
+	}
+	return slices.Contains(p.URLSchemes, strings.ToLower(scheme))
+}
+
+// allowsSrcset reports whether every URL in a srcset is allowed.
+func (p HTMLPolicy) allowsSrcset(srcset string) bool {
+	for _, candidate := range strings.Split(html.UnescapeString(srcset), ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 && !p.allowsURL(fields[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Sanitize returns src, an HTML fragment, with every element, attribute and
+// URL the policy does not allow removed. The result is written afresh from
+// what is kept, so that it is read the same way by every browser: attribute
+// values are quoted and escaped, and a < that does not start a tag is
+// escaped. So is the < of a tag or comment that is never closed, so that a
+// stray quote cannot swallow the rest of the fragment.
+func (p HTMLPolicy) Sanitize(src []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(src))
+	for i := 0; i < len(src); {
+		lt := bytes.IndexByte(src[i:], '<')
+		if lt < 0 {
+			out.Write(src[i:])
+			break
+		}
+		out.Write(src[i : i+lt])
+		i += lt
+		rest := src[i:]
+
+		switch {
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			// Comments are removed
+			end := bytes.Index(rest[4:], []byte("-->"))
+			if end < 0 {
+				out.WriteString("&lt;")
+				i++
+				continue
+			}
+			i += 4 + end + 3
+		case len(rest) > 1 && (rest[1] == '!' || rest[1] == '?'):
+			// So are doctypes, CDATA sections and processing instructions
+			end := bytes.IndexByte(rest, '>')
+			if end < 0 {
+				out.WriteString("&lt;")
+				i++
+				continue
+			}
+			i += end + 1
+		case len(rest) > 2 && rest[1] == '/' && isASCIILetter(rest[2]):
+			t, n := parseTag(rest[2:])
+			if n < 0 {
+				out.WriteString("&lt;")
+				i++
+				continue
+			}
+			i += 2 + n
+			if _, ok := p.Elements[t.name]; ok && t.name != "script" {
+				out.WriteString("</" + t.name + ">")
+			}
+		case len(rest) > 1 && isASCIILetter(rest[1]):
+			t, n := parseTag(rest[1:])
+			if n < 0 {
+				out.WriteString("&lt;")
+				i++
+				continue
+			}
+			i += 1 + n
+			if _, ok := p.Elements[t.name]; !ok || t.name == "script" {
+				if slices.Contains(rawTextElements, t.name) && !t.selfClosing {
+					i += skipRawText(src[i:], t.name)
+				}
+				continue
+			}
+			p.writeTag(&out, t)
+		default:
+			out.WriteString("&lt;")
+			i++
+		}
+	}
+	return out.Bytes()
+}
+
+// writeTag writes the start tag t with only the attributes the policy
+// allows.
+func (p HTMLPolicy) writeTag(out *bytes.Buffer, t htmlTag) {
+	out.WriteString("<" + t.name)
+	for _, a := range t.attrs {
+		if !p.allowsAttribute(t.name, a.name) {
+			continue
+		}
+		if slices.Contains(urlAttributes, a.name) && !p.allowsURL(a.value) {
+			continue
+		}
+		if a.name == "style" && !textAlign.MatchString(html.UnescapeString(a.value)) {
+			continue
+		}
+		if a.name == "srcset" && !p.allowsSrcset(a.value) {
+			continue
+		}
+		out.WriteString(" " + a.name)
+		if a.hasValue {
+			out.WriteString(`="` + escapeAttribute(html.UnescapeString(a.value)) + `"`)
+		}
+	}
+	if t.selfClosing {
+		out.WriteString(" /")
+	}
+	out.WriteByte('>')
+}
+
+// escapeAttribute escapes s for use in a double-quoted attribute value, in
+// the same way goldmark does.
+func escapeAttribute(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
+
+// htmlTag is a start or end tag read by parseTag.
+type htmlTag struct {
+	name        string // Lower case
+	attrs       []htmlAttribute
+	selfClosing bool
+}
+
+// htmlAttribute is an attribute of an htmlTag.
+type htmlAttribute struct {
+	name     string // Lower case
+	value    string // As written, with entities
+	hasValue bool
+}
+
+// parseTag reads the tag whose name begins b, just after its < or </, and
+// returns it with the number of bytes up to and including its >. It returns
+// -1 when the tag is not closed.
+func parseTag(b []byte) (htmlTag, int) {
+	var t htmlTag
+	i := 0
+	for i < len(b) && !isTagSpace(b[i]) && b[i] != '/' && b[i] != '>' {
+		i++
+	}
+	t.name = strings.ToLower(string(b[:i]))
+
+	for {
+		for i < len(b) && (isTagSpace(b[i]) || b[i] == '/') {
+			t.selfClosing = b[i] == '/'
+			i++
+		}
+		if i >= len(b) {
+			return t, -1
+		}
+		if b[i] == '>' {
+			return t, i + 1
+		}
+		t.selfClosing = false
+
+		start := i
+		for i < len(b) && !isTagSpace(b[i]) && b[i] != '/' && b[i] != '>' && (b[i] != '=' || i == start) {
+			i++
+		}
+		a := htmlAttribute{name: strings.ToLower(string(b[start:i]))}
+		for i < len(b) && isTagSpace(b[i]) {
+			i++
+		}
+		if i < len(b) && b[i] == '=' {
+			i++
+			for i < len(b) && isTagSpace(b[i]) {
+				i++
+			}
+			a.hasValue = true
+			if i < len(b) && (b[i] == '"' || b[i] == '\'') {
+				end := bytes.IndexByte(b[i+1:], b[i])
+				if end < 0 {
+					return t, -1
+				}
+				a.value = string(b[i+1 : i+1+end])
+				i += end + 2
+			} else {
+				start := i
+				for i < len(b) && !isTagSpace(b[i]) && b[i] != '>' {
+					i++
+				}
+				a.value = string(b[start:i])
+			}
+		}
+		t.attrs = append(t.attrs, a)
+	}
+}
+
+// isTagSpace reports whether c separates the parts of a tag.
+func isTagSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// skipRawText returns the length of b up to and including the end tag of the
+// raw text element name, or 0 when it has none, so that the text after an
+// unclosed element is kept rather than lost.
+func skipRawText(b []byte, name string) int {
+	// The end tag is matched in b itself, since lower-casing can change the
+	// length of other characters and so the offsets found
+	for i := 0; ; {
+		at := bytes.Index(b[i:], []byte("</"))
+		if at < 0 {
+			return 0
+		}
+		start := i + at + 2
+		end := start + len(name)
+		if end <= len(b) && bytes.EqualFold(b[start:end], []byte(name)) &&
+			(end == len(b) || isTagSpace(b[end]) || b[end] == '/' || b[end] == '>') {
+			if gt := bytes.IndexByte(b[end:], '>'); gt >= 0 {
+				return end + gt + 1
+			}
+			return end
+		}
+		i = start
+	}
+}
@@ -0,0 +1,263 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrMissingKey is wrapped by the SchemaError of a required front matter key
+// that a post does not set.
+var ErrMissingKey = errors.New("required key is missing")
+
+// SchemaError reports a front matter value that breaks a rule of the Schema
+// set with WithSchema.
+type SchemaError struct {
+	Key string // The front matter key
+	Err error  // What is wrong with its value
+}
+
+func (se SchemaError) Error() string {
+	return fmt.Sprintf("front matter %q: %v", se.Key, se.Err)
+}
+
+func (se SchemaError) Unwrap() error {
+	return se.Err
+}
+
+// FieldType is the type of value a SchemaField accepts.
+type FieldType string
+
+const (
+	FieldString FieldType = "string" // Text
+	FieldInt    FieldType = "int"    // A whole number
+	FieldNumber FieldType = "number" // Any number
+	FieldBool   FieldType = "bool"   // true or false
+	FieldDate   FieldType = "date"   // A date, such as 2024-01-02, or an RFC 3339 time
+	FieldList   FieldType = "list"   // A list of values
+)
+
+// fieldTypes are the FieldType values a SchemaField may have.
+var fieldTypes = []FieldType{FieldString, FieldInt, FieldNumber, FieldBool, FieldDate, FieldList}
+
+// SchemaField holds the rules for the value of one front matter key. Rules
+// other than Required apply only when the key is set.
+type SchemaField struct {
+	// Required fails posts that do not set the key, or set it to null.
+	Required bool `yaml:"required"`
+
+	// Type is the type of value accepted. Empty accepts any type.
+	Type FieldType `yaml:"type"`
+
+	// Enum lists the values accepted. Each item of a list must be one of
+	// them. Empty accepts any value.
+	Enum []string `yaml:"enum"`
+
+	// MinLength and MaxLength bound the number of characters in a string,
+	// or of items in a list. Zero means no bound.
+	MinLength int `yaml:"minLength"`
+	MaxLength int `yaml:"maxLength"`
+
+	// Pattern is a regular expression, in the syntax of package regexp,
+	// that a string, or each item of a list, must match. It is not anchored,
+	// so use ^ and $ to match the whole value.
+	Pattern string `yaml:"pattern"`
+}
+
+// Schema describes the front matter posts must have, on top of the title,
+// date and description models.Post.Validate requires. It is usually read
+// from a schema.yaml file with ParseSchema:
+//
+//	fields:
+//	  author:
+//	    required: true
+//	    type: string
+//	    minLength: 2
+//	  tags:
+//	    type: list
+//	    enum: [go, web, release]
+//	  description:
+//	    maxLength: 160
+//	  ticket:
+//	    pattern: ^[A-Z]+-[0-9]+$
+type Schema struct {
+	// Fields maps front matter keys to the rules for their values. Keys
+	// that are not listed are not checked.
+	Fields map[string]SchemaField `yaml:"fields"`
+}
+
+// ParseSchema reads a Schema written in YAML, as in the example of Schema,
+// and checks it with Schema.Check. Unknown keys are an error, so that a
+// misspelt rule is not silently ignored.
+func ParseSchema(data []byte) (Schema, error) {
+	var s Schema
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil && !errors.Is(err, io.EOF) {
+		return Schema{}, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := s.Check(); err != nil {
+		return Schema{}, err
+	}
+	return s, nil
+}
+
+// Check reports the first rule of s that cannot be applied: an unknown
+// type, a pattern that does not compile, or length bounds that are negative
+// or the wrong way round.
+func (s Schema) Check() error {
+	for _, key := range slices.Sorted(maps.Keys(s.Fields)) {
+		f := s.Fields[key]
+		if f.Type != "" && !slices.Contains(fieldTypes, f.Type) {
+			return fmt.Errorf("invalid schema for %q: unknown type %q (want one of string, int, number, bool, date, list)", key, f.Type)
+		}
+		if f.MinLength < 0 || f.MaxLength < 0 || (f.MaxLength > 0 && f.MinLength > f.MaxLength) {
+			return fmt.Errorf("invalid schema for %q: bad length bounds %d to %d", key, f.MinLength, f.MaxLength)
+		}
+		if _, err := regexp.Compile(f.Pattern); err != nil {
+			return fmt.Errorf("invalid schema for %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// validate checks fields, a post's decoded front matter, against s and
+// returns a SchemaError for each broken rule, in key order.
+func (s Schema) validate(fields map[string]any) []error {
+	var errs []error
+	for _, key := range slices.Sorted(maps.Keys(s.Fields)) {
+		for _, err := range s.Fields[key].validate(fields[key]) {
+			errs = append(errs, SchemaError{Key: key, Err: err})
+		}
+	}
+	return errs
+}
+
+// validate returns an error for each rule of f that value breaks. A nil
+// value is a key that is not set.
+func (f SchemaField) validate(value any) []error {
+	if value == nil {
+		if f.Required {
+			return []error{ErrMissingKey}
+		}
+		return nil
+	}
+	if f.Type != "" && !f.Type.accepts(value) {
+		return []error{fmt.Errorf("want %s, got %s", f.Type, typeOf(value))}
+	}
+
+	var errs []error
+	length := -1
+	switch v := value.(type) {
+	case string:
+		length = utf8.RuneCountInString(v)
+	case []any:
+		length = len(v)
+	}
+	if length >= 0 && length < f.MinLength {
+		errs = append(errs, fmt.Errorf("length %d is less than %d", length, f.MinLength))
+	}
+	if length >= 0 && f.MaxLength > 0 && length > f.MaxLength {
+		errs = append(errs, fmt.Errorf("length %d is more than %d", length, f.MaxLength))
+	}
+
+	items, isList := value.([]any)
+	if !isList {
+		items = []any{value}
+	}
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			str = fmt.Sprint(item)
+		}
+		if len(f.Enum) > 0 && !slices.Contains(f.Enum, str) {
+			errs = append(errs, fmt.Errorf("%q is not one of %s", str, strings.Join(f.Enum, ", ")))
+		}
+		if f.Pattern != "" {
+			// Patterns are checked by Schema.Check, but a Schema may be built
+			// in Go without it
+			re, err := regexp.Compile(f.Pattern)
+			if err != nil {
+				errs = append(errs, err)
+			} else if !re.MatchString(str) {
+				errs = append(errs, fmt.Errorf("%q does not match %s", str, f.Pattern))
+			}
+		}
+	}
+	return errs
+}
+
+// accepts reports whether value, decoded from YAML, TOML or JSON front
+// matter, is of type t.
+func (t FieldType) accepts(value any) bool {
+	switch t {
+	case FieldString:
+		_, ok := value.(string)
+		return ok
+	case FieldInt:
+		switch v := value.(type) {
+		case int, int64, uint64:
+			return true
+		case float64:
+			return v == float64(int64(v))
+		}
+	case FieldNumber:
+		switch value.(type) {
+		case int, int64, uint64, float64:
+			return true
+		}
+	case FieldBool:
+		_, ok := value.(bool)
+		return ok
+	case FieldDate:
+		switch v := value.(type) {
+		case time.Time:
+			return true
+		case string:
+			if _, err := time.Parse(time.DateOnly, v); err == nil {
+				return true
+			}
+			_, err := time.Parse(time.RFC3339, v)
+			return err == nil
+		}
+	case FieldList:
+		_, ok := value.([]any)
+		return ok
+	}
+	return false
+}
+
+// typeOf names the type of value, decoded from front matter, for error
+// messages.
+func typeOf(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case int, int64, uint64:
+		return "int"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	case time.Time:
+		return "date"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,750 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/models"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// ErrUnknownShortcode is wrapped in the ShortcodeError reported for a
+// shortcode that has no handler.
+var ErrUnknownShortcode = errors.New("unknown shortcode")
+
+// ShortcodeError reports a shortcode that could not be expanded. It is
+// wrapped in a FileError whose Path is the file containing the shortcode.
+type ShortcodeError struct {
+	Line int    // Line of the file on which the shortcode starts
+	Name string // Name of the shortcode, empty if the tag has none
+	Err  error  // Why the shortcode could not be expanded
+}
+
+// Error implements the error interface.
+func (se ShortcodeError) Error() string {
+	return fmt.Sprintf("line %d: shortcode %q: %v", se.Line, se.Name, se.Err)
+}
+
+// Unwrap returns the underlying error for error wrapping support.
+func (se ShortcodeError) Unwrap() error {
+	return se.Err
+}
+
+// Shortcode is one use of a shortcode in a post, as passed to its handler.
+type Shortcode struct {
+	// Name is the name of the shortcode, such as "figure".
+	Name string
+
+	// Args are the arguments given without a name, in order, with their
+	// quotes removed.
+	Args []string
+
+	// Params are the arguments given as name=value, with their quotes
+	// removed.
+	Params map[string]string
+
+	// Paired reports whether the shortcode has a closing tag, as in
+	// {{< details >}}...{{< /details >}}.
+	Paired bool
+
+	// Inner is the markdown between the opening and closing tags of a paired
+	// shortcode, as written.
+	Inner string
+
+	// InnerHTML is Inner rendered to HTML, with any shortcodes inside it
+	// expanded. Markdown that renders to a single paragraph is unwrapped
+	// from its <p>, so that shortcodes used within a line stay inline.
+	InnerHTML template.HTML
+
+	// Post is the post containing the shortcode. Its front matter and slug
+	// are set; its content is not.
+	Post *models.Post
+
+	// Line is the line of the file on which the shortcode starts.
+	Line int
+
+	base string // Where the post's bundle assets are published
+}
+
+// Get returns the value of the named parameter, or the empty string when it
+// was not given.
+func (s Shortcode) Get(name string) string {
+	return s.Params[name]
+}
+
+// Arg returns the i-th argument given without a name, or the empty string
+// when there are not that many.
+func (s Shortcode) Arg(i int) string {
+	if i < 0 || i >= len(s.Args) {
+		return ""
+	}
+	return s.Args[i]
+}
+
+// Resolve returns the URL a page bundle asset named relatively by ref is
+// published at, such as /posts/my-post/cat.jpg for cat.jpg. Any other
+// reference is returned unchanged.
+func (s Shortcode) Resolve(ref string) string {
+	if s.Post == nil || !s.Post.IsBundle() {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+		return ref
+	}
+	asset := path.Clean(u.Path)
+	if !slices.Contains(s.Post.Assets, asset) {
+		return ref
+	}
+	out := &url.URL{Path: s.base + asset, RawQuery: u.RawQuery, Fragment: u.Fragment}
+	return out.String()
+}
+
+// ShortcodeFunc renders a shortcode to HTML. The HTML is written into the
+// post as given, so handlers must escape anything taken from the shortcode.
+// An error fails the post with a ShortcodeError.
+type ShortcodeFunc func(sc Shortcode) (template.HTML, error)
+
+// TemplateShortcode returns a ShortcodeFunc that executes t with the
+// Shortcode as its data, so the template can use {{.Get "src"}},
+// {{.Arg 0}}, {{.Resolve (.Get "src")}}, {{.InnerHTML}} and {{.Post.Title}}.
+func TemplateShortcode(t *template.Template) ShortcodeFunc {
+	return func(sc Shortcode) (template.HTML, error) {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, sc); err != nil {
+			return "", err
+		}
+		return template.HTML(buf.String()), nil
+	}
+}
+
+// builtinShortcodeTemplates holds the markup of the built-in shortcodes.
+var builtinShortcodeTemplates = template.Must(template.New("shortcodes").Parse(`
+{{- define "youtube" -}}
+<div class="shortcode-youtube"><iframe src="https://www.youtube-nocookie.com/embed/{{.ID}}{{with .Start}}?start={{.}}{{end}}" title="{{.Title}}" loading="lazy" allow="accelerometer; clipboard-write; encrypted-media; gyroscope; picture-in-picture" referrerpolicy="strict-origin-when-cross-origin" allowfullscreen></iframe></div>
+{{- end -}}
+{{- define "figure" -}}
+<figure{{with .Class}} class="{{.}}"{{end}}>
+{{- if .Link}}<a href="{{.Link}}">{{end -}}
+<img src="{{.Src}}" alt="{{.Alt}}"{{with .Width}} width="{{.}}"{{end}}{{with .Height}} height="{{.}}"{{end}} loading="lazy" />
+{{- if .Link}}</a>{{end -}}
+{{with .Caption}}<figcaption>{{.}}</figcaption>{{end -}}
+</figure>
+{{- end -}}
+{{- define "details" -}}
+<details{{if .Open}} open{{end}}><summary>{{.Summary}}</summary>
+{{.Inner}}</details>
+{{- end -}}
+`))
+
+// youtubeID matches the form of a YouTube video id.
+var youtubeID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// BuiltinShortcodes returns the shortcodes available whenever shortcodes are
+// enabled:
+//
+//	{{< youtube id [title="..."] [start=seconds] >}}
+//	    a privacy-enhanced, lazily loaded YouTube embed
+//	{{< figure src="..." [alt="..."] [caption="..."] [link="..."] [class="..."] [width=n] [height=n] >}}
+//	    an image in a <figure>, with src resolved against the page bundle
+//	{{< details ["summary"] [open=true] >}}...{{< /details >}}
+//	    a collapsible <details> element around the rendered inner markdown
+//
+// Handlers registered under the same names replace them.
+func BuiltinShortcodes() map[string]ShortcodeFunc {
+	return map[string]ShortcodeFunc{
+		"youtube": youtubeShortcode,
+		"figure":  figureShortcode,
+		"details": detailsShortcode,
+	}
+}
+
+// executeBuiltin renders the built-in template name with data.
+func executeBuiltin(name string, data any) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := builtinShortcodeTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// youtubeShortcode renders {{< youtube >}}.
+func youtubeShortcode(sc Shortcode) (template.HTML, error) {
+	id := cmp.Or(sc.Get("id"), sc.Arg(0))
+	if id == "" {
+		return "", errors.New("missing video id")
+	}
+	if !youtubeID.MatchString(id) {
+		return "", fmt.Errorf("invalid video id %q", id)
+	}
+	start := sc.Get("start")
+	if start != "" {
+		if n, err := strconv.Atoi(start); err != nil || n < 0 {
+			return "", fmt.Errorf("invalid start %q: want a number of seconds", start)
+		}
+	}
+	return executeBuiltin("youtube", struct{ ID, Title, Start string }{
+		ID:    id,
+		Title: cmp.Or(sc.Get("title"), "YouTube video"),
+		Start: start,
+	})
+}
+
+// figureShortcode renders {{< figure >}}.
+func figureShortcode(sc Shortcode) (template.HTML, error) {
+	src := cmp.Or(sc.Get("src"), sc.Arg(0))
+	if src == "" {
+		return "", errors.New("missing src")
+	}
+	for _, dim := range []string{"width", "height"} {
+		if v := sc.Get(dim); v != "" {
+			if n, err := strconv.Atoi(v); err != nil || n <= 0 {
+				return "", fmt.Errorf("invalid %s %q: want a number of pixels", dim, v)
+			}
+		}
+	}
+	caption := sc.Get("caption")
+	link := sc.Get("link")
+	if link != "" {
+		link = sc.Resolve(link)
+	}
+	return executeBuiltin("figure", struct{ Src, Alt, Caption, Link, Class, Width, Height string }{
+		Src:     sc.Resolve(src),
+		Alt:     cmp.Or(sc.Get("alt"), caption),
+		Caption: caption,
+		Link:    link,
+		Class:   sc.Get("class"),
+		Width:   sc.Get("width"),
+		Height:  sc.Get("height"),
+	})
+}
+
+// detailsShortcode renders {{< details >}}...{{< /details >}}.
+func detailsShortcode(sc Shortcode) (template.HTML, error) {
+	open := false
+	if v := sc.Get("open"); v != "" {
+		var err error
+		if open, err = strconv.ParseBool(v); err != nil {
+			return "", fmt.Errorf("invalid open %q: want true or false", v)
+		}
+	}
+	return executeBuiltin("details", struct {
+		Summary string
+		Open    bool
+		Inner   template.HTML
+	}{
+		Summary: cmp.Or(sc.Get("summary"), sc.Arg(0), "Details"),
+		Open:    open,
+		Inner:   sc.InnerHTML,
+	})
+}
+
+// shortcodeTag is a {{< ... >}} tag found in markdown source.
+type shortcodeTag struct {
+	start, end int // Offsets of the tag's {{< and the end of its >}}
+
+	name        string
+	args        []string
+	params      map[string]string
+	closing     bool   // {{< /name >}}
+	selfClosing bool   // {{< name ... / >}}
+	escaped     string // The literal text of a {{</* name */>}} tag, or empty
+	close       int    // Index of the matching closing tag, or -1
+}
+
+// scanShortcodes returns the shortcode tags in source, skipping those that
+// start inside one of the ranges in skip. line gives the line of the file an
+// offset of source falls on, for errors.
+func scanShortcodes(source []byte, skip [][2]int, line func(int) int) ([]shortcodeTag, error) {
+	var tags []shortcodeTag
+	for i := 0; ; {
+		at := bytes.Index(source[i:], []byte("{{<"))
+		if at < 0 {
+			return tags, nil
+		}
+		start := i + at
+		if slices.ContainsFunc(skip, func(r [2]int) bool { return start >= r[0] && start < r[1] }) {
+			i = start + 3
+			continue
+		}
+		length := bytes.Index(source[start+3:], []byte(">}}"))
+		if length < 0 {
+			return nil, ShortcodeError{Line: line(start), Err: errors.New("unterminated tag: missing >}}")}
+		}
+		body := string(source[start+3 : start+3+length])
+		tag := shortcodeTag{start: start, end: start + 3 + length + 3, close: -1}
+		i = tag.end
+
+		body = strings.NewReplacer("\r", " ", "\n", " ").Replace(body)
+		trimmed := strings.TrimSpace(body)
+		switch {
+		case strings.HasPrefix(trimmed, "/*") && strings.HasSuffix(trimmed, "*/"):
+			// {{</* name */>}} is how a post shows a shortcode tag as text
+			inner := strings.Replace(body, "/*", "", 1)
+			cut := strings.LastIndex(inner, "*/")
+			tag.escaped = "{{<" + inner[:cut] + inner[cut+2:] + ">}}"
+			tags = append(tags, tag)
+			continue
+		case strings.HasPrefix(trimmed, "/"):
+			tag.closing = true
+			trimmed = strings.TrimSpace(trimmed[1:])
+		case strings.HasSuffix(trimmed, "/"):
+			tag.selfClosing = true
+			trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, "/"))
+		}
+
+		fields := infoFields(trimmed)
+		if len(fields) == 0 {
+			return nil, ShortcodeError{Line: line(start), Err: errors.New("missing shortcode name")}
+		}
+		tag.name = fields[0]
+		if tag.closing && len(fields) > 1 {
+			return nil, ShortcodeError{Line: line(start), Name: tag.name, Err: errors.New("closing tag cannot have arguments")}
+		}
+		for _, f := range fields[1:] {
+			if k, v, ok := strings.Cut(f, "="); ok && k != "" && !strings.ContainsAny(k, `"'`) {
+				if tag.params == nil {
+					tag.params = make(map[string]string)
+				}
+				tag.params[k] = unquote(v)
+			} else {
+				tag.args = append(tag.args, unquote(f))
+			}
+		}
+		tags = append(tags, tag)
+	}
+}
+
+// pairShortcodes matches each closing tag with the nearest open tag of the
+// same name, setting its close index. Open tags left without one stand
+// alone.
+func pairShortcodes(tags []shortcodeTag, line func(int) int) error {
+	var open []int
+	for i, t := range tags {
+		switch {
+		case t.escaped != "" || t.selfClosing:
+		case t.closing:
+			k := len(open) - 1
+			for k >= 0 && tags[open[k]].name != t.name {
+				k--
+			}
+			if k < 0 {
+				return ShortcodeError{Line: line(t.start), Name: t.name, Err: errors.New("closing tag has no opening tag")}
+			}
+			tags[open[k]].close = i
+			open = open[:k]
+		default:
+			open = append(open, i)
+		}
+	}
+	return nil
+}
+
+// codeRanges returns the ranges of source held by code blocks and code spans
+// in doc, where shortcodes are left as written.
+func codeRanges(doc ast.Node) [][2]int {
+	var ranges [][2]int
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.Kind() {
+		case ast.KindFencedCodeBlock, ast.KindCodeBlock:
+			if lines := n.Lines(); lines.Len() > 0 {
+				ranges = append(ranges, [2]int{lines.At(0).Start, lines.At(lines.Len() - 1).Stop})
+			}
+			return ast.WalkSkipChildren, nil
+		case ast.KindCodeSpan:
+			first, last := n.FirstChild(), n.LastChild()
+			if first != nil {
+				ft, fok := first.(*ast.Text)
+				lt, lok := last.(*ast.Text)
+				if fok && lok {
+					ranges = append(ranges, [2]int{ft.Segment.Start, lt.Segment.Stop})
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return ranges
+}
+
+// frontMatterEnd returns the length of the YAML or TOML front matter that
+// source opens with, or 0 if it has none.
+func frontMatterEnd(source []byte) int {
+	delim := ""
+	switch {
+	case bytes.HasPrefix(source, []byte("---")):
+		delim = "---"
+	case bytes.HasPrefix(source, []byte("+++")):
+		delim = "+++"
+	default:
+		return 0
+	}
+	offset := bytes.IndexByte(source, '\n') + 1
+	if offset == 0 {
+		return 0
+	}
+	for offset < len(source) {
+		end := bytes.IndexByte(source[offset:], '\n')
+		lineEnd := len(source)
+		if end >= 0 {
+			lineEnd = offset + end + 1
+		}
+		if string(bytes.TrimRight(source[offset:lineEnd], " \t\r\n")) == delim {
+			return lineEnd
+		}
+		offset = lineEnd
+	}
+	return 0
+}
+
+// shortcodePlaceholder marks an expanded shortcode in markdown source, as
+// {␚index␚}. The SUB character (␚) does not occur in text, and goldmark only
+// tries inline parsers at punctuation, hence the braces.
+const shortcodePlaceholder = '\x1a'
+
+// expandShortcodes replaces each shortcode in source, outside code and front
+// matter, with a placeholder for the HTML its handler renders, and returns
+// the new source with the HTML of each placeholder. Placeholders are
+// followed by the newlines of the shortcode they replace, so that lines keep
+// their numbers.
+//
+// It returns a ShortcodeError for each shortcode that cannot be expanded,
+// with line numbers counted from firstLine, the line of the file on which
+// source begins.
+func (p *Parser) expandShortcodes(fsys fs.FS, doc ast.Node, source []byte, firstLine int, post *models.Post, ids *documentIDs) ([]byte, []template.HTML, error) {
+	line := func(offset int) int {
+		return firstLine + bytes.Count(source[:offset], []byte("\n"))
+	}
+	skip := codeRanges(doc)
+	if end := frontMatterEnd(source); end > 0 {
+		skip = append(skip, [2]int{0, end})
+	}
+	tags, err := scanShortcodes(source, skip, line)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tags) == 0 {
+		return source, nil, nil
+	}
+	if err := pairShortcodes(tags, line); err != nil {
+		return nil, nil, err
+	}
+
+	var out bytes.Buffer
+	var rendered []template.HTML
+	var errs []error
+	pos := 0
+	for i := 0; i < len(tags); i++ {
+		t := tags[i]
+		out.Write(source[pos:t.start])
+		if t.escaped != "" {
+			out.WriteString(t.escaped)
+			pos = t.end
+			continue
+		}
+
+		sc := Shortcode{
+			Name:   t.name,
+			Args:   t.args,
+			Params: t.params,
+			Post:   post,
+			Line:   line(t.start),
+			base:   p.config.BlogRoot + "posts/" + post.Slug + "/",
+		}
+		end := t.end
+		if t.close >= 0 {
+			closing := tags[t.close]
+			inner := source[t.end:closing.start]
+			sc.Paired = true
+			sc.Inner = string(inner)
+			html, err := p.renderFragment(fsys, inner, line(t.end), post, ids)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			sc.InnerHTML = html
+			end = closing.end
+			i = t.close
+		}
+
+		handler, ok := p.shortcodes[t.name]
+		if !ok {
+			errs = append(errs, ShortcodeError{Line: sc.Line, Name: t.name, Err: ErrUnknownShortcode})
+		} else if html, err := handler(sc); err != nil {
+			errs = append(errs, ShortcodeError{Line: sc.Line, Name: t.name, Err: err})
+		} else {
+			fmt.Fprintf(&out, "{%c%d%c}", shortcodePlaceholder, len(rendered), shortcodePlaceholder)
+			rendered = append(rendered, html)
+		}
+		out.Write(bytes.Repeat([]byte("\n"), bytes.Count(source[t.start:end], []byte("\n"))))
+		pos = end
+	}
+	out.Write(source[pos:])
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	return out.Bytes(), rendered, nil
+}
+
+// renderFragment renders source, the inner markdown of a paired shortcode
+// starting on line firstLine of the file, with the same transformations as
+// the rest of the post. A single paragraph is returned without its <p>.
+//
+// Its headings take their IDs from ids, and its footnotes are given an ID
+// prefix of their own, so that neither clashes with the rest of the post.
+func (p *Parser) renderFragment(fsys fs.FS, source []byte, firstLine int, post *models.Post, ids *documentIDs) (template.HTML, error) {
+	doc := p.md.Parser().Parse(text.NewReader(source), parser.WithContext(ids.context()))
+	doc, source, err := p.transform(fsys, doc, source, firstLine, post, ids)
+	if err != nil {
+		return "", err
+	}
+	ids.fragments++
+	doc.SetAttributeString(footnotePrefixAttribute, []byte("sc"+strconv.Itoa(ids.fragments)+"-"))
+	var buf bytes.Buffer
+	if err := p.md.Renderer().Render(&buf, source, doc); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+	html := buf.Bytes()
+	if doc.ChildCount() == 1 && doc.FirstChild().Kind() == ast.KindParagraph {
+		html = bytes.TrimSuffix(bytes.TrimPrefix(html, []byte("<p>")), []byte("</p>\n"))
+	}
+	return template.HTML(html), nil
+}
+
+// reparseShortcodes parses source, produced by expandShortcodes, and sets
+// the HTML of each shortcode node from rendered. A shortcode that is the
+// only thing in its paragraph replaces the paragraph, so that block-level
+// HTML is not wrapped in a <p>.
+//
+// Placeholders in raw HTML, which goldmark does not parse, are replaced in
+// its text. Heading IDs are taken from ids, leaving out placeholders.
+func (p *Parser) reparseShortcodes(source []byte, rendered []template.HTML, ids *documentIDs) ast.Node {
+	doc := p.md.Parser().Parse(text.NewReader(source), parser.WithContext(ids.context()))
+	var alone, raw []ast.Node
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.HTMLBlock, *ast.RawHTML:
+			raw = append(raw, n)
+			return ast.WalkSkipChildren, nil
+		case *shortcodeInline:
+			if n.index < len(rendered) {
+				n.HTML = string(rendered[n.index])
+			}
+			if parent := n.Parent(); parent.Kind() == ast.KindParagraph && parent.ChildCount() == 1 {
+				alone = append(alone, parent)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	for _, para := range alone {
+		block := &shortcodeBlock{HTML: para.FirstChild().(*shortcodeInline).HTML}
+		para.Parent().ReplaceChild(para.Parent(), para, block)
+	}
+
+	// Raw HTML is left out unless it is enabled, placeholders and all
+	if !p.config.EnableUnsafeHTML {
+		return doc
+	}
+	for _, n := range raw {
+		var value []byte
+		switch n := n.(type) {
+		case *ast.HTMLBlock:
+			for i := 0; i < n.Lines().Len(); i++ {
+				line := n.Lines().At(i)
+				value = append(value, line.Value(source)...)
+			}
+			if n.HasClosure() {
+				value = append(value, n.ClosureLine.Value(source)...)
+			}
+		case *ast.RawHTML:
+			for i := 0; i < n.Segments.Len(); i++ {
+				segment := n.Segments.At(i)
+				value = append(value, segment.Value(source)...)
+			}
+		}
+		if !placeholderPattern.Match(value) {
+			continue
+		}
+		html := placeholderPattern.ReplaceAllFunc(value, func(m []byte) []byte {
+			index, _ := strconv.Atoi(string(m[2 : len(m)-2]))
+			if index < len(rendered) {
+				return []byte(rendered[index])
+			}
+			return nil
+		})
+		if n.Type() == ast.TypeBlock {
+			n.Parent().ReplaceChild(n.Parent(), n, &shortcodeBlock{HTML: strings.TrimSuffix(string(html), "\n")})
+		} else {
+			n.Parent().ReplaceChild(n.Parent(), n, &shortcodeInline{HTML: string(html)})
+		}
+	}
+	return doc
+}
+
+// placeholderPattern matches the placeholders left by expandShortcodes.
+var placeholderPattern = regexp.MustCompile("\\{\x1a[0-9]+\x1a\\}")
+
+// documentIDs holds what a post shares with the markdown inside its paired
+// shortcodes, which is parsed and rendered on its own, so that heading and
+// footnote IDs are unique across the page.
+type documentIDs struct {
+	ids       parser.IDs // Heading IDs, shared by every parse
+	fragments int        // Number of fragments rendered, for footnote ID prefixes
+}
+
+// newDocumentIDs returns the documentIDs of a post with no IDs taken yet.
+func newDocumentIDs() *documentIDs {
+	return &documentIDs{ids: shortcodeIDs{parser.NewContext().IDs()}}
+}
+
+// context returns a parser context that generates heading IDs from d.
+func (d *documentIDs) context() parser.Context {
+	return parser.NewContext(parser.WithIDs(d.ids))
+}
+
+// footnotePrefixAttribute is the document attribute holding the prefix of
+// the footnote IDs of a rendered fragment.
+const footnotePrefixAttribute = "footnotePrefix"
+
+// footnoteIDPrefix returns the prefix of the IDs of the footnote node n,
+// which is empty except within the fragment of a paired shortcode.
+func footnoteIDPrefix(n ast.Node) []byte {
+	if doc := n.OwnerDocument(); doc != nil {
+		if prefix, ok := doc.AttributeString(footnotePrefixAttribute); ok {
+			return prefix.([]byte)
+		}
+	}
+	return nil
+}
+
+// shortcodeIDs generates heading IDs from the text of headings without the
+// placeholders of the shortcodes in them, which would otherwise add their
+// index to the ID.
+type shortcodeIDs struct {
+	parser.IDs
+}
+
+// Generate implements parser.IDs.
+func (ids shortcodeIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	return ids.IDs.Generate(placeholderPattern.ReplaceAll(value, nil), kind)
+}
+
+// kindShortcode is the AST node kind of a shortcode used within a line.
+var kindShortcode = ast.NewNodeKind("Shortcode")
+
+// kindShortcodeBlock is the AST node kind of a shortcode standing alone in
+// its paragraph.
+var kindShortcodeBlock = ast.NewNodeKind("ShortcodeBlock")
+
+// shortcodeInline is an expanded shortcode within a line of text.
+type shortcodeInline struct {
+	ast.BaseInline
+	HTML  string
+	index int // Index of the shortcode's HTML, from its placeholder
+}
+
+// Kind implements ast.Node.
+func (n *shortcodeInline) Kind() ast.NodeKind {
+	return kindShortcode
+}
+
+// Dump implements ast.Node.
+func (n *shortcodeInline) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Index": strconv.Itoa(n.index)}, nil)
+}
+
+// shortcodeBlock is an expanded shortcode that stands alone in its
+// paragraph.
+type shortcodeBlock struct {
+	ast.BaseBlock
+	HTML string
+}
+
+// Kind implements ast.Node.
+func (n *shortcodeBlock) Kind() ast.NodeKind {
+	return kindShortcodeBlock
+}
+
+// Dump implements ast.Node.
+func (n *shortcodeBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// shortcodeParser parses the placeholders left by expandShortcodes.
+type shortcodeParser struct{}
+
+// Trigger implements parser.InlineParser.
+func (shortcodeParser) Trigger() []byte {
+	return []byte{'{'}
+}
+
+// Parse implements parser.InlineParser.
+func (shortcodeParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if len(line) < 2 || line[1] != shortcodePlaceholder {
+		return nil
+	}
+	end := bytes.Index(line[2:], []byte{shortcodePlaceholder, '}'})
+	if end <= 0 {
+		return nil
+	}
+	index, err := strconv.Atoi(string(line[2 : 2+end]))
+	if err != nil || index < 0 {
+		return nil
+	}
+	block.Advance(2 + end + 2)
+	return &shortcodeInline{index: index}
+}
+
+// shortcodeRenderer writes the HTML of expanded shortcodes.
+type shortcodeRenderer struct{}
+
+// RegisterFuncs implements renderer.NodeRendererFuncRegisterer.
+func (shortcodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindShortcode, func(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			_, _ = w.WriteString(node.(*shortcodeInline).HTML)
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	reg.Register(kindShortcodeBlock, func(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			_, _ = w.WriteString(node.(*shortcodeBlock).HTML)
+			_ = w.WriteByte('\n')
+		}
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+// shortcodes is the goldmark extension enabled by Config.EnableShortcodes.
+type shortcodes struct{}
+
+// Extend implements goldmark.Extender.
+func (shortcodes) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(util.Prioritized(shortcodeParser{}, 100)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(shortcodeRenderer{}, 100)))
+}
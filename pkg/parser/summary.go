@@ -0,0 +1,185 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+)
+
+const (
+	// summaryMarker is the text of the HTML comment that separates a post's
+	// summary from the rest of its content, ignoring surrounding spaces.
+	summaryMarker = "more"
+
+	// defaultSummaryWords is the approximate length of an automatic excerpt.
+	defaultSummaryWords = 70
+)
+
+// summaryBlocks returns the top-level blocks of doc that make up the post's
+// summary, and a function that restores doc once they have been rendered.
+//
+// If the document contains a <!--more--> marker, the content before it is
+// returned. The marker may be a block of its own, inline within a paragraph
+// or nested in a block such as a blockquote, in which case the content of
+// that block after the marker is cut from doc until restore is called.
+// Otherwise blocks are taken in order until at least words words have been
+// collected, so the excerpt always ends on a block boundary.
+func summaryBlocks(doc ast.Node, source []byte, words int) ([]ast.Node, func()) {
+	if marker := findSummaryMarker(doc, source); marker != nil {
+		return cutAtMarker(doc, marker)
+	}
+
+	var blocks []ast.Node
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		blocks = append(blocks, n)
+	}
+	count := 0
+	for i, n := range blocks {
+		count += blockWords(n, source)
+		if count >= words {
+			return blocks[:i+1], func() {}
+		}
+	}
+	return blocks, func() {}
+}
+
+// renderSummary renders the given blocks to HTML in document order.
+func renderSummary(r renderer.Renderer, source []byte, blocks []ast.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, n := range blocks {
+		if err := r.Render(&buf, source, n); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// findSummaryMarker returns the first summary marker in doc, or nil.
+func findSummaryMarker(doc ast.Node, source []byte) ast.Node {
+	var marker ast.Node
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering && isSummaryMarker(n, source) {
+			marker = n
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return marker
+}
+
+// cutAtMarker removes marker and everything after it from the blocks that
+// contain it, and returns the top-level blocks left before it with a
+// function that puts the removed nodes back. Blocks left empty by the cut
+// are removed as well.
+func cutAtMarker(doc, marker ast.Node) ([]ast.Node, func()) {
+	type cut struct {
+		parent ast.Node
+		tail   []ast.Node
+	}
+	top := marker
+	for top.Parent() != doc {
+		top = top.Parent()
+	}
+
+	// Cut from the marker upwards, taking each ancestor's later siblings,
+	// and the ancestor itself once nothing is left in it
+	var cuts []cut
+	n, cutSelf := marker, true
+	for n != top {
+		parent := n.Parent()
+		from := n
+		if !cutSelf {
+			from = n.NextSibling()
+		}
+		var tail []ast.Node
+		for c := from; c != nil; {
+			next := c.NextSibling()
+			parent.RemoveChild(parent, c)
+			tail = append(tail, c)
+			c = next
+		}
+		cuts = append(cuts, cut{parent: parent, tail: tail})
+		n, cutSelf = parent, !parent.HasChildren()
+	}
+
+	var blocks []ast.Node
+	for c := doc.FirstChild(); c != top; c = c.NextSibling() {
+		blocks = append(blocks, c)
+	}
+	if !cutSelf {
+		blocks = append(blocks, top)
+	}
+	return blocks, func() {
+		for _, c := range cuts {
+			for _, t := range c.tail {
+				c.parent.AppendChild(c.parent, t)
+			}
+		}
+	}
+}
+
+// isSummaryMarker reports whether n is an HTML block or inline raw HTML
+// consisting solely of the summary marker comment.
+func isSummaryMarker(n ast.Node, source []byte) bool {
+	var sb strings.Builder
+	switch t := n.(type) {
+	case *ast.HTMLBlock:
+		if t.HTMLBlockType != ast.HTMLBlockType2 {
+			return false
+		}
+		lines := t.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			line := lines.At(i)
+			sb.Write(line.Value(source))
+		}
+		if t.HasClosure() {
+			sb.Write(t.ClosureLine.Value(source))
+		}
+	case *ast.RawHTML:
+		for i := 0; i < t.Segments.Len(); i++ {
+			segment := t.Segments.At(i)
+			sb.Write(segment.Value(source))
+		}
+	default:
+		return false
+	}
+	comment, ok := strings.CutPrefix(strings.TrimSpace(sb.String()), "<!--")
+	if !ok {
+		return false
+	}
+	comment, ok = strings.CutSuffix(comment, "-->")
+	return ok && strings.TrimSpace(comment) == summaryMarker
+}
+
+// blockWords counts the words in a block, including the text of its inline
+// descendants and the raw lines of leaf blocks such as code blocks.
+func blockWords(n ast.Node, source []byte) int {
+	count := 0
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := c.(type) {
+		case *ast.Text:
+			count += len(strings.Fields(string(t.Segment.Value(source))))
+		case *ast.String:
+			count += len(strings.Fields(string(t.Value)))
+		default:
+			if c.Type() == ast.TypeBlock && !c.HasChildren() {
+				lines := c.Lines()
+				for i := 0; i < lines.Len(); i++ {
+					line := lines.At(i)
+					count += len(strings.Fields(string(line.Value(source))))
+				}
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return count
+}
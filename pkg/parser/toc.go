@@ -0,0 +1,91 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"strings"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/models"
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	defaultTOCMinLevel = 2
+	defaultTOCMaxLevel = 3
+)
+
+// clampHeadingLevel restricts level to the valid HTML heading range 1–6.
+func clampHeadingLevel(level int) int {
+	return min(max(level, 1), 6)
+}
+
+// buildTOC walks the document and returns a nested table of contents of the
+// headings whose level lies within [minLevel, maxLevel]. Heading IDs are read
+// from the id attribute assigned by parser.WithAutoHeadingID.
+func buildTOC(doc ast.Node, source []byte, minLevel, maxLevel int) models.TOC {
+	var toc models.TOC
+	var stack []*models.TOCEntry
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		heading, ok := n.(*ast.Heading)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+		if heading.Level < minLevel || heading.Level > maxLevel {
+			return ast.WalkSkipChildren, nil
+		}
+
+		entry := &models.TOCEntry{
+			Level: heading.Level,
+			Text:  nodeText(heading, source),
+		}
+		if id, ok := heading.AttributeString("id"); ok {
+			if b, ok := id.([]byte); ok {
+				entry.ID = string(b)
+			}
+		}
+
+		// Pop until the top of the stack is a shallower heading.
+		for len(stack) > 0 && stack[len(stack)-1].Level >= entry.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			toc = append(toc, entry)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, entry)
+		}
+		stack = append(stack, entry)
+
+		return ast.WalkSkipChildren, nil
+	})
+
+	return toc
+}
+
+// nodeText returns the plain text content of an inline container such as a
+// heading, concatenating its text and string descendants.
+func nodeText(n ast.Node, source []byte) string {
+	var sb strings.Builder
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := c.(type) {
+		case *ast.Text:
+			sb.Write(t.Segment.Value(source))
+			if t.SoftLineBreak() {
+				sb.WriteByte(' ')
+			}
+		case *ast.String:
+			sb.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,230 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/models"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// kindWikiLink is the AST node kind of a wiki link.
+var kindWikiLink = ast.NewNodeKind("WikiLink")
+
+// wikiLink is an inline node for [[Target]], [[Target|Label]] or, when Embed
+// is set, ![[file]]. Its label is held as a single ast.String child so that
+// plain-text walks (tables of contents, word counts) see it.
+//
+// Destination is empty until the link has been resolved; unresolved links
+// render as their label without an anchor.
+type wikiLink struct {
+	ast.BaseInline
+	Target      string
+	Embed       bool
+	Destination string
+}
+
+// Kind implements ast.Node.
+func (n *wikiLink) Kind() ast.NodeKind {
+	return kindWikiLink
+}
+
+// Dump implements ast.Node.
+func (n *wikiLink) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"Target":      n.Target,
+		"Destination": n.Destination,
+	}, nil)
+}
+
+// label returns the text shown for the link.
+func (n *wikiLink) label() []byte {
+	if s, ok := n.FirstChild().(*ast.String); ok {
+		return s.Value
+	}
+	return []byte(n.Target)
+}
+
+// wikiLinkParser parses wiki links. It runs ahead of goldmark's link parser,
+// which would otherwise claim the leading '['.
+type wikiLinkParser struct{}
+
+// Trigger implements parser.InlineParser.
+func (wikiLinkParser) Trigger() []byte {
+	return []byte{'!', '['}
+}
+
+// Parse implements parser.InlineParser.
+func (wikiLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	start := 0
+	embed := len(line) > 0 && line[0] == '!'
+	if embed {
+		start = 1
+	}
+	if !bytes.HasPrefix(line[start:], []byte("[[")) {
+		return nil
+	}
+
+	rest := line[start+2:]
+	end := bytes.Index(rest, []byte("]]"))
+	if end < 0 || bytes.ContainsAny(rest[:end], "[]\n") {
+		return nil
+	}
+	target, label, _ := bytes.Cut(rest[:end], []byte("|"))
+	target = bytes.TrimSpace(target)
+	label = bytes.TrimSpace(label)
+	if len(target) == 0 {
+		return nil
+	}
+	if len(label) == 0 {
+		label = target
+	}
+	block.Advance(start + 2 + end + 2)
+
+	n := &wikiLink{Target: string(target), Embed: embed}
+	n.AppendChild(n, ast.NewString(bytes.Clone(label)))
+	return n
+}
+
+// wikiLinkRenderer renders wiki links as anchors, or images for embeds.
+type wikiLinkRenderer struct {
+	html.Config
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r *wikiLinkRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindWikiLink, r.renderWikiLink)
+}
+
+func (r *wikiLinkRenderer) renderWikiLink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*wikiLink)
+
+	if n.Embed {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if n.Destination == "" {
+			_, _ = w.WriteString(`<span class="wikilink-unresolved">`)
+			_, _ = w.Write(util.EscapeHTML(n.label()))
+			_, _ = w.WriteString(`</span>`)
+			return ast.WalkSkipChildren, nil
+		}
+		_, _ = w.WriteString(`<img src="`)
+		_, _ = w.Write(util.EscapeHTML(util.URLEscape([]byte(n.Destination), true)))
+		_, _ = w.WriteString(`" alt="`)
+		_, _ = w.Write(util.EscapeHTML(n.label()))
+		if r.XHTML {
+			_, _ = w.WriteString(`" />`)
+		} else {
+			_, _ = w.WriteString(`">`)
+		}
+		return ast.WalkSkipChildren, nil
+	}
+
+	if n.Destination == "" {
+		if entering {
+			_, _ = w.WriteString(`<span class="wikilink-unresolved">`)
+		} else {
+			_, _ = w.WriteString(`</span>`)
+		}
+		return ast.WalkContinue, nil
+	}
+	if entering {
+		_, _ = w.WriteString(`<a href="`)
+		_, _ = w.Write(util.EscapeHTML(util.URLEscape([]byte(n.Destination), true)))
+		_, _ = w.WriteString(`">`)
+	} else {
+		_, _ = w.WriteString(`</a>`)
+	}
+	return ast.WalkContinue, nil
+}
+
+// wikiLinks is the goldmark extension enabled by Config.EnableWikiLinks.
+type wikiLinks struct{}
+
+// Extend implements goldmark.Extender.
+func (wikiLinks) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(
+		util.Prioritized(wikiLinkParser{}, 199),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&wikiLinkRenderer{Config: html.NewConfig()}, 199),
+	))
+}
+
+// wikiLinkIndex maps normalised post titles, slugs and file names to slugs.
+type wikiLinkIndex map[string]string
+
+// newWikiLinkIndex indexes posts for wiki link lookup. When two posts share a
+// key, the first one keeps it.
+func newWikiLinkIndex(posts []*models.Post) wikiLinkIndex {
+	idx := make(wikiLinkIndex)
+	add := func(key, slug string) {
+		key = normaliseWikiTarget(key)
+		if _, taken := idx[key]; key != "" && !taken {
+			idx[key] = slug
+		}
+	}
+	for _, post := range posts {
+		add(post.Slug, post.Slug)
+		add(post.Title, post.Slug)
+		if post.IsBundle() {
+			add(path.Base(post.BundleDir), post.Slug)
+		} else {
+			add(path.Base(post.SourcePath), post.Slug)
+		}
+	}
+	return idx
+}
+
+// normaliseWikiTarget lowercases s, collapses runs of whitespace and drops a
+// markdown file extension, so [[My Post]], [[my post]] and [[My Post.md]]
+// all match.
+func normaliseWikiTarget(s string) string {
+	s = strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	if isMarkdown(s) {
+		s = strings.TrimSuffix(s, path.Ext(s))
+	}
+	return s
+}
+
+// resolveWikiLinks sets the destination of every wiki link in doc and returns
+// a BrokenLinkError for each one that cannot be resolved. Links resolve to
+// the post page of a matching post; embeds resolve to an asset of post's page
+// bundle.
+func resolveWikiLinks(doc ast.Node, post *models.Post, idx wikiLinkIndex, blogRoot string) []error {
+	var broken []error
+	_ = ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		n, ok := node.(*wikiLink)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		if n.Embed {
+			asset := path.Clean(n.Target)
+			for _, a := range post.Assets {
+				if a == asset {
+					n.Destination = blogRoot + "posts/" + post.Slug + "/" + asset
+				}
+			}
+		} else if slug, ok := idx[normaliseWikiTarget(n.Target)]; ok {
+			n.Destination = blogRoot + "posts/" + slug + ".html"
+		}
+		if n.Destination == "" {
+			broken = append(broken, BrokenLinkError{Target: n.Target, Embed: n.Embed})
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	return broken
+}
@@ -0,0 +1,110 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package server_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/config"
+	"github.com/harrydayexe/GoBlog/v2/pkg/server"
+)
+
+// TestServer_ServesBundleAssets verifies that files from a page bundle are
+// served next to their post, under the blog root.
+func TestServer_ServesBundleAssets(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	postsFS := fstest.MapFS{
+		"my-post/index.md":    &fstest.MapFile{Data: []byte("---\ntitle: My Post\ndate: 2024-01-01\ndescription: d\n---\n![Diagram](diagram.png)\n")},
+		"my-post/diagram.png": &fstest.MapFile{Data: []byte("\x89PNG\r\n\x1a\n")},
+	}
+
+	cfg := config.ServerConfig{
+		Server: []config.BaseServerOption{
+			config.WithBlogRoot("/blog/").AsServerOption(),
+		},
+		Gen: []config.GeneratorOption{
+			config.WithRawOutput(),
+			config.WithBlogRoot("/blog/").AsGeneratorOption(),
+		},
+	}
+
+	srv, err := server.New(logger, postsFS, cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/blog/posts/my-post/diagram.png", http.StatusOK},
+		{"/blog/posts/my-post/missing.png", http.StatusNotFound},
+		{"/blog/posts/my-post", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tt.wantStatus {
+			t.Errorf("GET %s: got status %d, want %d", tt.path, w.Code, tt.wantStatus)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/blog/posts/my-post/diagram.png", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if got := w.Header().Get("Content-Type"); got != "image/png" {
+		t.Errorf("Content-Type: got %q, want image/png", got)
+	}
+}
+
+// TestServer_ServesChromaCSS verifies that the code highlighting stylesheet is
+// served as CSS at the blog root.
+func TestServer_ServesChromaCSS(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	postsFS := fstest.MapFS{
+		"post.md": &fstest.MapFile{Data: []byte("---\ntitle: Post\ndate: 2024-01-01\ndescription: d\n---\nText.\n")},
+	}
+
+	cfg := config.ServerConfig{
+		Server: []config.BaseServerOption{
+			config.WithBlogRoot("/blog/").AsServerOption(),
+		},
+		Gen: []config.GeneratorOption{
+			config.WithRawOutput(),
+			config.WithBlogRoot("/blog/").AsGeneratorOption(),
+			config.WithHighlightStyle("monokailight", "monokai"),
+		},
+	}
+
+	srv, err := server.New(logger, postsFS, cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/blog/chroma.css", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /blog/chroma.css: got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/css; charset=utf-8" {
+		t.Errorf("Content-Type: got %q, want text/css; charset=utf-8", got)
+	}
+	if body := w.Body.String(); !strings.Contains(body, ".chroma") || !strings.Contains(body, "prefers-color-scheme: dark") {
+		t.Errorf("expected light and dark chroma rules, got %s", body)
+	}
+}
@@ -133,6 +133,21 @@
 //	    srv.UpdatePosts(os.DirFS(postsPath), ctx)
 //	})
 //
+// # Scheduled Publishing
+//
+// Posts with a future date stay hidden until that time, and posts with an
+// expiryDate are withdrawn once it passes. After every regeneration the server
+// arms a timer for the next such change and regenerates on its own when it
+// fires, so no file change or watcher is needed. Supply a controllable clock
+// with config.WithClock to test this deterministically:
+//
+//	cfg.Server = append(cfg.Server, config.WithClock(clock.Now).AsServerOption())
+//
+// Run stops the timer when it shuts down. When the Server is instead mounted
+// as an http.Handler in another server, call Close once that server stops:
+//
+//	defer srv.Close()
+//
 // # Health Checks
 //
 // Enable health-check endpoints via config.WithHealthChecks():
@@ -153,6 +168,13 @@
 //   - /healthz/startup — same semantics as /healthz/ready; used as the
 //     startup probe in Kubernetes deployments.
 //
+// When the generator is given config.WithLenient, posts that fail to parse
+// are left out instead of failing the load or reload. /healthz/ready and
+// /healthz/startup still return 200 OK, with a "skipped <path>: <reason>" line
+// after the "ok" for each file left out, and Server.SkippedFiles returns them.
+// Bundle images that could not be processed, and were published unchanged,
+// are listed the same way.
+//
 // When health checks are enabled the server binds the HTTP listener before
 // loading posts, so probes can observe startup state. The endpoints bypass
 // middleware (including authentication) and are intercepted in ServeHTTP before
@@ -5,10 +5,12 @@
 package server
 
 import (
+	"bytes"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/harrydayexe/GoBlog/v2/pkg/config"
 	"github.com/harrydayexe/GoBlog/v2/pkg/generator"
@@ -29,6 +31,9 @@ type HandlerConfig struct {
 // The handler serves the following routes (assuming default root "/"):
 //   - GET / and GET /posts - serves the blog index page
 //   - GET /posts/{postName} - serves individual blog posts
+//   - GET /posts/{postName}/{asset...} - serves page bundle assets (only if blog.Assets is non-empty)
+//   - GET /chroma.css - serves the code highlighting stylesheet (only if blog.ChromaCSS is non-empty)
+//   - GET /series/{seriesName} - serves series pages (only if blog.Series is non-empty)
 //   - GET /tags - serves the tags index page (only if blog.TagsIndex is non-empty)
 //   - GET /tags/{tagName} - serves tag-specific pages (only if blog.Tags is non-empty)
 //
@@ -95,6 +100,17 @@ func generateHandler(cfg HandlerConfig, blog *generator.GeneratedBlog) http.Hand
 	mux.Handle(root+"posts", handleIndex(cfg, blog))
 	mux.Handle(root+"{$}", handleIndex(cfg, blog))
 	mux.Handle(root+"posts/{postName}", handlePost(cfg, blog))
+	if len(blog.Assets) > 0 {
+		mux.Handle(root+"posts/{postName}/{asset...}", handleAsset(cfg, blog))
+	}
+
+	if len(blog.ChromaCSS) > 0 {
+		mux.Handle(root+"chroma.css", handleChromaCSS(cfg, blog))
+	}
+
+	if len(blog.Series) > 0 {
+		mux.Handle(root+"series/{seriesName}", handleSeries(cfg, blog))
+	}
 
 	if len(blog.Tags) > 0 || len(blog.TagsIndex) > 0 {
 		mux.Handle(root+"tags", handleTagsIndex(cfg, blog))
@@ -137,6 +153,54 @@ func handlePost(cfg HandlerConfig, blog *generator.GeneratedBlog) http.Handler {
 	})
 }
 
+func handleAsset(cfg HandlerConfig, blog *generator.GeneratedBlog) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("postName") + "/" + r.PathValue("asset")
+		cfg.Logger.Logger.DebugContext(r.Context(), "handling bundle asset", slog.String("asset", name))
+
+		bits, prs := blog.Assets[name]
+		if !prs {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(bits))
+	})
+}
+
+func handleChromaCSS(cfg HandlerConfig, blog *generator.GeneratedBlog) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg.Logger.Logger.DebugContext(r.Context(), "handling chroma stylesheet")
+
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+		if _, err := w.Write(blog.ChromaCSS); err != nil {
+			cfg.Logger.Logger.ErrorContext(r.Context(), "failed to write chroma stylesheet", "error", err)
+			return
+		}
+	})
+}
+
+func handleSeries(cfg HandlerConfig, blog *generator.GeneratedBlog) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg.Logger.Logger.DebugContext(r.Context(), "handling series page")
+
+		seriesName := strings.TrimSuffix(r.PathValue("seriesName"), ".html")
+		bits, prs := blog.Series[seriesName]
+		if !prs {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		cfg.Logger.Logger.DebugContext(r.Context(), "resolved series name", slog.String("seriesName", seriesName))
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if _, err := w.Write(bits); err != nil {
+			cfg.Logger.Logger.ErrorContext(r.Context(), "failed to write series page", "error", err, "series", seriesName)
+			return
+		}
+	})
+}
+
 func handleTagsIndex(cfg HandlerConfig, blog *generator.GeneratedBlog) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cfg.Logger.Logger.DebugContext(r.Context(), "handling tag index")
@@ -312,3 +312,105 @@ func TestHealthChecks_FailedInit(t *testing.T) {
 	t.Error("/healthz/ready did not return 503 with a failure reason within the deadline")
 	cancel()
 }
+
+// TestHealthChecks_ReadyReportsSkipped verifies that, in lenient mode, a post
+// that fails to parse leaves /healthz/ready at 200 OK with a line naming the
+// skipped file.
+func TestHealthChecks_ReadyReportsSkipped(t *testing.T) {
+	t.Parallel()
+
+	postsFS := fstest.MapFS{
+		"good.md":   {Data: []byte("---\ntitle: Good\ndate: 2024-01-01\ndescription: d\n---\n# hello\n")},
+		"broken.md": {Data: []byte("---\ntitle: Broken\ndate: 2024-01-01\n---\n# hello\n")},
+	}
+
+	// Grab a free port.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	cfg := config.ServerConfig{
+		Server: []config.BaseServerOption{
+			config.WithPort(port),
+			config.WithHost("127.0.0.1"),
+			config.WithHealthChecks(),
+		},
+		Gen: []config.GeneratorOption{config.WithLenient()},
+	}
+	srv, err := server.New(nil, postsFS, cfg)
+	if err != nil {
+		t.Fatalf("server.New: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	go func() { srv.Run(ctx) }() //nolint:errcheck
+
+	addr := fmt.Sprintf("http://127.0.0.1:%d", port)
+
+	// Poll /healthz/ready until 200 or timeout.
+	deadline := time.Now().Add(8 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(addr + "/healthz/ready") //nolint:noctx
+		if err == nil {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				want := "ok\nskipped broken.md: post missing required field: description (source: broken.md)"
+				if got := string(body); got != want {
+					t.Errorf("/healthz/ready body: got %q, want %q", got, want)
+				}
+				cancel()
+				return
+			}
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+
+	t.Error("/healthz/ready did not return 200 within the deadline")
+	cancel()
+}
+
+// TestServer_SkippedFiles verifies that SkippedFiles lists the posts left out
+// in lenient mode and is cleared once a reload builds every post.
+func TestServer_SkippedFiles(t *testing.T) {
+	t.Parallel()
+
+	brokenFS := fstest.MapFS{
+		"good.md":   {Data: []byte("---\ntitle: Good\ndate: 2024-01-01\ndescription: d\n---\n# hello\n")},
+		"broken.md": {Data: []byte("---\nbad yaml: [unclosed\n---\n# hello\n")},
+	}
+
+	strict := config.ServerConfig{}
+	if _, err := server.New(nil, brokenFS, strict); err == nil {
+		t.Fatal("server.New: want an error without config.WithLenient")
+	}
+
+	lenient := config.ServerConfig{Gen: []config.GeneratorOption{config.WithLenient()}}
+	srv, err := server.New(nil, brokenFS, lenient)
+	if err != nil {
+		t.Fatalf("server.New: %v", err)
+	}
+	if skipped := srv.SkippedFiles(); len(skipped) != 1 || skipped[0].Path != "broken.md" {
+		t.Errorf("SkippedFiles() = %v, want broken.md", skipped)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/good", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /posts/good: got %d, want 200", w.Code)
+	}
+
+	delete(brokenFS, "broken.md")
+	if err := srv.UpdatePosts(brokenFS, context.Background()); err != nil {
+		t.Fatalf("UpdatePosts: %v", err)
+	}
+	if skipped := srv.SkippedFiles(); skipped != nil {
+		t.Errorf("SkippedFiles() after fixing = %v, want nil", skipped)
+	}
+}
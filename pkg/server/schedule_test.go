@@ -0,0 +1,200 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package server_test
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/config"
+	"github.com/harrydayexe/GoBlog/v2/pkg/server"
+)
+
+// fakeClock is a controllable clock for scheduled-publishing tests.
+type fakeClock struct{ now atomic.Pointer[time.Time] }
+
+func newFakeClock(t time.Time) *fakeClock {
+	c := &fakeClock{}
+	c.Set(t)
+	return c
+}
+
+func (c *fakeClock) Now() time.Time  { return *c.now.Load() }
+func (c *fakeClock) Set(t time.Time) { c.now.Store(&t) }
+
+// scheduleFS returns posts for scheduling tests: one always live, one that
+// goes live at 12:00:00 UTC, and one that expires at 12:00:00 UTC.
+func scheduleFS() fstest.MapFS {
+	return fstest.MapFS{
+		"live.md":      {Data: []byte("---\ntitle: Live\ndescription: d\ndate: 2024-06-01T00:00:00Z\n---\nLive.")},
+		"scheduled.md": {Data: []byte("---\ntitle: Scheduled\ndescription: d\ndate: 2024-06-01T12:00:00Z\n---\nScheduled.")},
+		"expiring.md":  {Data: []byte("---\ntitle: Expiring\ndescription: d\ndate: 2024-06-01T00:00:00Z\nexpiryDate: 2024-06-01T12:00:00Z\n---\nExpiring.")},
+	}
+}
+
+func getStatus(srv *server.Server, path string) int {
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code
+}
+
+// TestServer_ScheduledRefresh verifies that the server regenerates on its own
+// when the next scheduled post becomes due, publishing future-dated posts and
+// withdrawing expired ones without a call to UpdatePosts.
+func TestServer_ScheduledRefresh(t *testing.T) {
+	t.Parallel()
+
+	// 100ms before the scheduled change.
+	clock := newFakeClock(time.Date(2024, 6, 1, 11, 59, 59, 900_000_000, time.UTC))
+
+	cfg := config.ServerConfig{
+		Server: []config.BaseServerOption{
+			config.WithClock(clock.Now).AsServerOption(),
+			config.WithCacheControl(0),
+		},
+		Gen: []config.GeneratorOption{config.WithRawOutput()},
+	}
+
+	srv, err := server.New(nil, scheduleFS(), cfg)
+	if err != nil {
+		t.Fatalf("server.New() error = %v", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	if code := getStatus(srv, "/posts/live"); code != http.StatusOK {
+		t.Errorf("GET /posts/live = %d, want 200", code)
+	}
+	if code := getStatus(srv, "/posts/scheduled"); code != http.StatusNotFound {
+		t.Errorf("GET /posts/scheduled before its date = %d, want 404", code)
+	}
+	if code := getStatus(srv, "/posts/expiring"); code != http.StatusOK {
+		t.Errorf("GET /posts/expiring before its expiry = %d, want 200", code)
+	}
+
+	// Move the clock past the change; the armed timer fires ~100ms later.
+	clock.Set(time.Date(2024, 6, 1, 12, 0, 1, 0, time.UTC))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for getStatus(srv, "/posts/scheduled") != http.StatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("scheduled post was not published by the scheduled refresh")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if code := getStatus(srv, "/posts/expiring"); code != http.StatusNotFound {
+		t.Errorf("GET /posts/expiring after its expiry = %d, want 404", code)
+	}
+	if code := getStatus(srv, "/posts/live"); code != http.StatusOK {
+		t.Errorf("GET /posts/live after refresh = %d, want 200", code)
+	}
+}
+
+// flakyFS is a posts filesystem that fails to open anything while fail is
+// set, counting the failed opens. It only implements fs.FS, so that every
+// access goes through Open.
+type flakyFS struct {
+	posts    fstest.MapFS
+	fail     atomic.Bool
+	failures atomic.Int32
+}
+
+func (f *flakyFS) Open(name string) (fs.File, error) {
+	if f.fail.Load() {
+		f.failures.Add(1)
+		return nil, fs.ErrPermission
+	}
+	return f.posts.Open(name)
+}
+
+// TestServer_ScheduledRefreshRetry verifies that a scheduled refresh which
+// fails is retried, rather than leaving scheduled posts unpublished until the
+// next file change.
+func TestServer_ScheduledRefreshRetry(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock(time.Date(2024, 6, 1, 11, 59, 59, 900_000_000, time.UTC))
+	posts := &flakyFS{posts: scheduleFS()}
+
+	cfg := config.ServerConfig{
+		Server: []config.BaseServerOption{
+			config.WithClock(clock.Now).AsServerOption(),
+			config.WithCacheControl(0),
+		},
+		Gen: []config.GeneratorOption{config.WithRawOutput()},
+	}
+
+	srv, err := server.New(nil, posts, cfg)
+	if err != nil {
+		t.Fatalf("server.New() error = %v", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	// The refresh at the scheduled change fails
+	posts.fail.Store(true)
+	clock.Set(time.Date(2024, 6, 1, 12, 0, 1, 0, time.UTC))
+	deadline := time.Now().Add(5 * time.Second)
+	for posts.failures.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("scheduled refresh did not run")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if code := getStatus(srv, "/posts/live"); code != http.StatusOK {
+		t.Errorf("GET /posts/live after a failed refresh = %d, want 200", code)
+	}
+
+	// Once the posts can be read again, the retry publishes the change
+	posts.fail.Store(false)
+	deadline = time.Now().Add(5 * time.Second)
+	for getStatus(srv, "/posts/scheduled") != http.StatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("scheduled post was not published by the retried refresh")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestServer_Close verifies that a closed server no longer regenerates for
+// scheduled post changes, while still serving its content.
+func TestServer_Close(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock(time.Date(2024, 6, 1, 11, 59, 59, 900_000_000, time.UTC))
+	posts := &flakyFS{posts: scheduleFS()}
+
+	cfg := config.ServerConfig{
+		Server: []config.BaseServerOption{
+			config.WithClock(clock.Now).AsServerOption(),
+			config.WithCacheControl(0),
+		},
+		Gen: []config.GeneratorOption{config.WithRawOutput()},
+	}
+
+	srv, err := server.New(nil, posts, cfg)
+	if err != nil {
+		t.Fatalf("server.New() error = %v", err)
+	}
+	if err := srv.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Any regeneration would fail and be counted
+	posts.fail.Store(true)
+	clock.Set(time.Date(2024, 6, 1, 12, 0, 1, 0, time.UTC))
+	time.Sleep(300 * time.Millisecond)
+	if n := posts.failures.Load(); n != 0 {
+		t.Errorf("closed server regenerated %d times, want 0", n)
+	}
+	posts.fail.Store(false)
+	if code := getStatus(srv, "/posts/live"); code != http.StatusOK {
+		t.Errorf("GET /posts/live after Close = %d, want 200", code)
+	}
+}
@@ -0,0 +1,52 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package server_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/harrydayexe/GoBlog/v2/pkg/config"
+	"github.com/harrydayexe/GoBlog/v2/pkg/server"
+)
+
+// TestServer_ServesSeriesPages verifies that each series is served at
+// /series/<slug>, with and without the .html extension.
+func TestServer_ServesSeriesPages(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	postsFS := fstest.MapFS{
+		"one.md": &fstest.MapFile{Data: []byte("---\ntitle: Part One\ndate: 2024-01-01\ndescription: d\nseries: Go Basics\nseriesOrder: 1\n---\nOne\n")},
+		"two.md": &fstest.MapFile{Data: []byte("---\ntitle: Part Two\ndate: 2024-01-02\ndescription: d\nseries: Go Basics\nseriesOrder: 2\n---\nTwo\n")},
+	}
+
+	srv, err := server.New(logger, postsFS, config.ServerConfig{})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/series/go-basics", http.StatusOK},
+		{"/series/go-basics.html", http.StatusOK},
+		{"/series/unknown", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tt.wantStatus {
+			t.Errorf("GET %s: got status %d, want %d", tt.path, w.Code, tt.wantStatus)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/harrydayexe/GoBlog/v2/pkg/config"
 	"github.com/harrydayexe/GoBlog/v2/pkg/generator"
+	"github.com/harrydayexe/GoBlog/v2/pkg/parser"
 	"github.com/harrydayexe/GoBlog/v2/pkg/templates"
 	"github.com/harrydayexe/GoWebUtilities/middleware"
 )
@@ -55,10 +57,18 @@ type healthStatus struct {
 // health-check endpoints (/healthz/live, /healthz/ready, /healthz/startup) are
 // intercepted before the middleware stack and always available without auth.
 //
+// Posts dated in the future or carrying an expiryDate are published and
+// withdrawn on schedule: after each regeneration the server arms a timer for
+// the next such change and regenerates on its own when it fires, without
+// needing a file change or a watcher. A regeneration that fails is retried
+// after a second, then after twice as long each time, up to five minutes.
+// Run stops the timer on shutdown; a Server served without Run should be
+// closed with Close once it is no longer needed.
+//
 // All methods are safe for concurrent use by multiple goroutines.
 type Server struct {
-	// mu protects postsDir, generator, and generator fields during initialize
-	// and refreshHandler.
+	// mu protects postsDir, generator, scheduled, retryDelay and stopped
+	// during initialize, refreshHandler, scheduled refreshes and Close.
 	mu       sync.RWMutex
 	postsDir fs.FS
 
@@ -66,13 +76,18 @@ type Server struct {
 	config.Port
 	config.Host
 	config.Logger
+	config.Clock
 	config.CacheControlTTL
 	config.HealthChecks
 
 	handler    atomic.Value // stores http.Handler
 	health     atomic.Pointer[healthStatus]
-	middleware []middleware.Middleware // middleware chain
+	skipped    atomic.Pointer[[]parser.FileError] // files left out of the current content
+	middleware []middleware.Middleware            // middleware chain
 	generator  *generator.Generator
+	scheduled  *time.Timer   // fires when the next scheduled post goes live or expires
+	retryDelay time.Duration // wait before retrying a failed scheduled refresh, or 0
+	stopped    bool          // set by Close, after which nothing is scheduled
 
 	// deferred initialisation inputs — set in New, consumed by initialize.
 	templatesDir fs.FS
@@ -129,6 +144,8 @@ func New(logger *slog.Logger, posts fs.FS, opts config.ServerConfig) (*Server, e
 			opt.WithCacheControlFunc(&srv.CacheControlTTL)
 		} else if opt.WithLoggerFunc != nil {
 			opt.WithLoggerFunc(&srv.Logger)
+		} else if opt.WithClockFunc != nil {
+			opt.WithClockFunc(&srv.Clock)
 		} else if opt.WithHealthChecksFunc != nil {
 			opt.WithHealthChecksFunc(&srv.HealthChecks)
 		}
@@ -143,6 +160,10 @@ func New(logger *slog.Logger, posts fs.FS, opts config.ServerConfig) (*Server, e
 		}
 	}
 
+	if srv.Clock.Now == nil {
+		srv.Clock.Now = time.Now
+	}
+
 	// Resolve the template filesystem.
 	var templatesDir fs.FS
 	if opts.TemplateDir != nil {
@@ -153,10 +174,14 @@ func New(logger *slog.Logger, posts fs.FS, opts config.ServerConfig) (*Server, e
 		templatesDir = templates.Default
 	}
 
-	// Build the generator option slice (merging caller options with logger).
-	genOpts := make([]config.GeneratorOption, 0, len(opts.Gen)+1)
+	// Build the generator option slice (merging caller options with logger
+	// and clock, so scheduling decisions agree with the server's timer).
+	genOpts := make([]config.GeneratorOption, 0, len(opts.Gen)+2)
 	genOpts = append(genOpts, opts.Gen...)
-	genOpts = append(genOpts, srv.Logger.AsOption().AsGeneratorOption())
+	genOpts = append(genOpts,
+		srv.Logger.AsOption().AsGeneratorOption(),
+		srv.Clock.AsOption().AsGeneratorOption(),
+	)
 
 	// Store inputs needed by initialize (both sync and async paths use them).
 	srv.templatesDir = templatesDir
@@ -243,7 +268,9 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 //
 // /healthz/live always returns 200 OK.
 // /healthz/ready and /healthz/startup return 200 OK once content has loaded
-// and 503 Service Unavailable while starting or after a load failure.
+// and 503 Service Unavailable while starting or after a load failure. When
+// posts were skipped in lenient mode, the "ok" is followed by a line naming
+// each skipped file and why it failed.
 func (s *Server) serveHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
@@ -267,7 +294,25 @@ func (s *Server) serveHealth(w http.ResponseWriter, r *http.Request) {
 		}
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 		fmt.Fprint(w, "ok")
+		for _, fe := range s.SkippedFiles() {
+			reason := strings.ReplaceAll(fe.Err.Error(), "\n", "; ")
+			fmt.Fprintf(w, "\nskipped %s: %s", fe.Path, reason)
+		}
+	}
+}
+
+// SkippedFiles returns the posts left out of the content being served
+// because they failed to parse, and the bundle images served unchanged
+// because they could not be processed, which are only listed when the
+// generator is given config.WithLenient. It returns nil when every post was
+// built.
+//
+// SkippedFiles is safe for concurrent use by multiple goroutines.
+func (s *Server) SkippedFiles() []parser.FileError {
+	if skipped := s.skipped.Load(); skipped != nil {
+		return *skipped
 	}
+	return nil
 }
 
 // Run starts the HTTP server and blocks until interrupted via context cancellation
@@ -330,6 +375,8 @@ func (s *Server) Run(ctx context.Context) error {
 	wg.Go(func() {
 		<-ctx.Done()
 
+		s.Close()
+
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
@@ -342,6 +389,22 @@ func (s *Server) Run(ctx context.Context) error {
 	return listenErr
 }
 
+// Close stops the timer that regenerates the blog when the next scheduled
+// post goes live or expires, and keeps it from being armed again. The
+// content being served is unchanged.
+//
+// Run calls Close on shutdown. A Server mounted in another http.Server
+// instead should be closed once that server stops, or its timer keeps the
+// Server and its content alive. Close always returns nil.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scheduleRefresh(time.Time{})
+	s.stopped = true
+	return nil
+}
+
 // UpdatePosts updates the posts directory and refreshes the HTTP handler with
 // the new content. This triggers a complete regeneration of the blog and an
 // atomic swap of the HTTP handler.
@@ -381,7 +444,8 @@ func (s *Server) UpdatePosts(posts fs.FS, ctx context.Context) error {
 
 // refreshHandler regenerates the blog content and updates the HTTP handler atomically.
 // It generates fresh blog content from the current posts directory, creates a new
-// handler with the updated content, and swaps it in atomically.
+// handler with the updated content, and swaps it in atomically. It then arms a
+// timer for the next scheduled post change reported by the generator.
 //
 // Callers must either hold s.mu or be the sole goroutine accessing the server
 // (e.g. during New before the Server is published).
@@ -413,6 +477,65 @@ func (s *Server) refreshHandler(ctx context.Context) error {
 	}
 
 	s.handler.Store(handler)
+	s.skipped.Store(&blog.Skipped)
+	s.retryDelay = 0
+	s.scheduleRefresh(blog.NextUpdate)
 
 	return nil
 }
+
+// scheduleRefresh arranges for the handler to be regenerated at next, the time
+// at which the next scheduled post goes live or expires. Any previously armed
+// timer is stopped. A zero next, or a server that has been closed, leaves no
+// refresh scheduled.
+//
+// Callers must either hold s.mu or be the sole goroutine accessing the server.
+func (s *Server) scheduleRefresh(next time.Time) {
+	if s.scheduled != nil {
+		s.scheduled.Stop()
+		s.scheduled = nil
+	}
+	if next.IsZero() || s.stopped {
+		return
+	}
+
+	delay := max(next.Sub(s.Clock.Now()), 0)
+	s.Logger.Logger.Debug("Scheduling refresh for next post change",
+		slog.Time("at", next),
+		slog.Duration("in", delay),
+	)
+	s.scheduled = time.AfterFunc(delay, s.runScheduledRefresh)
+}
+
+// Failed scheduled refreshes are retried after minRefreshRetry, doubling
+// each time up to maxRefreshRetry.
+const (
+	minRefreshRetry = time.Second
+	maxRefreshRetry = 5 * time.Minute
+)
+
+// runScheduledRefresh is invoked by the scheduling timer. It regenerates the
+// blog under s.mu so it cannot race with UpdatePosts. On failure the previous
+// handler remains active, the error is logged and the refresh is retried with
+// a backoff. It does nothing once the server has been closed, since the
+// timer may fire while Close is stopping it.
+func (s *Server) runScheduledRefresh() {
+	ctx := context.Background()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return
+	}
+
+	s.Logger.Logger.InfoContext(ctx, "Regenerating blog for scheduled post change")
+	if err := s.refreshHandler(ctx); err != nil {
+		s.retryDelay = min(max(2*s.retryDelay, minRefreshRetry), maxRefreshRetry)
+		s.Logger.Logger.ErrorContext(ctx, "scheduled refresh failed",
+			slog.Any("error", err),
+			slog.Duration("retryIn", s.retryDelay),
+		)
+		s.scheduleRefresh(s.Clock.Now().Add(s.retryDelay))
+	}
+}
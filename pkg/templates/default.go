@@ -30,18 +30,20 @@ var rawDefault embed.FS
 //	  index.tmpl         executed by TemplateRenderer.RenderIndex
 //	  tag.tmpl           executed by TemplateRenderer.RenderTag
 //	  tags-index.tmpl    executed by TemplateRenderer.RenderTagsIndex
+//	  series.tmpl        executed by TemplateRenderer.RenderSeries
 //	partials/
 //	  head.tmpl          {{define "head"}}
 //	  header.tmpl        {{define "header"}}
 //	  footer.tmpl        {{define "footer"}}
 //	  post-card.tmpl     {{define "post-card"}}
+//	  toc.tmpl           {{define "toc"}} and {{define "toc-entries"}}
 //	layouts/
 //	  base.tmpl          loaded but not executed; pages are self-contained
 //
 // Each page template is a complete HTML document that references partials via
 // {{template "head" .}}, {{template "header" .}}, etc. Custom templates must
-// follow the same convention: define the four named blocks in their partials/
-// directory and reference them from each page template.
+// follow the same convention: define the named blocks their pages use in their
+// partials/ directory and reference them from each page template.
 var Default fs.FS = func() fs.FS {
 	sub, err := fs.Sub(rawDefault, "default")
 	if err != nil {
@@ -66,9 +66,12 @@
 // Subdirectories created after the Watcher is constructed are automatically
 // picked up by Run when the parent directory fires a Create event.
 //
-// Only changes to files with a .md extension trigger the onChange callback.
-// All other file types (images, CSS, YAML, etc.) are silently ignored, as
-// are common editor temporary files (dotfiles, *.swp, *~, etc.).
+// Only changes to files with a .md extension, to files inside a page bundle
+// (a directory containing an index.md), and to a schema.yaml front matter
+// schema in the root trigger the onChange callback. All other file types
+// (images, CSS, YAML, etc.) are silently ignored, as are common editor
+// temporary files (dotfiles, *.swp, *~, etc.). A schema kept outside the
+// root, as with the --schema flag, is not watched.
 // Deletion of watched subdirectories releases the corresponding watch
 // descriptor automatically. A subdirectory that is removed and then recreated
 // is re-watched when the parent fires the subsequent Create event.
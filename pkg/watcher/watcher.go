@@ -129,8 +129,9 @@ func (w *Watcher) Run(ctx context.Context, onChange func(context.Context)) error
 				}
 			}
 
-			// Only regenerate for markdown file changes.
-			if filepath.Ext(event.Name) != ".md" {
+			// Only regenerate for markdown files, page bundle assets and
+			// the front matter schema.
+			if filepath.Ext(event.Name) != ".md" && !w.isBundleAsset(event.Name) && !w.isSchema(event.Name) {
 				continue
 			}
 
@@ -153,6 +154,33 @@ func (w *Watcher) Run(ctx context.Context, onChange func(context.Context)) error
 	}
 }
 
+// isBundleAsset reports whether the file at path sits in a page bundle, a
+// subdirectory of the watched root that contains an index.md, or in any
+// directory below one, since bundles publish their nested files too.
+func (w *Watcher) isBundleAsset(path string) bool {
+	root := filepath.Clean(w.path)
+	for dir := filepath.Dir(path); dir != root; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "index.md")); err == nil {
+			return true
+		}
+		if filepath.Dir(dir) == dir {
+			// Reached the top of the filesystem without passing the root
+			return false
+		}
+	}
+	return false
+}
+
+// schemaFile is the name of the front matter schema the generator reads from
+// the root of the posts directory.
+const schemaFile = "schema.yaml"
+
+// isSchema reports whether path is the front matter schema in the watched
+// root.
+func (w *Watcher) isSchema(path string) bool {
+	return filepath.Clean(path) == filepath.Join(w.path, schemaFile)
+}
+
 // addDirs walks path and adds every directory (including path itself) to the
 // fsnotify watcher. Returns an error if path is not a directory or if any
 // Add call fails.
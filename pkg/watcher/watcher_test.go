@@ -226,6 +226,101 @@ func TestRun_NonMarkdownIgnored(t *testing.T) {
 	}
 }
 
+// TestRun_BundleAssetChange verifies that changes to files next to a page
+// bundle's index.md trigger onChange.
+func TestRun_BundleAssetChange(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "my-post")
+	if err := os.Mkdir(bundle, 0o755); err != nil {
+		t.Fatalf("Mkdir error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(bundle, "index.md"), []byte("hello"), 0o644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+
+	w, err := watcher.New(dir, config.WithDebounce(shortDebounce))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	var count atomic.Int64
+	go w.Run(ctx, func(context.Context) { count.Add(1) }) //nolint:errcheck
+
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.WriteFile(filepath.Join(bundle, "diagram.png"), []byte("png"), 0o644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+
+	waitForCount(t, &count, 1, 3*time.Second)
+}
+
+// TestRun_NestedBundleAssetChange verifies that changes to files in a
+// subdirectory of a page bundle trigger onChange.
+func TestRun_NestedBundleAssetChange(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "my-post")
+	nested := filepath.Join(bundle, "images", "large")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("MkdirAll error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(bundle, "index.md"), []byte("hello"), 0o644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+
+	w, err := watcher.New(dir, config.WithDebounce(shortDebounce))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	var count atomic.Int64
+	go w.Run(ctx, func(context.Context) { count.Add(1) }) //nolint:errcheck
+
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.WriteFile(filepath.Join(nested, "photo.png"), []byte("png"), 0o644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+
+	waitForCount(t, &count, 1, 3*time.Second)
+}
+
+// TestRun_SchemaChange verifies that changes to the schema.yaml in the root
+// trigger onChange.
+func TestRun_SchemaChange(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	w, err := watcher.New(dir, config.WithDebounce(shortDebounce))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	var count atomic.Int64
+	go w.Run(ctx, func(context.Context) { count.Add(1) }) //nolint:errcheck
+
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.WriteFile(filepath.Join(dir, "schema.yaml"), []byte("fields: {}"), 0o644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+
+	waitForCount(t, &count, 1, 3*time.Second)
+}
+
 // TestRun_SubdirRemoveThenRecreateTracked verifies that removing a watched
 // subdirectory and recreating it still triggers onChange when a file is written
 // inside the new directory.